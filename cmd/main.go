@@ -2,81 +2,317 @@ package main
 
 import (
 	"context"
-	"log"
+	"errors"
+	"log/slog"
 	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/banking-superapp/upi-service/config"
 	"github.com/banking-superapp/upi-service/handler"
+	"github.com/banking-superapp/upi-service/logging"
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/ratelimit"
 	"github.com/banking-superapp/upi-service/repository"
+	"github.com/banking-superapp/upi-service/secure"
 	"github.com/banking-superapp/upi-service/service"
+	"github.com/banking-superapp/upi-service/tracing"
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.mongodb.org/mongo-driver/v2/mongo/readpref"
 )
 
 func main() {
 	cfg := config.Load()
+	logging.Setup(os.Stdout, cfg.LogLevel)
+
+	shutdownTracing, err := tracing.Setup(cfg.ServiceName, cfg.TraceExporter, cfg.TraceFile, cfg.TraceSampleRatio)
+	if err != nil {
+		fatal("Tracing setup failed", err)
+	}
+
+	// Keys are needed before anything is read, migrations included.
+	if err := secure.Setup(cfg.EncryptionKeyFile, cfg.EncryptionDisabled); err != nil {
+		fatal("Failed to load encryption keys", err)
+	}
 
 	mongoClient, err := repository.NewMongoClient(cfg.MongoAtlasURI)
 	if err != nil {
-		log.Fatalf("MongoDB connection failed: %v", err)
+		fatal("MongoDB connection failed", err)
 	}
 	defer mongoClient.Disconnect(context.Background())
 
 	db := mongoClient.Database("banking_upi")
-	if err := repository.CreateIndexes(db); err != nil {
-		log.Fatalf("Failed to create indexes: %v", err)
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrate(context.Background(), db, os.Args[2:]); err != nil {
+			fatal("Migration failed", err)
+		}
+		return
+	}
+
+	// Migrations can take a while, so the server listens first and reports
+	// not ready until they finish.
+	var migrated atomic.Bool
+	heartbeats := service.NewHeartbeats()
+	readiness := service.NewReadiness()
+	readiness.Add("mongo", func(ctx context.Context) error { return mongoClient.Ping(ctx, readpref.Primary()) })
+	readiness.Add("migrations", func(context.Context) error {
+		if !migrated.Load() {
+			return errors.New("migrations pending")
+		}
+		return nil
+	})
+	readiness.Add("workers", heartbeats.Check)
+	if cfg.SwitchHealthURL != "" {
+		readiness.Add("switch", service.HTTPCheck(cfg.SwitchHealthURL))
 	}
 
 	vpaRepo := repository.NewVPARepo(db)
 	txnRepo := repository.NewTxnRepo(db)
 	mandateRepo := repository.NewMandateRepo(db)
 	collectRepo := repository.NewCollectRepo(db)
+	orderRepo := repository.NewMerchantOrderRepo(db)
+	merchantRepo := repository.NewMerchantRepo(db)
+	settlementRepo := repository.NewSettlementRepo(db)
+	payoutRepo := repository.NewPayoutRepo(db)
+	splitRepo := repository.NewSplitRepo(db)
+	scheduleRepo := repository.NewScheduleRepo(db)
+	ledgerRepo := repository.NewLedgerRepo(db)
+	statementRepo := repository.NewStatementRepo(db)
+	overrideRepo := repository.NewCategoryOverrideRepo(db)
+	budgetRepo := repository.NewBudgetRepo(db)
+	beneficiaryRepo := repository.NewBeneficiaryRepo(db)
+	upiNumberRepo := repository.NewUPINumberRepo(db)
+	liteWalletRepo := repository.NewLiteWalletRepo(db)
+	fundingRepo := repository.NewFundingSourceRepo(db)
+	auditTrailRepo := repository.NewAuditTrailRepo(db)
+	privacyRepo := repository.NewPrivacyRepo(db)
+
+	qrSigner, err := service.LoadQRSigner(cfg.QRSigningKeyFile, cfg.QRTrustedKeyFiles)
+	if err != nil {
+		fatal("Failed to load QR keys", err)
+	}
+
+	webhooks, err := service.NewWebhookSender(cfg.WebhookSecret)
+	if err != nil {
+		fatal("Webhook setup failed", err)
+	}
+	notifier := service.NewLogNotifier()
+	preauth, err := service.NewPreAuthorizer(cfg.PreAuthSecret)
+	if err != nil {
+		fatal("Pre-authorisation setup failed", err)
+	}
 
-	upiSvc := service.NewUPIService(vpaRepo, txnRepo, mandateRepo, collectRepo)
+	pins := service.NewPINVerifier()
+	auditSvc := service.NewAuditService(auditTrailRepo)
+	budgetSvc := service.NewBudgetService(vpaRepo, txnRepo, budgetRepo, notifier)
+	liteSvc := service.NewLiteService(vpaRepo, liteWalletRepo, ledgerRepo, pins, preauth)
+	fundingSvc := service.NewFundingService(fundingRepo, vpaRepo, ledgerRepo, pins)
+	upiSvc := service.TraceUPIService(service.NewUPIService(vpaRepo, txnRepo, mandateRepo, collectRepo, orderRepo, merchantRepo, splitRepo, ledgerRepo, beneficiaryRepo, upiNumberRepo, liteSvc, fundingSvc, service.NewCategorizer(overrideRepo), budgetSvc, webhooks, auditSvc))
+	qrSvc := service.NewQRService(vpaRepo, merchantRepo, qrSigner)
+	orderSvc := service.NewOrderService(vpaRepo, orderRepo, merchantRepo, qrSigner, webhooks)
+	merchantSvc := service.NewMerchantService(vpaRepo, txnRepo, merchantRepo, settlementRepo, auditSvc)
+	payoutSvc := service.NewPayoutService(payoutRepo, merchantRepo, txnRepo, upiSvc, cfg.PayoutConcurrency, processOwner())
+	splitSvc := service.NewSplitService(vpaRepo, collectRepo, splitRepo, notifier)
+	scheduleSvc := service.NewScheduleService(scheduleRepo, upiSvc, pins, preauth)
+	statementSvc := service.NewStatementService(ledgerRepo, statementRepo)
+	insightsSvc := service.NewInsightsService(vpaRepo, txnRepo, overrideRepo)
+	beneficiarySvc := service.NewBeneficiaryService(vpaRepo, txnRepo, beneficiaryRepo, upiSvc)
+	upiNumberSvc := service.NewUPINumberService(vpaRepo, upiNumberRepo)
+	periods := model.RetentionPeriods{Notes: cfg.RetentionNotes, Names: cfg.RetentionNames, DeviceData: cfg.RetentionDeviceData}
+	privacySvc := service.NewPrivacyService(privacyRepo, auditSvc, periods)
 	upiHandler := handler.NewUPIHandler(upiSvc)
+	qrHandler := handler.NewQRHandler(qrSvc)
+	orderHandler := handler.NewOrderHandler(orderSvc)
+	merchantHandler := handler.NewMerchantHandler(merchantSvc)
+	payoutHandler := handler.NewPayoutHandler(payoutSvc)
+	splitHandler := handler.NewSplitHandler(splitSvc)
+	scheduleHandler := handler.NewScheduleHandler(scheduleSvc)
+	statementHandler := handler.NewStatementHandler(statementSvc)
+	insightsHandler := handler.NewInsightsHandler(insightsSvc)
+	budgetHandler := handler.NewBudgetHandler(budgetSvc)
+	beneficiaryHandler := handler.NewBeneficiaryHandler(beneficiarySvc)
+	upiNumberHandler := handler.NewUPINumberHandler(upiNumberSvc)
+	liteHandler := handler.NewLiteHandler(liteSvc)
+	fundingHandler := handler.NewFundingHandler(fundingSvc)
+	healthHandler := handler.NewHealthHandler(readiness)
+	auditHandler := handler.NewAuditHandler(auditSvc)
+	privacyHandler := handler.NewPrivacyHandler(privacySvc)
 
+	rules, err := ratelimit.ParseRules(cfg.RateLimits)
+	if err != nil {
+		fatal("Invalid RATE_LIMITS", err)
+	}
+	var limitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.RateLimitStore == "mongo" {
+		limitStore = ratelimit.NewMongoStore(db)
+	}
+	limiter := ratelimit.New(limitStore, rules)
+
+	if (cfg.ProxyHeader == "") != (len(cfg.TrustedProxies) == 0) {
+		fatal("Invalid proxy settings", errors.New("PROXY_HEADER and TRUSTED_PROXIES must be set together"))
+	}
 	app := fiber.New(fiber.Config{
-		AppName:      cfg.ServiceName,
-		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 30 * time.Second,
+		AppName:                 cfg.ServiceName,
+		ReadTimeout:             30 * time.Second,
+		WriteTimeout:            30 * time.Second,
+		ProxyHeader:             cfg.ProxyHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          cfg.TrustedProxies,
+		EnableIPValidation:      true,
 	})
 
 	app.Use(recover.New())
 	app.Use(requestid.New())
-	app.Use(logger.New())
+	app.Use(handler.Trace())
+	app.Use(handler.LogRequests())
+	app.Use(handler.RecordMetrics())
+	app.Use(handler.AttributeChanges())
 
 	app.Get("/health", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{"status": "ok", "service": cfg.ServiceName})
 	})
+	app.Get("/livez", healthHandler.Livez)
+	app.Get("/readyz", healthHandler.Readyz)
 
-	v1 := app.Group("/v1")
+	app.Get("/metrics", adaptor.HTTPHandler(promhttp.Handler()))
+
+	v1 := app.Group("/v1", handler.RateLimit(limiter))
 	upi := v1.Group("/upi")
 	upi.Post("/vpa/create", upiHandler.CreateVPA)
 	upi.Get("/vpa", upiHandler.GetVPAs)
 	upi.Post("/validate", upiHandler.ValidateVPA)
 	upi.Post("/pay", upiHandler.Pay)
 	upi.Post("/collect", upiHandler.Collect)
+	upi.Get("/collect/pending", upiHandler.GetPendingCollects)
+	upi.Post("/collect/:collectId/approve", upiHandler.ApproveCollect)
+	upi.Post("/collect/:collectId/decline", upiHandler.DeclineCollect)
 	upi.Get("/transactions", upiHandler.GetTransactions)
 	upi.Post("/mandate/create", upiHandler.CreateMandate)
 	upi.Get("/mandate", upiHandler.GetMandates)
+	upi.Post("/qr/generate", qrHandler.GenerateQR)
+	upi.Post("/qr/parse", qrHandler.ParseQR)
+	upi.Post("/orders", orderHandler.CreateOrder)
+	upi.Get("/orders/callback-key", orderHandler.CallbackKey)
+	upi.Get("/orders/:orderId", orderHandler.GetOrder)
+	upi.Post("/merchants", merchantHandler.OnboardMerchant)
+	upi.Get("/merchants", merchantHandler.GetMerchants)
+	upi.Post("/merchants/:merchantId/settlements", merchantHandler.GenerateSettlement)
+	upi.Get("/merchants/:merchantId/settlements", merchantHandler.GetSettlements)
+	upi.Post("/payouts/batches", payoutHandler.CreateBatch)
+	upi.Get("/payouts/batches/:batchId", payoutHandler.GetBatch)
+	upi.Get("/payouts/batches/:batchId/rows", payoutHandler.GetRows)
+	upi.Get("/payouts/batches/:batchId/results.csv", payoutHandler.DownloadResults)
+	upi.Post("/splits", splitHandler.CreateSplit)
+	upi.Get("/splits", splitHandler.GetSplits)
+	upi.Get("/splits/:splitId", splitHandler.GetSplit)
+	upi.Post("/splits/:splitId/remind", splitHandler.Remind)
+	upi.Post("/schedules", scheduleHandler.CreateSchedule)
+	upi.Get("/schedules", scheduleHandler.GetSchedules)
+	upi.Get("/schedules/:scheduleId/runs", scheduleHandler.GetRuns)
+	upi.Post("/schedules/:scheduleId/pause", scheduleHandler.Pause)
+	upi.Post("/schedules/:scheduleId/resume", scheduleHandler.Resume)
+	upi.Post("/schedules/:scheduleId/skip", scheduleHandler.SkipNext)
+	upi.Delete("/schedules/:scheduleId", scheduleHandler.Cancel)
+	upi.Post("/statements", statementHandler.RequestStatement)
+	upi.Get("/statements/:jobId", statementHandler.GetJob)
+	upi.Get("/statements/:jobId/download", statementHandler.Download)
+	upi.Get("/insights/categories", insightsHandler.SpendByCategory)
+	upi.Get("/insights/payees", insightsHandler.TopPayees)
+	upi.Get("/insights/cashflow", insightsHandler.CashFlow)
+	upi.Get("/categories", insightsHandler.GetCategories)
+	upi.Get("/categories/overrides", insightsHandler.GetOverrides)
+	upi.Put("/categories/overrides", insightsHandler.SetOverride)
+	upi.Delete("/categories/overrides/:payeeVpa", insightsHandler.DeleteOverride)
+	upi.Put("/budgets", budgetHandler.SetBudget)
+	upi.Get("/budgets", budgetHandler.GetUtilization)
+	upi.Delete("/budgets/:category", budgetHandler.DeleteBudget)
+	upi.Post("/beneficiaries", beneficiaryHandler.CreateBeneficiary)
+	upi.Get("/beneficiaries", beneficiaryHandler.GetBeneficiaries)
+	upi.Get("/beneficiaries/suggestions", beneficiaryHandler.SuggestPayees)
+	upi.Patch("/beneficiaries/:beneficiaryId", beneficiaryHandler.UpdateBeneficiary)
+	upi.Delete("/beneficiaries/:beneficiaryId", beneficiaryHandler.DeleteBeneficiary)
+	upi.Post("/numbers", upiNumberHandler.Register)
+	upi.Get("/numbers", upiNumberHandler.GetNumbers)
+	upi.Post("/numbers/:number/port", upiNumberHandler.Port)
+	upi.Delete("/numbers/:number", upiNumberHandler.Deregister)
+	upi.Post("/lite", liteHandler.Enable)
+	upi.Get("/lite", liteHandler.GetWallet)
+	upi.Post("/lite/topup", liteHandler.TopUp)
+	upi.Put("/lite/auto-topup", liteHandler.SetAutoTopUp)
+	upi.Delete("/lite/auto-topup", liteHandler.DisableAutoTopUp)
+	upi.Post("/funding-sources", fundingHandler.Link)
+	upi.Get("/funding-sources", fundingHandler.List)
+	upi.Delete("/funding-sources/:id", fundingHandler.Unlink)
+	upi.Post("/funding-sources/:id/repay", fundingHandler.Repay)
+	upi.Post("/privacy/erasure", privacyHandler.RequestErasure)
+	upi.Get("/privacy/erasure/:erasureId", privacyHandler.GetErasure)
+
+	admin := v1.Group("/admin", handler.RequireAdminKey(cfg.AdminAPIKey))
+	admin.Post("/merchants/:merchantId/verify", merchantHandler.VerifyMerchant)
+	admin.Post("/users/:userId/statements", statementHandler.RequestStatement)
+	admin.Get("/lite/reconciliation", liteHandler.Reconcile)
+	admin.Get("/audit", auditHandler.Query)
+	admin.Get("/audit/verify", auditHandler.Verify)
+	admin.Get("/users/:userId/statements/:jobId", statementHandler.GetJob)
+	admin.Get("/users/:userId/statements/:jobId/download", statementHandler.Download)
+	admin.Post("/users/:userId/erasure", privacyHandler.RequestErasure)
+	admin.Get("/users/:userId/erasure/:erasureId", privacyHandler.GetErasure)
+	admin.Get("/retention/runs", privacyHandler.RetentionRuns)
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
 	go func() {
-		log.Printf("Starting %s on port %s", cfg.ServiceName, cfg.Port)
+		slog.Info("Starting server", "service", cfg.ServiceName, "port", cfg.Port)
 		if err := app.Listen(":" + cfg.Port); err != nil {
-			log.Fatalf("Server error: %v", err)
+			fatal("Server error", err)
 		}
 	}()
 
+	if err := awaitMigrations(context.Background(), db, cfg.MigrateOnStart); err != nil {
+		fatal("Migrations failed", err)
+	}
+	migrated.Store(true)
+	if err := payoutSvc.ResumeBatches(context.Background()); err != nil {
+		slog.Error("Failed to resume payout batches", "error", err)
+	}
+
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	go service.RunPeriodically(workerCtx, heartbeats, "audit trail", 2*time.Second, auditSvc.Drain)
+	go service.RunPeriodically(workerCtx, heartbeats, "splits", time.Hour, splitSvc.ProcessOpenSplits)
+	go service.RunPeriodically(workerCtx, heartbeats, "schedules", time.Minute, scheduleSvc.ExecuteDue)
+	go service.RunPeriodically(workerCtx, heartbeats, "statements", 15*time.Second, statementSvc.ProcessQueued)
+	go service.RunPeriodically(workerCtx, heartbeats, "lite reconciliation", time.Hour, liteSvc.CheckBalances)
+	go service.RunPeriodically(workerCtx, heartbeats, "payout recovery", 10*time.Minute, payoutSvc.ResumeBatches)
+	go service.RunPeriodically(workerCtx, heartbeats, "collect recovery", 5*time.Minute, upiSvc.RecoverCollects)
+	go service.RunPeriodically(workerCtx, heartbeats, "erasures", time.Minute, privacySvc.ProcessQueued)
+	go service.RunPeriodically(workerCtx, heartbeats, "retention", 24*time.Hour, func(ctx context.Context) error {
+		_, err := privacySvc.ApplyRetention(ctx, false)
+		return err
+	})
+
 	<-quit
+	// Fail readiness first and give load balancers time to notice before
+	// in-flight requests are drained.
+	readiness.Drain()
+	slog.Info("Draining before shutdown", "delay", cfg.ShutdownDrainDelay.String())
+	time.Sleep(cfg.ShutdownDrainDelay)
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	_ = app.ShutdownWithContext(ctx)
+	_ = shutdownTracing(ctx)
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
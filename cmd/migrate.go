@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/banking-superapp/upi-service/migrations"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const migrateUsage = `usage: upi-service migrate <command> [flags]
+
+commands:
+  status            list migrations and when each was applied
+  up   [-to N]      apply pending migrations, up to version N if given
+  down [-steps N]   roll back the last N applied migrations (default 1)
+
+flags:
+  -dry-run          report what would change without changing it
+`
+
+// migrate runs the migrate subcommand against db.
+func migrate(ctx context.Context, db *mongo.Database, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return errors.New("missing migrate command")
+	}
+	fs := flag.NewFlagSet("migrate "+args[0], flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "report what would change without changing it")
+	to := fs.Int("to", 0, "highest version to apply")
+	steps := fs.Int("steps", 1, "number of migrations to roll back")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	runner := migrations.NewRunner(db, migrations.All, processOwner(), *dryRun)
+	switch args[0] {
+	case "status":
+		statuses, err := runner.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT\tBY")
+		for _, s := range statuses {
+			at, by := "pending", ""
+			if s.Applied != nil {
+				at, by = s.Applied.AppliedAt.Format(time.RFC3339), s.Applied.AppliedBy
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Version, s.Name, at, by)
+		}
+		return w.Flush()
+	case "up":
+		done, err := runner.Up(ctx, *to)
+		printSteps(done, *dryRun)
+		return err
+	case "down":
+		done, err := runner.Down(ctx, *steps)
+		printSteps(done, *dryRun)
+		return err
+	}
+	fmt.Fprint(os.Stderr, migrateUsage)
+	return fmt.Errorf("unknown migrate command %q", args[0])
+}
+
+func printSteps(steps []migrations.Step, dryRun bool) {
+	out := map[string]interface{}{"dry_run": dryRun, "steps": steps}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(out)
+}
+
+// awaitMigrations blocks until every known migration is applied. With apply
+// set it applies them itself, waiting while another replica holds the lock;
+// otherwise it waits for someone else to.
+func awaitMigrations(ctx context.Context, db *mongo.Database, apply bool) error {
+	runner := migrations.NewRunner(db, migrations.All, processOwner(), false)
+	for {
+		if apply {
+			if _, err := runner.Up(ctx, 0); err != nil && !errors.Is(err, migrations.ErrLocked) {
+				return err
+			}
+		}
+		statuses, err := runner.Status(ctx)
+		if err != nil {
+			return err
+		}
+		pending := 0
+		for _, s := range statuses {
+			if s.Applied == nil {
+				pending++
+			}
+		}
+		if pending == 0 {
+			return nil
+		}
+		slog.InfoContext(ctx, "waiting for migrations", "pending", pending)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// processOwner names this process in leases and the records it writes.
+func processOwner() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s/%d", host, os.Getpid())
+}
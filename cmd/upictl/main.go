@@ -0,0 +1,350 @@
+// Command upictl is the operator CLI for the UPI service. Every command,
+// including lookups and exports, is written to the admin audit log.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"os/user"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/banking-superapp/upi-service/config"
+	"github.com/banking-superapp/upi-service/logging"
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/repository"
+	"github.com/banking-superapp/upi-service/secure"
+	"github.com/banking-superapp/upi-service/service"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const usage = `usage: upictl <command> [flags] [args]
+
+commands:
+  txn <txn-id|rrn>                                 show a transaction and its ledger entries
+  vpas-by-account <account-id>                     list the VPAs linked to a bank account
+  resolve -reason R <txn-id> success|failed         force-resolve a pending transaction
+  deactivate-vpa -reason R <vpa>                    deactivate a VPA
+  revoke-mandate -reason R <mandate-id>             revoke an active or paused mandate
+  reconcile lite                                    re-run UPI Lite wallet reconciliation
+  export -user ID -from DATE -to DATE txns|ledger   write JSON lines to stdout (IST dates, inclusive)
+  audit [-target T] [-limit N]                      list recent audit entries
+  reencrypt [-dry-run]                             encrypt clear values and rewrap ones under old keys
+  retention [-dry-run]                             apply the retention policies and print what they changed
+`
+
+type cli struct {
+	db        *mongo.Database
+	ops       service.OpsService
+	privacy   service.PrivacyService
+	audit     repository.AuditRepo
+	actor     string
+	host      string
+	requestID string
+}
+
+func main() {
+	cfg := config.Load()
+	logging.Setup(os.Stderr, cfg.LogLevel)
+
+	global := flag.NewFlagSet("upictl", flag.ExitOnError)
+	global.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	global.Parse(os.Args[1:])
+	if global.NArg() == 0 {
+		global.Usage()
+		os.Exit(2)
+	}
+	actor, err := operator()
+	if err != nil {
+		fatal("Cannot identify the operator", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := secure.Setup(cfg.EncryptionKeyFile, cfg.EncryptionDisabled); err != nil {
+		fatal("Failed to load encryption keys", err)
+	}
+
+	mongoClient, err := repository.NewMongoClient(cfg.MongoAtlasURI)
+	if err != nil {
+		fatal("MongoDB connection failed", err)
+	}
+	defer mongoClient.Disconnect(context.Background())
+	db := mongoClient.Database("banking_upi")
+
+	vpaRepo := repository.NewVPARepo(db)
+	ledgerRepo := repository.NewLedgerRepo(db)
+	preauth, err := service.NewPreAuthorizer(cfg.PreAuthSecret)
+	if err != nil {
+		fatal("Pre-authorisation setup failed", err)
+	}
+	pins := service.NewPINVerifier()
+	liteSvc := service.NewLiteService(vpaRepo, repository.NewLiteWalletRepo(db), ledgerRepo, pins, preauth)
+	fundingSvc := service.NewFundingService(repository.NewFundingSourceRepo(db), vpaRepo, ledgerRepo, pins)
+	auditSvc := service.NewAuditService(repository.NewAuditTrailRepo(db))
+	periods := model.RetentionPeriods{Notes: cfg.RetentionNotes, Names: cfg.RetentionNames, DeviceData: cfg.RetentionDeviceData}
+	host, _ := os.Hostname()
+	c := &cli{
+		db:        db,
+		ops:       service.NewOpsService(vpaRepo, repository.NewTxnRepo(db), repository.NewMandateRepo(db), repository.NewMerchantRepo(db), ledgerRepo, liteSvc, fundingSvc, auditSvc),
+		privacy:   service.NewPrivacyService(repository.NewPrivacyRepo(db), auditSvc, periods),
+		audit:     repository.NewAuditRepo(db),
+		actor:     actor,
+		host:      host,
+		requestID: uuid.NewString(),
+	}
+
+	// Changes this invocation makes appear in the audit trail under the
+	// operator, with the request ID of its admin audit log entry.
+	ctx = service.WithAuditActor(ctx, model.AuditActor{Type: "operator", ID: c.actor, RequestID: c.requestID})
+	ctx = logging.With(ctx, "actor", c.actor, "request_id", c.requestID)
+	if err := c.run(ctx, global.Arg(0), global.Args()[1:]); err != nil {
+		fatal(global.Arg(0)+" failed", err)
+	}
+}
+
+func (c *cli) run(ctx context.Context, cmd string, args []string) error {
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	reason := fs.String("reason", "", "why the action is being taken")
+	user := fs.String("user", "", "user ID to export")
+	from := fs.String("from", "", "first day to export, YYYY-MM-DD")
+	to := fs.String("to", "", "last day to export, YYYY-MM-DD")
+	target := fs.String("target", "", "only audit entries for this target")
+	limit := fs.Int64("limit", 50, "number of audit entries to list")
+	dryRun := fs.Bool("dry-run", false, "count what reencrypt or retention would change without changing it")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+
+	switch cmd {
+	case "txn":
+		if len(args) != 1 {
+			break
+		}
+		return c.audited(ctx, "txn.lookup", args[0], nil, "", func() error {
+			txn, entries, err := c.ops.FindTransaction(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			return printJSON(map[string]interface{}{"transaction": txn, "ledger": entries})
+		})
+	case "vpas-by-account":
+		if len(args) != 1 {
+			break
+		}
+		// The account number is kept out of the audit log; its blind index identifies it.
+		return c.audited(ctx, "vpa.lookup_by_account", secure.Fingerprint(args[0]), nil, "", func() error {
+			vpas, err := c.ops.FindVPAsByAccount(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			return printJSON(vpas)
+		})
+	case "reencrypt":
+		params := map[string]string{"dry_run": strconv.FormatBool(*dryRun), "key_id": secure.CurrentKeyID()}
+		return c.audited(ctx, "fields.reencrypt", "", params, "", func() error {
+			counts, err := repository.Reencrypt(ctx, c.db, *dryRun)
+			if perr := printJSON(map[string]interface{}{"dry_run": *dryRun, "rewritten": counts}); err == nil {
+				err = perr
+			}
+			return err
+		})
+	case "retention":
+		params := map[string]string{"dry_run": strconv.FormatBool(*dryRun)}
+		return c.audited(ctx, "retention.apply", "", params, "", func() error {
+			run, err := c.privacy.ApplyRetention(ctx, *dryRun)
+			if perr := printJSON(run); err == nil {
+				err = perr
+			}
+			return err
+		})
+	case "resolve":
+		if len(args) != 2 || *reason == "" {
+			break
+		}
+		params := map[string]string{"status": args[1]}
+		return c.audited(ctx, "txn.resolve", args[0], params, *reason, func() error {
+			txn, err := c.ops.ResolveTransaction(ctx, args[0], args[1], *reason)
+			if err != nil {
+				return err
+			}
+			return printJSON(txn)
+		})
+	case "deactivate-vpa":
+		if len(args) != 1 || *reason == "" {
+			break
+		}
+		return c.audited(ctx, "vpa.deactivate", args[0], nil, *reason, func() error {
+			return c.ops.DeactivateVPA(ctx, args[0])
+		})
+	case "revoke-mandate":
+		if len(args) != 1 || *reason == "" {
+			break
+		}
+		return c.audited(ctx, "mandate.revoke", args[0], nil, *reason, func() error {
+			m, err := c.ops.RevokeMandate(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			return printJSON(m)
+		})
+	case "reconcile":
+		if len(args) != 1 || args[0] != "lite" {
+			break
+		}
+		return c.audited(ctx, "reconcile.lite", "", nil, "", func() error {
+			mismatches, err := c.ops.ReconcileLite(ctx)
+			if err != nil {
+				return err
+			}
+			return printJSON(map[string]interface{}{"mismatches": mismatches})
+		})
+	case "export":
+		if len(args) != 1 || *user == "" {
+			break
+		}
+		start, end, err := dateRange(*from, *to)
+		if err != nil {
+			return err
+		}
+		params := map[string]string{"kind": args[0], "from": *from, "to": *to}
+		return c.audited(ctx, "export."+args[0], *user, params, "", func() error {
+			switch args[0] {
+			case "txns":
+				txns, err := c.ops.ExportTransactions(ctx, *user, start, end)
+				if err != nil {
+					return err
+				}
+				return printLines(txns)
+			case "ledger":
+				entries, err := c.ops.ExportLedger(ctx, *user, start, end)
+				if err != nil {
+					return err
+				}
+				return printLines(entries)
+			}
+			return fmt.Errorf("unknown export %q", args[0])
+		})
+	case "audit":
+		params := map[string]string{"limit": strconv.FormatInt(*limit, 10)}
+		return c.audited(ctx, "audit.list", *target, params, "", func() error {
+			entries, err := c.audit.FindRecent(ctx, *target, *limit)
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "AT\tACTOR\tACTION\tTARGET\tOUTCOME\tREASON")
+			for _, e := range entries {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", e.At.Format(time.RFC3339), e.Actor, e.Action, e.Target, e.Outcome, e.Reason)
+			}
+			return w.Flush()
+		})
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown command %q", cmd)
+	}
+	fmt.Fprint(os.Stderr, usage)
+	return fmt.Errorf("bad arguments for %s", cmd)
+}
+
+// audited records the action as started, runs fn and then records its
+// outcome, so an action that crashes upictl still leaves an entry. Nothing
+// is done if the first write fails; an action whose outcome cannot be
+// written is reported as failed even if it took effect.
+func (c *cli) audited(ctx context.Context, action, target string, params map[string]string, reason string, fn func() error) error {
+	entry := &model.AuditEntry{
+		Actor:     c.actor,
+		Host:      c.host,
+		RequestID: c.requestID,
+		Action:    action,
+		Target:    target,
+		Params:    params,
+		Reason:    reason,
+		Outcome:   "started",
+	}
+	if err := c.audit.Record(ctx, entry); err != nil {
+		return fmt.Errorf("recording audit entry: %w", err)
+	}
+
+	err := fn()
+	outcome, errMsg := "ok", ""
+	if err != nil {
+		outcome, errMsg = "error", err.Error()
+	}
+	// Record even when ctx was cancelled mid-action.
+	if auditErr := c.audit.Finish(context.WithoutCancel(ctx), entry.ID, outcome, errMsg); auditErr != nil {
+		slog.ErrorContext(ctx, "audit write failed", "action", action, "target", target, "error", auditErr)
+		return errors.Join(err, fmt.Errorf("recording audit outcome: %w", auditErr))
+	}
+	slog.InfoContext(ctx, "action recorded", "action", action, "target", target, "outcome", outcome)
+	return err
+}
+
+// operator names the OS account running upictl, or the account that ran it
+// through sudo, rather than anything the caller can pass in. In production:
+// operators reach the hosts through SSO, so every account is a person.
+func operator() (string, error) {
+	uid := os.Getuid()
+	if uid == 0 {
+		// Only root can set SUDO_UID, and sudo always does.
+		sudo, err := strconv.Atoi(os.Getenv("SUDO_UID"))
+		if err != nil {
+			return "", errors.New("run upictl from a personal account, not root")
+		}
+		uid = sudo
+	}
+	u, err := user.LookupId(strconv.Itoa(uid))
+	if err != nil {
+		return "", err
+	}
+	return u.Username, nil
+}
+
+// dateRange turns inclusive IST dates into [start, end) bounds, in the zone
+// statements use so exports line up with them.
+func dateRange(from, to string) (time.Time, time.Time, error) {
+	start, err := service.ParseDate(from)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid -from: %w", err)
+	}
+	end, err := service.ParseDate(to)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid -to: %w", err)
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, errors.New("-to is before -from")
+	}
+	return start, end.AddDate(0, 0, 1), nil
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func printLines[T any](rows []T) error {
+	enc := json.NewEncoder(os.Stdout)
+	for _, r := range rows {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
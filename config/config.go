@@ -1,22 +1,111 @@
 package config
 
-import "github.com/spf13/viper"
+import (
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
 
 type Config struct {
 	Port          string
 	MongoAtlasURI string
 	ServiceName   string
 	LogLevel      string
+	AdminAPIKey   string // guards operator only endpoints, which are disabled when empty
+
+	QRSigningKeyFile  string   // PEM encoded ECDSA private key used to sign merchant QRs
+	QRTrustedKeyFiles []string // PEM encoded public keys of other QR issuers we accept
+
+	PayoutConcurrency int
+	PreAuthSecret     string // HMAC key for scheduled payment pre-authorisation tokens
+	WebhookSecret     string // merchant callback signing keys are derived from it
+
+	MigrateOnStart     bool          // when false, start up waits for migrations run with `migrate up`
+	SwitchHealthURL    string        // readiness probes the UPI switch adapter here when set
+	ShutdownDrainDelay time.Duration // how long /readyz fails before the server stops
+
+	TraceExporter    string // stdout | file; tracing is off when empty
+	TraceFile        string
+	TraceSampleRatio float64
+
+	RateLimitStore string // memory | mongo; mongo shares limits between replicas
+	RateLimits     string // rules in ratelimit.ParseRules syntax
+
+	// Behind a load balancer, the header it sets to the client's address
+	// (replacing any the client sent) and the addresses it connects from.
+	// Without them the balancer's address stands in for every client.
+	ProxyHeader    string
+	TrustedProxies []string
+
+	EncryptionKeyFile  string // JSON key file for field encryption; required unless EncryptionDisabled
+	EncryptionDisabled bool   // local development only: store sensitive fields in clear without a key file
+
+	// How long personal data is kept before retention clears it; 0 keeps it.
+	RetentionNotes      time.Duration
+	RetentionNames      time.Duration
+	RetentionDeviceData time.Duration
 }
 
 func Load() *Config {
 	viper.AutomaticEnv()
 	viper.SetDefault("PORT", "8080")
 	viper.SetDefault("LOG_LEVEL", "info")
+	viper.SetDefault("PAYOUT_CONCURRENCY", 8)
+	viper.SetDefault("MIGRATE_ON_START", true)
+	viper.SetDefault("SHUTDOWN_DRAIN_DELAY", "5s")
+	viper.SetDefault("TRACE_FILE", "traces.jsonl")
+	viper.SetDefault("TRACE_SAMPLE_RATIO", 1.0)
+	viper.SetDefault("RATE_LIMIT_STORE", "memory")
+	viper.SetDefault("RATE_LIMITS", "POST /v1/upi/validate=user:20/m,device:20/m,ip:60/m;"+
+		"POST /v1/upi/pay=user:10/m,device:10/m,ip:60/m;"+
+		"*=user:300/m,ip:1200/m")
+	viper.SetDefault("RETENTION_NOTES", "8760h")
+	viper.SetDefault("RETENTION_NAMES", "8760h")
+	viper.SetDefault("RETENTION_DEVICE_DATA", "2160h")
 	return &Config{
 		Port:          viper.GetString("PORT"),
 		MongoAtlasURI: viper.GetString("MONGODB_ATLAS_URI"),
 		ServiceName:   viper.GetString("SERVICE_NAME"),
 		LogLevel:      viper.GetString("LOG_LEVEL"),
+		AdminAPIKey:   viper.GetString("ADMIN_API_KEY"),
+
+		QRSigningKeyFile:  viper.GetString("QR_SIGNING_KEY_FILE"),
+		QRTrustedKeyFiles: splitList(viper.GetString("QR_TRUSTED_KEY_FILES")),
+
+		PayoutConcurrency: viper.GetInt("PAYOUT_CONCURRENCY"),
+		PreAuthSecret:     viper.GetString("PREAUTH_SECRET"),
+		WebhookSecret:     viper.GetString("WEBHOOK_SECRET"),
+
+		MigrateOnStart:     viper.GetBool("MIGRATE_ON_START"),
+		SwitchHealthURL:    viper.GetString("SWITCH_HEALTH_URL"),
+		ShutdownDrainDelay: viper.GetDuration("SHUTDOWN_DRAIN_DELAY"),
+
+		TraceExporter:    viper.GetString("TRACE_EXPORTER"),
+		TraceFile:        viper.GetString("TRACE_FILE"),
+		TraceSampleRatio: viper.GetFloat64("TRACE_SAMPLE_RATIO"),
+
+		RateLimitStore: viper.GetString("RATE_LIMIT_STORE"),
+		RateLimits:     viper.GetString("RATE_LIMITS"),
+
+		ProxyHeader:    viper.GetString("PROXY_HEADER"),
+		TrustedProxies: splitList(viper.GetString("TRUSTED_PROXIES")),
+
+		EncryptionKeyFile:  viper.GetString("ENCRYPTION_KEY_FILE"),
+		EncryptionDisabled: viper.GetBool("ENCRYPTION_DISABLED"),
+
+		RetentionNotes:      viper.GetDuration("RETENTION_NOTES"),
+		RetentionNames:      viper.GetDuration("RETENTION_NAMES"),
+		RetentionDeviceData: viper.GetDuration("RETENTION_DEVICE_DATA"),
+	}
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
 	}
+	return out
 }
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuditHandler serves the audit trail to auditors under /admin.
+type AuditHandler struct {
+	svc service.AuditService
+}
+
+func NewAuditHandler(svc service.AuditService) *AuditHandler {
+	return &AuditHandler{svc: svc}
+}
+
+func (h *AuditHandler) Query(c *fiber.Ctx) error {
+	from, to, err := queryRange(c)
+	if err != nil {
+		return respond(c, fiber.StatusBadRequest, nil, err.Error())
+	}
+	q := &model.AuditQuery{
+		EntityType: c.Query("entity_type"),
+		EntityID:   c.Query("entity_id"),
+		ActorID:    c.Query("actor_id"),
+		Action:     c.Query("action"),
+		From:       from,
+		To:         to,
+	}
+	q.BeforeSeq, _ = strconv.ParseInt(c.Query("before_seq"), 10, 64)
+	limit, _ := strconv.ParseInt(c.Query("limit"), 10, 64)
+	events, err := h.svc.Query(c.UserContext(), q, limit)
+	if err != nil {
+		return auditError(c, err)
+	}
+	return respond(c, fiber.StatusOK, events, "")
+}
+
+func (h *AuditHandler) Verify(c *fiber.Ctx) error {
+	fromSeq, _ := strconv.ParseInt(c.Query("from_seq"), 10, 64)
+	limit, _ := strconv.ParseInt(c.Query("limit"), 10, 64)
+	result, err := h.svc.Verify(c.UserContext(), fromSeq, limit)
+	if err != nil {
+		return auditError(c, err)
+	}
+	return respond(c, fiber.StatusOK, result, "")
+}
+
+func auditError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, service.ErrInvalidAuditQuery) {
+		return respond(c, fiber.StatusBadRequest, nil, err.Error())
+	}
+	return respond(c, fiber.StatusInternalServerError, nil, err.Error())
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type BeneficiaryHandler struct {
+	svc service.BeneficiaryService
+}
+
+func NewBeneficiaryHandler(svc service.BeneficiaryService) *BeneficiaryHandler {
+	return &BeneficiaryHandler{svc: svc}
+}
+
+func (h *BeneficiaryHandler) CreateBeneficiary(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	var req model.CreateBeneficiaryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return respond(c, fiber.StatusBadRequest, nil, "invalid request body")
+	}
+	b, err := h.svc.CreateBeneficiary(c.UserContext(), userID, &req)
+	if err != nil {
+		return beneficiaryError(c, err)
+	}
+	return respond(c, fiber.StatusCreated, b, "")
+}
+
+// GetBeneficiaries accepts favorites=true to list only favorites.
+func (h *BeneficiaryHandler) GetBeneficiaries(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	list, err := h.svc.GetBeneficiaries(c.UserContext(), userID, c.QueryBool("favorites"))
+	if err != nil {
+		return beneficiaryError(c, err)
+	}
+	return respond(c, fiber.StatusOK, list, "")
+}
+
+func (h *BeneficiaryHandler) UpdateBeneficiary(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	var req model.UpdateBeneficiaryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return respond(c, fiber.StatusBadRequest, nil, "invalid request body")
+	}
+	b, err := h.svc.UpdateBeneficiary(c.UserContext(), userID, c.Params("beneficiaryId"), &req)
+	if err != nil {
+		return beneficiaryError(c, err)
+	}
+	return respond(c, fiber.StatusOK, b, "")
+}
+
+func (h *BeneficiaryHandler) DeleteBeneficiary(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	if err := h.svc.DeleteBeneficiary(c.UserContext(), userID, c.Params("beneficiaryId")); err != nil {
+		return beneficiaryError(c, err)
+	}
+	return respond(c, fiber.StatusOK, nil, "")
+}
+
+func (h *BeneficiaryHandler) SuggestPayees(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	limit, _ := strconv.ParseInt(c.Query("limit"), 10, 64)
+	suggestions, err := h.svc.SuggestPayees(c.UserContext(), userID, limit)
+	if err != nil {
+		return beneficiaryError(c, err)
+	}
+	return respond(c, fiber.StatusOK, suggestions, "")
+}
+
+func beneficiaryError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrVPANotFound), errors.Is(err, service.ErrInvalidNickname):
+		return respond(c, fiber.StatusBadRequest, nil, err.Error())
+	case errors.Is(err, service.ErrBeneficiaryNotFound):
+		return respond(c, fiber.StatusNotFound, nil, err.Error())
+	case errors.Is(err, service.ErrBeneficiaryExists):
+		return respond(c, fiber.StatusConflict, nil, err.Error())
+	}
+	return respond(c, fiber.StatusInternalServerError, nil, err.Error())
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type BudgetHandler struct {
+	svc service.BudgetService
+}
+
+func NewBudgetHandler(svc service.BudgetService) *BudgetHandler {
+	return &BudgetHandler{svc: svc}
+}
+
+func (h *BudgetHandler) SetBudget(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	var req model.SetBudgetRequest
+	if err := c.BodyParser(&req); err != nil {
+		return respond(c, fiber.StatusBadRequest, nil, "invalid request body")
+	}
+	budget, err := h.svc.SetBudget(c.UserContext(), userID, &req)
+	if err != nil {
+		return budgetError(c, err)
+	}
+	return respond(c, fiber.StatusOK, budget, "")
+}
+
+// GetUtilization accepts an optional month=YYYY-MM query parameter.
+func (h *BudgetHandler) GetUtilization(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	usage, err := h.svc.GetUtilization(c.UserContext(), userID, c.Query("month"))
+	if err != nil {
+		return budgetError(c, err)
+	}
+	return respond(c, fiber.StatusOK, usage, "")
+}
+
+func (h *BudgetHandler) DeleteBudget(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	if err := h.svc.DeleteBudget(c.UserContext(), userID, c.Params("category")); err != nil {
+		return budgetError(c, err)
+	}
+	return respond(c, fiber.StatusOK, nil, "")
+}
+
+func budgetError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidBudget), errors.Is(err, service.ErrInvalidMonth):
+		return respond(c, fiber.StatusBadRequest, nil, err.Error())
+	case errors.Is(err, service.ErrBudgetNotFound):
+		return respond(c, fiber.StatusNotFound, nil, err.Error())
+	}
+	return respond(c, fiber.StatusInternalServerError, nil, err.Error())
+}
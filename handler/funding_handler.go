@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type FundingHandler struct {
+	svc service.FundingService
+}
+
+func NewFundingHandler(svc service.FundingService) *FundingHandler {
+	return &FundingHandler{svc: svc}
+}
+
+func (h *FundingHandler) Link(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	var req model.LinkFundingSourceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return respond(c, fiber.StatusBadRequest, nil, "invalid request body")
+	}
+	source, err := h.svc.Link(c.UserContext(), userID, &req)
+	if err != nil {
+		return fundingError(c, err)
+	}
+	return respond(c, fiber.StatusCreated, source, "")
+}
+
+func (h *FundingHandler) List(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	sources, err := h.svc.List(c.UserContext(), userID)
+	if err != nil {
+		return fundingError(c, err)
+	}
+	return respond(c, fiber.StatusOK, sources, "")
+}
+
+func (h *FundingHandler) Unlink(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	if err := h.svc.Unlink(c.UserContext(), userID, c.Params("id")); err != nil {
+		return fundingError(c, err)
+	}
+	return respond(c, fiber.StatusOK, nil, "")
+}
+
+func (h *FundingHandler) Repay(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	var req model.RepayCreditRequest
+	if err := c.BodyParser(&req); err != nil {
+		return respond(c, fiber.StatusBadRequest, nil, "invalid request body")
+	}
+	source, err := h.svc.Repay(c.UserContext(), userID, c.Params("id"), &req)
+	if err != nil {
+		return fundingError(c, err)
+	}
+	return respond(c, fiber.StatusOK, source, "")
+}
+
+func fundingError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidFundingSource), errors.Is(err, service.ErrInvalidRepayment),
+		errors.Is(err, service.ErrPINRequired):
+		return respond(c, fiber.StatusBadRequest, nil, err.Error())
+	case errors.Is(err, service.ErrFundingSourceNotFound):
+		return respond(c, fiber.StatusNotFound, nil, err.Error())
+	case errors.Is(err, service.ErrFundingSourceExists), errors.Is(err, service.ErrCreditOutstanding):
+		return respond(c, fiber.StatusConflict, nil, err.Error())
+	}
+	return respond(c, fiber.StatusInternalServerError, nil, err.Error())
+}
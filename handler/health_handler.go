@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"github.com/banking-superapp/upi-service/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// HealthHandler serves the Kubernetes style probes. Liveness only says the
+// process is serving requests; readiness says it should receive traffic.
+type HealthHandler struct {
+	readiness *service.Readiness
+}
+
+func NewHealthHandler(readiness *service.Readiness) *HealthHandler {
+	return &HealthHandler{readiness: readiness}
+}
+
+func (h *HealthHandler) Livez(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"status": "ok"})
+}
+
+func (h *HealthHandler) Readyz(c *fiber.Ctx) error {
+	ready, checks := h.readiness.Check(c.UserContext())
+	if !ready {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"status": "not ready", "checks": checks})
+	}
+	return c.JSON(fiber.Map{"status": "ready", "checks": checks})
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type InsightsHandler struct {
+	svc service.InsightsService
+}
+
+func NewInsightsHandler(svc service.InsightsService) *InsightsHandler {
+	return &InsightsHandler{svc: svc}
+}
+
+func (h *InsightsHandler) SpendByCategory(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	from, to, err := queryRange(c)
+	if err != nil {
+		return respond(c, fiber.StatusBadRequest, nil, err.Error())
+	}
+	spend, err := h.svc.SpendByCategory(c.UserContext(), userID, from, to)
+	if err != nil {
+		return insightsError(c, err)
+	}
+	return respond(c, fiber.StatusOK, spend, "")
+}
+
+func (h *InsightsHandler) TopPayees(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	from, to, err := queryRange(c)
+	if err != nil {
+		return respond(c, fiber.StatusBadRequest, nil, err.Error())
+	}
+	limit, _ := strconv.ParseInt(c.Query("limit"), 10, 64)
+	payees, err := h.svc.TopPayees(c.UserContext(), userID, from, to, limit)
+	if err != nil {
+		return insightsError(c, err)
+	}
+	return respond(c, fiber.StatusOK, payees, "")
+}
+
+func (h *InsightsHandler) CashFlow(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	from, to, err := queryRange(c)
+	if err != nil {
+		return respond(c, fiber.StatusBadRequest, nil, err.Error())
+	}
+	points, err := h.svc.CashFlow(c.UserContext(), userID, from, to, c.Query("interval"))
+	if err != nil {
+		return insightsError(c, err)
+	}
+	return respond(c, fiber.StatusOK, points, "")
+}
+
+func (h *InsightsHandler) GetCategories(c *fiber.Ctx) error {
+	return respond(c, fiber.StatusOK, service.Categories, "")
+}
+
+func (h *InsightsHandler) GetOverrides(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	overrides, err := h.svc.GetOverrides(c.UserContext(), userID)
+	if err != nil {
+		return insightsError(c, err)
+	}
+	return respond(c, fiber.StatusOK, overrides, "")
+}
+
+func (h *InsightsHandler) SetOverride(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	var req model.SetCategoryOverrideRequest
+	if err := c.BodyParser(&req); err != nil {
+		return respond(c, fiber.StatusBadRequest, nil, "invalid request body")
+	}
+	override, err := h.svc.SetOverride(c.UserContext(), userID, &req)
+	if err != nil {
+		return insightsError(c, err)
+	}
+	return respond(c, fiber.StatusOK, override, "")
+}
+
+func (h *InsightsHandler) DeleteOverride(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	if err := h.svc.DeleteOverride(c.UserContext(), userID, c.Params("payeeVpa")); err != nil {
+		return insightsError(c, err)
+	}
+	return respond(c, fiber.StatusOK, nil, "")
+}
+
+func queryRange(c *fiber.Ctx) (*time.Time, *time.Time, error) {
+	from, err := queryTime(c, "from")
+	if err != nil {
+		return nil, nil, err
+	}
+	to, err := queryTime(c, "to")
+	return from, to, err
+}
+
+func insightsError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidCategory), errors.Is(err, service.ErrInvalidRange),
+		errors.Is(err, service.ErrInvalidInterval), errors.Is(err, service.ErrVPANotFound):
+		return respond(c, fiber.StatusBadRequest, nil, err.Error())
+	case errors.Is(err, service.ErrOverrideNotFound):
+		return respond(c, fiber.StatusNotFound, nil, err.Error())
+	}
+	return respond(c, fiber.StatusInternalServerError, nil, err.Error())
+}
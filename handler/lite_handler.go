@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type LiteHandler struct {
+	svc service.LiteService
+}
+
+func NewLiteHandler(svc service.LiteService) *LiteHandler {
+	return &LiteHandler{svc: svc}
+}
+
+func (h *LiteHandler) Enable(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	w, err := h.svc.Enable(c.UserContext(), userID)
+	if err != nil {
+		return liteError(c, err)
+	}
+	return respond(c, fiber.StatusCreated, w, "")
+}
+
+func (h *LiteHandler) GetWallet(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	w, err := h.svc.GetWallet(c.UserContext(), userID)
+	if err != nil {
+		return liteError(c, err)
+	}
+	return respond(c, fiber.StatusOK, w, "")
+}
+
+func (h *LiteHandler) TopUp(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	var req model.LiteTopUpRequest
+	if err := c.BodyParser(&req); err != nil {
+		return respond(c, fiber.StatusBadRequest, nil, "invalid request body")
+	}
+	w, err := h.svc.TopUp(c.UserContext(), userID, &req)
+	if err != nil {
+		return liteError(c, err)
+	}
+	return respond(c, fiber.StatusOK, w, "")
+}
+
+func (h *LiteHandler) SetAutoTopUp(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	var req model.LiteAutoTopUpRequest
+	if err := c.BodyParser(&req); err != nil {
+		return respond(c, fiber.StatusBadRequest, nil, "invalid request body")
+	}
+	w, err := h.svc.SetAutoTopUp(c.UserContext(), userID, &req)
+	if err != nil {
+		return liteError(c, err)
+	}
+	return respond(c, fiber.StatusOK, w, "")
+}
+
+func (h *LiteHandler) DisableAutoTopUp(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	w, err := h.svc.DisableAutoTopUp(c.UserContext(), userID)
+	if err != nil {
+		return liteError(c, err)
+	}
+	return respond(c, fiber.StatusOK, w, "")
+}
+
+func (h *LiteHandler) Reconcile(c *fiber.Ctx) error {
+	mismatches, err := h.svc.Reconcile(c.UserContext())
+	if err != nil {
+		return liteError(c, err)
+	}
+	return respond(c, fiber.StatusOK, mismatches, "")
+}
+
+func liteError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidLiteTopUp), errors.Is(err, service.ErrInvalidLiteAutoRule),
+		errors.Is(err, service.ErrPINRequired):
+		return respond(c, fiber.StatusBadRequest, nil, err.Error())
+	case errors.Is(err, service.ErrLiteNotEnabled):
+		return respond(c, fiber.StatusNotFound, nil, err.Error())
+	case errors.Is(err, service.ErrLiteEnabled):
+		return respond(c, fiber.StatusConflict, nil, err.Error())
+	case errors.Is(err, service.ErrLiteBalanceCap):
+		return respond(c, fiber.StatusUnprocessableEntity, nil, err.Error())
+	}
+	return respond(c, fiber.StatusInternalServerError, nil, err.Error())
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type MerchantHandler struct {
+	svc service.MerchantService
+}
+
+func NewMerchantHandler(svc service.MerchantService) *MerchantHandler {
+	return &MerchantHandler{svc: svc}
+}
+
+func (h *MerchantHandler) OnboardMerchant(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	var req model.OnboardMerchantRequest
+	if err := c.BodyParser(&req); err != nil {
+		return respond(c, fiber.StatusBadRequest, nil, "invalid request body")
+	}
+	merchant, err := h.svc.OnboardMerchant(c.UserContext(), userID, &req)
+	if err != nil {
+		return merchantError(c, err)
+	}
+	return respond(c, fiber.StatusCreated, merchant, "")
+}
+
+func (h *MerchantHandler) GetMerchants(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	merchants, err := h.svc.GetMerchants(c.UserContext(), userID)
+	if err != nil {
+		return respond(c, fiber.StatusInternalServerError, nil, err.Error())
+	}
+	return respond(c, fiber.StatusOK, merchants, "")
+}
+
+func (h *MerchantHandler) VerifyMerchant(c *fiber.Ctx) error {
+	var req model.VerifyMerchantRequest
+	if err := c.BodyParser(&req); err != nil {
+		return respond(c, fiber.StatusBadRequest, nil, "invalid request body")
+	}
+	merchant, err := h.svc.VerifyMerchant(c.UserContext(), c.Params("merchantId"), &req)
+	if err != nil {
+		return merchantError(c, err)
+	}
+	return respond(c, fiber.StatusOK, merchant, "")
+}
+
+func (h *MerchantHandler) GenerateSettlement(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	settlement, err := h.svc.GenerateSettlement(c.UserContext(), userID, c.Params("merchantId"), c.Query("date"))
+	if err != nil {
+		return merchantError(c, err)
+	}
+	return respond(c, fiber.StatusOK, settlement, "")
+}
+
+func (h *MerchantHandler) GetSettlements(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	settlements, err := h.svc.GetSettlements(c.UserContext(), userID, c.Params("merchantId"))
+	if err != nil {
+		return merchantError(c, err)
+	}
+	return respond(c, fiber.StatusOK, settlements, "")
+}
+
+func merchantError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidMerchant), errors.Is(err, service.ErrInvalidDate):
+		return respond(c, fiber.StatusBadRequest, nil, err.Error())
+	case errors.Is(err, service.ErrMerchantNotFound):
+		return respond(c, fiber.StatusNotFound, nil, err.Error())
+	case errors.Is(err, service.ErrVPAExists):
+		return respond(c, fiber.StatusConflict, nil, err.Error())
+	}
+	return respond(c, fiber.StatusInternalServerError, nil, err.Error())
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/banking-superapp/upi-service/logging"
+	"github.com/banking-superapp/upi-service/metrics"
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/ratelimit"
+	"github.com/banking-superapp/upi-service/service"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequireAdminKey rejects requests whose X-Admin-Key header does not match
+// key. All requests are rejected when key is empty.
+func RequireAdminKey(key string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if key == "" || subtle.ConstantTimeCompare([]byte(c.Get("X-Admin-Key")), []byte(key)) != 1 {
+			return respond(c, fiber.StatusForbidden, nil, "forbidden")
+		}
+		actor := service.AuditActorFrom(c.UserContext())
+		actor.Type, actor.ID = "admin", "admin-key"
+		c.SetUserContext(service.WithAuditActor(c.UserContext(), actor))
+		return c.Next()
+	}
+}
+
+// RateLimit answers 429 with Retry-After once the caller has used up any
+// bucket the request falls in. Users are identified by X-User-ID, devices by
+// X-Device-ID and addresses by clientIP.
+func RateLimit(l *ratelimit.Limiter) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Bucket keys outlive the request, so they are copied out of fasthttp's buffers.
+		ids := map[string]string{
+			ratelimit.User:   utils.CopyString(c.Get("X-User-ID")),
+			ratelimit.Device: utils.CopyString(c.Get("X-Device-ID")),
+			ratelimit.IP:     utils.CopyString(clientIP(c)),
+		}
+		wait, ok := l.Allow(c.UserContext(), c.Method(), c.Path(), ids)
+		if !ok {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			return respond(c, fiber.StatusTooManyRequests, nil, "rate limit exceeded")
+		}
+		return c.Next()
+	}
+}
+
+// clientIP is the caller's address: the peer, or the one a trusted proxy put
+// in the configured header. It is empty when a trusted proxy did not say,
+// since limiting by the proxy's own address would put every client behind it
+// in one bucket.
+func clientIP(c *fiber.Ctx) string {
+	ip := c.IP()
+	if c.App().Config().ProxyHeader != "" && c.IsProxyTrusted() && ip == c.Context().RemoteIP().String() {
+		return ""
+	}
+	return ip
+}
+
+// AttributeChanges records who is making the request, so the services can
+// attribute what it changes in the audit trail.
+func AttributeChanges() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.SetUserContext(service.WithAuditActor(c.UserContext(), model.AuditActor{
+			Type:      "user",
+			ID:        utils.CopyString(c.Get("X-User-ID")),
+			RequestID: utils.CopyString(c.GetRespHeader(fiber.HeaderXRequestID)),
+			IP:        utils.CopyString(c.IP()),
+		}))
+		return c.Next()
+	}
+}
+
+// RecordMetrics observes request latency labelled by route template, so
+// /v1/upi/orders/:orderId is one series however many orders there are.
+func RecordMetrics() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status := responseStatus(c, err)
+		route := routeTemplate(c, status)
+		// fasthttp reuses its buffers, so the method is copied before it is kept as a label.
+		metrics.HTTPRequestDuration.WithLabelValues(utils.CopyString(c.Method()), route, strconv.Itoa(status)).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// responseStatus is the status the error handler will send for err.
+func responseStatus(c *fiber.Ctx, err error) int {
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		return fe.Code
+	} else if err != nil {
+		return fiber.StatusInternalServerError
+	}
+	return c.Response().StatusCode()
+}
+
+// routeTemplate is the matched route, or "unmatched" for requests that only
+// reached the global middleware.
+func routeTemplate(c *fiber.Ctx, status int) string {
+	route := c.Route().Path
+	if status == fiber.StatusNotFound && route == "/" && c.Path() != "/" {
+		return "unmatched"
+	}
+	return route
+}
+
+var tracer = otel.Tracer("github.com/banking-superapp/upi-service/handler")
+
+// Trace starts a server span per request, continuing any W3C trace context
+// in the incoming headers, and tags it with the requestid middleware's ID.
+// Handlers pass c.UserContext() on so service and Mongo spans nest under it.
+// Request strings are copied since spans outlive fasthttp's buffers.
+func Trace() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		method := utils.CopyString(c.Method())
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), requestHeaders{c})
+		ctx, span := tracer.Start(ctx, method, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+			attribute.String("http.request.method", method),
+			attribute.String("url.path", utils.CopyString(c.Path())),
+			attribute.String("request.id", utils.CopyString(c.GetRespHeader(fiber.HeaderXRequestID))),
+		))
+		defer span.End()
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		status := responseStatus(c, err)
+		route := routeTemplate(c, status)
+		span.SetName(method + " " + route)
+		span.SetAttributes(attribute.String("http.route", route), attribute.Int("http.response.status_code", status))
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		return err
+	}
+}
+
+// requestHeaders adapts fasthttp request headers for trace context extraction.
+type requestHeaders struct{ c *fiber.Ctx }
+
+func (h requestHeaders) Get(key string) string { return utils.CopyString(h.c.Get(key)) }
+func (h requestHeaders) Set(key, value string) { h.c.Request().Header.Set(key, value) }
+func (h requestHeaders) Keys() []string {
+	keys := make([]string, 0, len(h.c.GetReqHeaders()))
+	for k := range h.c.GetReqHeaders() {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// LogRequests adds the request and user IDs to the request context, so
+// every record logged while serving it carries them, and writes one access
+// log record per request.
+func LogRequests() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		ctx := logging.With(c.UserContext(), "request_id", utils.CopyString(c.GetRespHeader(fiber.HeaderXRequestID)))
+		if userID := c.Get("X-User-ID"); userID != "" {
+			ctx = logging.With(ctx, "user_id", utils.CopyString(userID))
+		}
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		status := responseStatus(c, err)
+		level := slog.LevelInfo
+		if status >= fiber.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.Log(ctx, level, "request",
+			"method", utils.CopyString(c.Method()),
+			"route", routeTemplate(c, status),
+			"status", status,
+			"latency_ms", time.Since(start).Milliseconds())
+		return err
+	}
+}
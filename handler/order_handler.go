@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type OrderHandler struct {
+	svc service.OrderService
+}
+
+func NewOrderHandler(svc service.OrderService) *OrderHandler {
+	return &OrderHandler{svc: svc}
+}
+
+func (h *OrderHandler) CreateOrder(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	var req model.CreateOrderRequest
+	if err := c.BodyParser(&req); err != nil {
+		return respond(c, fiber.StatusBadRequest, nil, "invalid request body")
+	}
+	resp, err := h.svc.CreateOrder(c.UserContext(), userID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidAmount), errors.Is(err, service.ErrInvalidOrder):
+			return respond(c, fiber.StatusBadRequest, nil, err.Error())
+		case errors.Is(err, service.ErrVPANotFound):
+			return respond(c, fiber.StatusNotFound, nil, err.Error())
+		case errors.Is(err, service.ErrOrderExists):
+			return respond(c, fiber.StatusConflict, nil, err.Error())
+		}
+		return respond(c, fiber.StatusInternalServerError, nil, err.Error())
+	}
+	return respond(c, fiber.StatusCreated, resp, "")
+}
+
+func (h *OrderHandler) CallbackKey(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	key, err := h.svc.CallbackKey(c.UserContext(), userID)
+	if err != nil {
+		if errors.Is(err, service.ErrUnauthorized) {
+			return respond(c, fiber.StatusUnauthorized, nil, err.Error())
+		}
+		return respond(c, fiber.StatusInternalServerError, nil, err.Error())
+	}
+	return respond(c, fiber.StatusOK, key, "")
+}
+
+func (h *OrderHandler) GetOrder(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	order, err := h.svc.GetOrder(c.UserContext(), userID, c.Params("orderId"))
+	if err != nil {
+		if errors.Is(err, service.ErrOrderNotFound) {
+			return respond(c, fiber.StatusNotFound, nil, err.Error())
+		}
+		return respond(c, fiber.StatusInternalServerError, nil, err.Error())
+	}
+	return respond(c, fiber.StatusOK, order, "")
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type PayoutHandler struct {
+	svc service.PayoutService
+}
+
+func NewPayoutHandler(svc service.PayoutService) *PayoutHandler {
+	return &PayoutHandler{svc: svc}
+}
+
+// CreateBatch accepts a JSON body, a text/csv body, or a multipart upload
+// with the CSV in the "file" field and an optional "reference" field.
+func (h *PayoutHandler) CreateBatch(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	var req model.CreatePayoutBatchRequest
+
+	contentType := strings.ToLower(c.Get(fiber.HeaderContentType))
+	switch {
+	case strings.HasPrefix(contentType, fiber.MIMEMultipartForm):
+		fh, err := c.FormFile("file")
+		if err != nil {
+			return respond(c, fiber.StatusBadRequest, nil, "missing file")
+		}
+		f, err := fh.Open()
+		if err != nil {
+			return respond(c, fiber.StatusBadRequest, nil, "unreadable file")
+		}
+		defer f.Close()
+		if req.Rows, err = service.ParsePayoutCSV(f); err != nil {
+			return respond(c, fiber.StatusBadRequest, nil, err.Error())
+		}
+		req.Reference = c.FormValue("reference")
+	case strings.HasPrefix(contentType, "text/csv"):
+		var err error
+		if req.Rows, err = service.ParsePayoutCSV(bytes.NewReader(c.Body())); err != nil {
+			return respond(c, fiber.StatusBadRequest, nil, err.Error())
+		}
+		req.Reference = c.Query("reference")
+	default:
+		if err := c.BodyParser(&req); err != nil {
+			return respond(c, fiber.StatusBadRequest, nil, "invalid request body")
+		}
+	}
+
+	batch, err := h.svc.CreateBatch(c.UserContext(), userID, &req)
+	if err != nil {
+		var invalid *service.PayoutValidationError
+		if errors.As(err, &invalid) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"success": false, "error": err.Error(), "rows": invalid.Rows})
+		}
+		return payoutError(c, err)
+	}
+	return respond(c, fiber.StatusAccepted, batch, "")
+}
+
+func (h *PayoutHandler) GetBatch(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	batch, err := h.svc.GetBatch(c.UserContext(), userID, c.Params("batchId"))
+	if err != nil {
+		return payoutError(c, err)
+	}
+	return respond(c, fiber.StatusOK, batch, "")
+}
+
+func (h *PayoutHandler) GetRows(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	rows, err := h.svc.GetRows(c.UserContext(), userID, c.Params("batchId"), c.Query("status"))
+	if err != nil {
+		return payoutError(c, err)
+	}
+	return respond(c, fiber.StatusOK, rows, "")
+}
+
+func (h *PayoutHandler) DownloadResults(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	data, err := h.svc.ExportResults(c.UserContext(), userID, c.Params("batchId"))
+	if err != nil {
+		return payoutError(c, err)
+	}
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="payout-`+c.Params("batchId")+`.csv"`)
+	c.Type("csv")
+	return c.Send(data)
+}
+
+func payoutError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrNotBusinessAccount):
+		return respond(c, fiber.StatusForbidden, nil, err.Error())
+	case errors.Is(err, service.ErrBatchNotFound):
+		return respond(c, fiber.StatusNotFound, nil, err.Error())
+	}
+	return respond(c, fiber.StatusInternalServerError, nil, err.Error())
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/banking-superapp/upi-service/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// PrivacyHandler takes erasure requests from customers under /upi and from
+// grievance officers under /admin/users/:userId, and serves retention
+// reports under /admin.
+type PrivacyHandler struct {
+	svc service.PrivacyService
+}
+
+func NewPrivacyHandler(svc service.PrivacyService) *PrivacyHandler {
+	return &PrivacyHandler{svc: svc}
+}
+
+func (h *PrivacyHandler) RequestErasure(c *fiber.Ctx) error {
+	userID := c.Params("userId", c.Get("X-User-ID"))
+	req, err := h.svc.RequestErasure(c.UserContext(), userID)
+	if err != nil {
+		return privacyError(c, err)
+	}
+	return respond(c, fiber.StatusAccepted, req, "")
+}
+
+func (h *PrivacyHandler) GetErasure(c *fiber.Ctx) error {
+	userID := c.Params("userId", c.Get("X-User-ID"))
+	req, err := h.svc.GetErasure(c.UserContext(), userID, c.Params("erasureId"))
+	if err != nil {
+		return privacyError(c, err)
+	}
+	return respond(c, fiber.StatusOK, req, "")
+}
+
+func (h *PrivacyHandler) RetentionRuns(c *fiber.Ctx) error {
+	limit, _ := strconv.ParseInt(c.Query("limit"), 10, 64)
+	runs, err := h.svc.RetentionRuns(c.UserContext(), limit)
+	if err != nil {
+		return privacyError(c, err)
+	}
+	return respond(c, fiber.StatusOK, runs, "")
+}
+
+func privacyError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrUnauthorized):
+		return respond(c, fiber.StatusUnauthorized, nil, err.Error())
+	case errors.Is(err, service.ErrErasureNotFound):
+		return respond(c, fiber.StatusNotFound, nil, err.Error())
+	case errors.Is(err, service.ErrErasurePendingTxns),
+		errors.Is(err, service.ErrErasureLiteBalance),
+		errors.Is(err, service.ErrErasureCreditOwed):
+		return respond(c, fiber.StatusConflict, nil, err.Error())
+	}
+	return respond(c, fiber.StatusInternalServerError, nil, err.Error())
+}
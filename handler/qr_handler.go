@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type QRHandler struct {
+	svc service.QRService
+}
+
+func NewQRHandler(svc service.QRService) *QRHandler {
+	return &QRHandler{svc: svc}
+}
+
+// GenerateQR returns the intent and QR as JSON, or the raw PNG with ?format=png.
+func (h *QRHandler) GenerateQR(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	var req model.GenerateQRRequest
+	if err := c.BodyParser(&req); err != nil {
+		return respond(c, fiber.StatusBadRequest, nil, "invalid request body")
+	}
+	qr, err := h.svc.GenerateQR(c.UserContext(), userID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidAmount):
+			return respond(c, fiber.StatusBadRequest, nil, err.Error())
+		case errors.Is(err, service.ErrVPANotFound):
+			return respond(c, fiber.StatusNotFound, nil, err.Error())
+		}
+		return respond(c, fiber.StatusInternalServerError, nil, err.Error())
+	}
+	if c.Query("format") == "png" {
+		c.Type("png")
+		return c.Send(qr.PNG)
+	}
+	return respond(c, fiber.StatusOK, qr, "")
+}
+
+func (h *QRHandler) ParseQR(c *fiber.Ctx) error {
+	var req model.ParseQRRequest
+	if err := c.BodyParser(&req); err != nil {
+		return respond(c, fiber.StatusBadRequest, nil, "invalid request body")
+	}
+	parsed, err := h.svc.ParseQR(c.UserContext(), req.Payload)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidQR):
+			return respond(c, fiber.StatusBadRequest, nil, err.Error())
+		case errors.Is(err, service.ErrInvalidQRSignature):
+			return respond(c, fiber.StatusUnprocessableEntity, nil, err.Error())
+		}
+		return respond(c, fiber.StatusInternalServerError, nil, err.Error())
+	}
+	return respond(c, fiber.StatusOK, parsed, "")
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ScheduleHandler struct {
+	svc service.ScheduleService
+}
+
+func NewScheduleHandler(svc service.ScheduleService) *ScheduleHandler {
+	return &ScheduleHandler{svc: svc}
+}
+
+func (h *ScheduleHandler) CreateSchedule(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	var req model.CreateScheduleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return respond(c, fiber.StatusBadRequest, nil, "invalid request body")
+	}
+	sp, err := h.svc.CreateSchedule(c.UserContext(), userID, &req)
+	if err != nil {
+		return scheduleError(c, err)
+	}
+	return respond(c, fiber.StatusCreated, sp, "")
+}
+
+func (h *ScheduleHandler) GetSchedules(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	schedules, err := h.svc.GetSchedules(c.UserContext(), userID)
+	if err != nil {
+		return scheduleError(c, err)
+	}
+	return respond(c, fiber.StatusOK, schedules, "")
+}
+
+func (h *ScheduleHandler) GetRuns(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	runs, err := h.svc.GetRuns(c.UserContext(), userID, c.Params("scheduleId"))
+	if err != nil {
+		return scheduleError(c, err)
+	}
+	return respond(c, fiber.StatusOK, runs, "")
+}
+
+func (h *ScheduleHandler) Pause(c *fiber.Ctx) error {
+	sp, err := h.svc.Pause(c.UserContext(), c.Get("X-User-ID"), c.Params("scheduleId"))
+	if err != nil {
+		return scheduleError(c, err)
+	}
+	return respond(c, fiber.StatusOK, sp, "")
+}
+
+func (h *ScheduleHandler) Resume(c *fiber.Ctx) error {
+	sp, err := h.svc.Resume(c.UserContext(), c.Get("X-User-ID"), c.Params("scheduleId"))
+	if err != nil {
+		return scheduleError(c, err)
+	}
+	return respond(c, fiber.StatusOK, sp, "")
+}
+
+func (h *ScheduleHandler) SkipNext(c *fiber.Ctx) error {
+	sp, err := h.svc.SkipNext(c.UserContext(), c.Get("X-User-ID"), c.Params("scheduleId"))
+	if err != nil {
+		return scheduleError(c, err)
+	}
+	return respond(c, fiber.StatusOK, sp, "")
+}
+
+func (h *ScheduleHandler) Cancel(c *fiber.Ctx) error {
+	sp, err := h.svc.Cancel(c.UserContext(), c.Get("X-User-ID"), c.Params("scheduleId"))
+	if err != nil {
+		return scheduleError(c, err)
+	}
+	return respond(c, fiber.StatusOK, sp, "")
+}
+
+func scheduleError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidAmount), errors.Is(err, service.ErrInvalidSchedule),
+		errors.Is(err, service.ErrVPANotFound):
+		return respond(c, fiber.StatusBadRequest, nil, err.Error())
+	case errors.Is(err, service.ErrPINRequired):
+		return respond(c, fiber.StatusUnauthorized, nil, err.Error())
+	case errors.Is(err, service.ErrScheduleNotFound):
+		return respond(c, fiber.StatusNotFound, nil, err.Error())
+	case errors.Is(err, service.ErrScheduleState):
+		return respond(c, fiber.StatusConflict, nil, err.Error())
+	}
+	return respond(c, fiber.StatusInternalServerError, nil, err.Error())
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type SplitHandler struct {
+	svc service.SplitService
+}
+
+func NewSplitHandler(svc service.SplitService) *SplitHandler {
+	return &SplitHandler{svc: svc}
+}
+
+func (h *SplitHandler) CreateSplit(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	var req model.CreateSplitRequest
+	if err := c.BodyParser(&req); err != nil {
+		return respond(c, fiber.StatusBadRequest, nil, "invalid request body")
+	}
+	split, err := h.svc.CreateSplit(c.UserContext(), userID, &req)
+	if err != nil {
+		return splitError(c, err)
+	}
+	return respond(c, fiber.StatusCreated, split, "")
+}
+
+func (h *SplitHandler) GetSplits(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	splits, err := h.svc.GetSplits(c.UserContext(), userID)
+	if err != nil {
+		return splitError(c, err)
+	}
+	return respond(c, fiber.StatusOK, splits, "")
+}
+
+func (h *SplitHandler) GetSplit(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	split, err := h.svc.GetSplit(c.UserContext(), userID, c.Params("splitId"))
+	if err != nil {
+		return splitError(c, err)
+	}
+	return respond(c, fiber.StatusOK, split, "")
+}
+
+func (h *SplitHandler) Remind(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	split, err := h.svc.Remind(c.UserContext(), userID, c.Params("splitId"))
+	if err != nil {
+		return splitError(c, err)
+	}
+	return respond(c, fiber.StatusOK, split, "")
+}
+
+func splitError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidAmount), errors.Is(err, service.ErrInvalidSplit):
+		return respond(c, fiber.StatusBadRequest, nil, err.Error())
+	case errors.Is(err, service.ErrSplitNotFound):
+		return respond(c, fiber.StatusNotFound, nil, err.Error())
+	}
+	return respond(c, fiber.StatusInternalServerError, nil, err.Error())
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// StatementHandler serves customers under /upi and support staff under
+// /admin/users/:userId, where the user comes from the path instead of the
+// X-User-ID header.
+type StatementHandler struct {
+	svc service.StatementService
+}
+
+func NewStatementHandler(svc service.StatementService) *StatementHandler {
+	return &StatementHandler{svc: svc}
+}
+
+func (h *StatementHandler) RequestStatement(c *fiber.Ctx) error {
+	userID := c.Params("userId", c.Get("X-User-ID"))
+	var req model.CreateStatementRequest
+	if err := c.BodyParser(&req); err != nil {
+		return respond(c, fiber.StatusBadRequest, nil, "invalid request body")
+	}
+	job, err := h.svc.RequestStatement(c.UserContext(), userID, &req)
+	if err != nil {
+		return statementError(c, err)
+	}
+	status := fiber.StatusCreated
+	if job.Status == "queued" {
+		status = fiber.StatusAccepted
+	}
+	return respond(c, status, job, "")
+}
+
+func (h *StatementHandler) GetJob(c *fiber.Ctx) error {
+	userID := c.Params("userId", c.Get("X-User-ID"))
+	job, err := h.svc.GetJob(c.UserContext(), userID, c.Params("jobId"))
+	if err != nil {
+		return statementError(c, err)
+	}
+	return respond(c, fiber.StatusOK, job, "")
+}
+
+func (h *StatementHandler) Download(c *fiber.Ctx) error {
+	userID := c.Params("userId", c.Get("X-User-ID"))
+	job, err := h.svc.Download(c.UserContext(), userID, c.Params("jobId"))
+	if err != nil {
+		return statementError(c, err)
+	}
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="statement-`+job.From+`-to-`+job.To+`.`+job.Format+`"`)
+	c.Type(job.Format)
+	return c.Send(job.Content)
+}
+
+func statementError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidStatement):
+		return respond(c, fiber.StatusBadRequest, nil, err.Error())
+	case errors.Is(err, service.ErrStatementNotFound):
+		return respond(c, fiber.StatusNotFound, nil, err.Error())
+	case errors.Is(err, service.ErrStatementNotReady):
+		return respond(c, fiber.StatusConflict, nil, err.Error())
+	}
+	return respond(c, fiber.StatusInternalServerError, nil, err.Error())
+}
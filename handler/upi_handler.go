@@ -2,7 +2,9 @@ package handler
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/banking-superapp/upi-service/model"
 	"github.com/banking-superapp/upi-service/service"
@@ -23,7 +25,7 @@ func (h *UPIHandler) CreateVPA(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return respond(c, fiber.StatusBadRequest, nil, "invalid request body")
 	}
-	vpa, err := h.svc.CreateVPA(c.Context(), userID, &req)
+	vpa, err := h.svc.CreateVPA(c.UserContext(), userID, &req)
 	if err != nil {
 		if errors.Is(err, service.ErrVPAExists) {
 			return respond(c, fiber.StatusConflict, nil, err.Error())
@@ -35,7 +37,7 @@ func (h *UPIHandler) CreateVPA(c *fiber.Ctx) error {
 
 func (h *UPIHandler) GetVPAs(c *fiber.Ctx) error {
 	userID := c.Get("X-User-ID")
-	vpas, err := h.svc.GetVPAs(c.Context(), userID)
+	vpas, err := h.svc.GetVPAs(c.UserContext(), userID)
 	if err != nil {
 		return respond(c, fiber.StatusInternalServerError, nil, err.Error())
 	}
@@ -47,7 +49,7 @@ func (h *UPIHandler) ValidateVPA(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return respond(c, fiber.StatusBadRequest, nil, "invalid request body")
 	}
-	result, err := h.svc.ValidateVPA(c.Context(), req.VPA)
+	result, err := h.svc.ValidateVPA(c.UserContext(), req.VPA)
 	if err != nil {
 		return respond(c, fiber.StatusInternalServerError, nil, err.Error())
 	}
@@ -60,10 +62,23 @@ func (h *UPIHandler) Pay(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return respond(c, fiber.StatusBadRequest, nil, "invalid request body")
 	}
-	txn, err := h.svc.Pay(c.Context(), userID, &req)
+	txn, err := h.svc.Pay(c.UserContext(), userID, &req)
 	if err != nil {
-		if errors.Is(err, service.ErrInvalidAmount) {
+		switch {
+		case errors.Is(err, service.ErrInvalidAmount), errors.Is(err, service.ErrAmountMismatch),
+			errors.Is(err, service.ErrBeneficiaryMismatch), errors.Is(err, service.ErrLiteNotEnabled):
 			return respond(c, fiber.StatusBadRequest, nil, err.Error())
+		case errors.Is(err, service.ErrLimitExceeded), errors.Is(err, service.ErrLiteTxnLimit),
+			errors.Is(err, service.ErrLiteInsufficient), errors.Is(err, service.ErrFundingNotEligible),
+			errors.Is(err, service.ErrCreditLimitExceeded):
+			return respond(c, fiber.StatusUnprocessableEntity, nil, err.Error())
+		case errors.Is(err, service.ErrOrderNotFound), errors.Is(err, service.ErrBeneficiaryNotFound),
+			errors.Is(err, service.ErrUPINumberNotFound), errors.Is(err, service.ErrFundingSourceNotFound):
+			return respond(c, fiber.StatusNotFound, nil, err.Error())
+		case errors.Is(err, service.ErrOrderPaid):
+			return respond(c, fiber.StatusConflict, nil, err.Error())
+		case errors.Is(err, service.ErrOrderExpired):
+			return respond(c, fiber.StatusGone, nil, err.Error())
 		}
 		return respond(c, fiber.StatusInternalServerError, nil, err.Error())
 	}
@@ -76,22 +91,66 @@ func (h *UPIHandler) Collect(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return respond(c, fiber.StatusBadRequest, nil, "invalid request body")
 	}
-	cr, err := h.svc.Collect(c.Context(), userID, &req)
+	cr, err := h.svc.Collect(c.UserContext(), userID, &req)
 	if err != nil {
 		return respond(c, fiber.StatusInternalServerError, nil, err.Error())
 	}
 	return respond(c, fiber.StatusCreated, cr, "")
 }
 
+func (h *UPIHandler) GetPendingCollects(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	requests, err := h.svc.GetPendingCollects(c.UserContext(), userID)
+	if err != nil {
+		return respond(c, fiber.StatusInternalServerError, nil, err.Error())
+	}
+	return respond(c, fiber.StatusOK, requests, "")
+}
+
+func (h *UPIHandler) ApproveCollect(c *fiber.Ctx) error {
+	return h.respondCollect(c, true)
+}
+
+func (h *UPIHandler) DeclineCollect(c *fiber.Ctx) error {
+	return h.respondCollect(c, false)
+}
+
+func (h *UPIHandler) respondCollect(c *fiber.Ctx, approve bool) error {
+	userID := c.Get("X-User-ID")
+	cr, err := h.svc.RespondCollect(c.UserContext(), userID, c.Params("collectId"), approve)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrCollectNotFound):
+			return respond(c, fiber.StatusNotFound, nil, err.Error())
+		case errors.Is(err, service.ErrCollectNotActive):
+			return respond(c, fiber.StatusConflict, nil, err.Error())
+		case errors.Is(err, service.ErrLimitExceeded):
+			return respond(c, fiber.StatusUnprocessableEntity, nil, err.Error())
+		}
+		return respond(c, fiber.StatusInternalServerError, nil, err.Error())
+	}
+	return respond(c, fiber.StatusOK, cr, "")
+}
+
 func (h *UPIHandler) GetTransactions(c *fiber.Ctx) error {
 	userID := c.Get("X-User-ID")
 	page, _ := strconv.ParseInt(c.Query("page", "1"), 10, 64)
 	limit, _ := strconv.ParseInt(c.Query("limit", "20"), 10, 64)
-	txns, total, err := h.svc.GetTransactions(c.Context(), userID, page, limit)
+	q := &model.TxnPageQuery{Cursor: c.Query("cursor"), Page: page, Limit: limit}
+	// Page based callers get the total as before; cursor callers opt in.
+	q.WithTotal = c.QueryBool("include_total", q.Cursor == "")
+	filter, err := parseTxnFilter(c)
+	if err != nil {
+		return respond(c, fiber.StatusBadRequest, nil, err.Error())
+	}
+	result, err := h.svc.GetTransactions(c.UserContext(), userID, filter, q)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidFilter) || errors.Is(err, service.ErrInvalidCursor) {
+			return respond(c, fiber.StatusBadRequest, nil, err.Error())
+		}
 		return respond(c, fiber.StatusInternalServerError, nil, err.Error())
 	}
-	return respond(c, fiber.StatusOK, fiber.Map{"transactions": txns, "total": total, "page": page}, "")
+	return respond(c, fiber.StatusOK, result, "")
 }
 
 func (h *UPIHandler) CreateMandate(c *fiber.Ctx) error {
@@ -100,7 +159,7 @@ func (h *UPIHandler) CreateMandate(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return respond(c, fiber.StatusBadRequest, nil, "invalid request body")
 	}
-	mandate, err := h.svc.CreateMandate(c.Context(), userID, &req)
+	mandate, err := h.svc.CreateMandate(c.UserContext(), userID, &req)
 	if err != nil {
 		return respond(c, fiber.StatusInternalServerError, nil, err.Error())
 	}
@@ -109,13 +168,63 @@ func (h *UPIHandler) CreateMandate(c *fiber.Ctx) error {
 
 func (h *UPIHandler) GetMandates(c *fiber.Ctx) error {
 	userID := c.Get("X-User-ID")
-	mandates, err := h.svc.GetMandates(c.Context(), userID)
+	mandates, err := h.svc.GetMandates(c.UserContext(), userID)
 	if err != nil {
 		return respond(c, fiber.StatusInternalServerError, nil, err.Error())
 	}
 	return respond(c, fiber.StatusOK, mandates, "")
 }
 
+// parseTxnFilter reads the history filters from the query string. Dates
+// accept RFC 3339 or YYYY-MM-DD (IST midnight); "to" is exclusive.
+func parseTxnFilter(c *fiber.Ctx) (*model.TxnFilter, error) {
+	f := &model.TxnFilter{
+		Status:       c.Query("status"),
+		Type:         c.Query("type"),
+		Direction:    c.Query("direction"),
+		Counterparty: c.Query("counterparty"),
+		Query:        c.Query("q"),
+	}
+	var err error
+	if f.From, err = queryTime(c, "from"); err != nil {
+		return nil, err
+	}
+	if f.To, err = queryTime(c, "to"); err != nil {
+		return nil, err
+	}
+	if f.MinAmount, err = queryFloat(c, "min_amount"); err != nil {
+		return nil, err
+	}
+	if f.MaxAmount, err = queryFloat(c, "max_amount"); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func queryTime(c *fiber.Ctx, key string) (*time.Time, error) {
+	v := c.Query(key)
+	if v == "" {
+		return nil, nil
+	}
+	t, err := service.ParseDateTime(v)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be RFC 3339 or YYYY-MM-DD", key)
+	}
+	return &t, nil
+}
+
+func queryFloat(c *fiber.Ctx, key string) (*float64, error) {
+	v := c.Query(key)
+	if v == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < 0 {
+		return nil, fmt.Errorf("%s must be a non-negative number", key)
+	}
+	return &f, nil
+}
+
 func respond(c *fiber.Ctx, status int, data interface{}, errMsg string) error {
 	if errMsg != "" {
 		return c.Status(status).JSON(fiber.Map{"success": false, "error": errMsg})
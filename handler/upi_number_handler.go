@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type UPINumberHandler struct {
+	svc service.UPINumberService
+}
+
+func NewUPINumberHandler(svc service.UPINumberService) *UPINumberHandler {
+	return &UPINumberHandler{svc: svc}
+}
+
+func (h *UPINumberHandler) Register(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	var req model.RegisterUPINumberRequest
+	if err := c.BodyParser(&req); err != nil {
+		return respond(c, fiber.StatusBadRequest, nil, "invalid request body")
+	}
+	// The gateway sets X-Bound-Mobile from the device binding, after SIM
+	// verification, alongside X-User-ID.
+	n, err := h.svc.Register(c.UserContext(), userID, c.Get("X-Bound-Mobile"), &req)
+	if err != nil {
+		return upiNumberError(c, err)
+	}
+	return respond(c, fiber.StatusCreated, n, "")
+}
+
+func (h *UPINumberHandler) GetNumbers(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	numbers, err := h.svc.GetNumbers(c.UserContext(), userID)
+	if err != nil {
+		return upiNumberError(c, err)
+	}
+	return respond(c, fiber.StatusOK, numbers, "")
+}
+
+func (h *UPINumberHandler) Port(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	var req model.PortUPINumberRequest
+	if err := c.BodyParser(&req); err != nil {
+		return respond(c, fiber.StatusBadRequest, nil, "invalid request body")
+	}
+	n, err := h.svc.Port(c.UserContext(), userID, c.Params("number"), &req)
+	if err != nil {
+		return upiNumberError(c, err)
+	}
+	return respond(c, fiber.StatusOK, n, "")
+}
+
+func (h *UPINumberHandler) Deregister(c *fiber.Ctx) error {
+	userID := c.Get("X-User-ID")
+	n, err := h.svc.Deregister(c.UserContext(), userID, c.Params("number"))
+	if err != nil {
+		return upiNumberError(c, err)
+	}
+	return respond(c, fiber.StatusOK, n, "")
+}
+
+func upiNumberError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidUPINumber), errors.Is(err, service.ErrVPANotFound):
+		return respond(c, fiber.StatusBadRequest, nil, err.Error())
+	case errors.Is(err, service.ErrMobileNotBound):
+		return respond(c, fiber.StatusForbidden, nil, err.Error())
+	case errors.Is(err, service.ErrUPINumberNotFound):
+		return respond(c, fiber.StatusNotFound, nil, err.Error())
+	case errors.Is(err, service.ErrUPINumberTaken), errors.Is(err, service.ErrVPAHasUPINumber):
+		return respond(c, fiber.StatusConflict, nil, err.Error())
+	}
+	return respond(c, fiber.StatusInternalServerError, nil, err.Error())
+}
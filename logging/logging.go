@@ -0,0 +1,65 @@
+// Package logging sets up the service's JSON slog logger. Attributes added
+// to a context with With are included in every record logged with that
+// context, and values are redacted according to the policy in redact.go.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Setup installs a JSON logger writing to w at level (debug, info, warn or
+// error; anything else means info) as the slog and log package default.
+func Setup(w io.Writer, level string) *slog.Logger {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(strings.TrimSpace(level))); err != nil {
+		lvl = slog.LevelInfo
+	}
+	h := slog.NewJSONHandler(w, &slog.HandlerOptions{Level: lvl, ReplaceAttr: redact})
+	logger := slog.New(contextHandler{h})
+	slog.SetDefault(logger)
+	return logger
+}
+
+type ctxKey struct{}
+
+// With returns a copy of ctx whose log records carry args, given as
+// alternating keys and values like slog.Logger.With.
+func With(ctx context.Context, args ...any) context.Context {
+	attrs, _ := ctx.Value(ctxKey{}).([]slog.Attr)
+	r := slog.Record{}
+	r.Add(args...)
+	merged := make([]slog.Attr, 0, len(attrs)+r.NumAttrs())
+	merged = append(merged, attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		merged = append(merged, a)
+		return true
+	})
+	return context.WithValue(ctx, ctxKey{}, merged)
+}
+
+// contextHandler adds the attributes stored by With, and the trace and span
+// IDs of any recording span, to each record.
+type contextHandler struct{ slog.Handler }
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs, ok := ctx.Value(ctxKey{}).([]slog.Attr); ok {
+		r.AddAttrs(attrs...)
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,115 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// Redaction policy. Attributes are masked by key: VPAs keep the first two
+// characters of the handle and the PSP, account IDs keep their last four
+// characters and amounts are reduced to an order of magnitude. Free text
+// (the message, errors and any other string) has VPAs, account numbers and
+// rupee amounts masked wherever they appear.
+var (
+	vpaKeys     = []string{"vpa", "_vpa", "upi_number"}
+	accountKeys = []string{"account_id", "account_number", "card"}
+	amountKeys  = []string{"amount", "balance", "limit", "difference", "spent"}
+	// Opaque identifiers are logged as is.
+	idKeys = map[string]bool{"request_id": true, "trace_id": true, "span_id": true, "user_id": true, "txn_id": true}
+
+	vpaText     = regexp.MustCompile(`[a-zA-Z0-9._-]{1,256}@[a-zA-Z][a-zA-Z0-9.-]{0,63}`)
+	accountText = regexp.MustCompile(`\b\d{9,18}\b`)
+	amountText  = regexp.MustCompile(`(?i)(₹|\brs\.?|\binr)\s?[\d,]+(\.\d+)?`)
+)
+
+func redact(_ []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	if a.Key == slog.TimeKey || a.Key == slog.LevelKey || idKeys[key] {
+		return a
+	}
+	switch {
+	case a.Value.Kind() == slog.KindGroup:
+		return a
+	case matchesKey(key, vpaKeys):
+		return slog.String(a.Key, MaskVPA(a.Value.String()))
+	case matchesKey(key, accountKeys):
+		return slog.String(a.Key, MaskAccount(a.Value.String()))
+	case matchesKey(key, amountKeys):
+		return slog.String(a.Key, maskAmountValue(a.Value))
+	}
+
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, ScrubText(v.String()))
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return slog.String(a.Key, ScrubText(err.Error()))
+		}
+		return slog.String(a.Key, ScrubText(fmt.Sprint(v.Any())))
+	}
+	return a
+}
+
+func matchesKey(key string, patterns []string) bool {
+	for _, p := range patterns {
+		if key == p || strings.HasSuffix(key, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaskVPA turns jane.doe@digitalbank into ja******@digitalbank.
+func MaskVPA(vpa string) string {
+	handle, psp, ok := strings.Cut(vpa, "@")
+	if !ok {
+		return MaskAccount(vpa)
+	}
+	keep := min(2, len(handle))
+	return handle[:keep] + strings.Repeat("*", len(handle)-keep) + "@" + psp
+}
+
+// MaskAccount keeps the last four characters.
+func MaskAccount(id string) string {
+	if len(id) <= 4 {
+		return strings.Repeat("*", len(id))
+	}
+	return strings.Repeat("*", len(id)-4) + id[len(id)-4:]
+}
+
+// maskAmountValue reports only the band an amount falls in.
+func maskAmountValue(v slog.Value) string {
+	var amount float64
+	switch v.Kind() {
+	case slog.KindFloat64:
+		amount = v.Float64()
+	case slog.KindInt64:
+		amount = float64(v.Int64())
+	case slog.KindUint64:
+		amount = float64(v.Uint64())
+	default:
+		return "***"
+	}
+	switch amount = math.Abs(amount); {
+	case amount < 100:
+		return "<100"
+	case amount < 1000:
+		return "100-1k"
+	case amount < 10000:
+		return "1k-10k"
+	case amount < 100000:
+		return "10k-1L"
+	}
+	return ">=1L"
+}
+
+// ScrubText masks VPAs, account numbers and rupee amounts in free text.
+func ScrubText(s string) string {
+	s = vpaText.ReplaceAllStringFunc(s, MaskVPA)
+	s = accountText.ReplaceAllStringFunc(s, MaskAccount)
+	return amountText.ReplaceAllString(s, "${1} ***")
+}
@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name string
+		attr slog.Attr
+		want string
+	}{
+		{name: "time", attr: slog.Time(slog.TimeKey, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)), want: "2026-01-02 03:04:05 +0000 UTC"},
+		{name: "opaque ID", attr: slog.String("user_id", "65f1a2b3c4d5e6f708192a3b"), want: "65f1a2b3c4d5e6f708192a3b"},
+		{name: "VPA", attr: slog.String("vpa", "jane.doe@digitalbank"), want: "ja******@digitalbank"},
+		{name: "VPA by suffix", attr: slog.String("to_vpa", "ravi@okbank"), want: "ra**@okbank"},
+		{name: "VPA without PSP", attr: slog.String("from_vpa", "9876543210"), want: "******3210"},
+		{name: "UPI number", attr: slog.String("upi_number", "9876543210"), want: "******3210"},
+		{name: "account", attr: slog.String("account_id", "123456789012"), want: "********9012"},
+		{name: "short account", attr: slog.String("card", "1234"), want: "****"},
+		{name: "small amount", attr: slog.Float64("amount", 42.5), want: "<100"},
+		{name: "amount band", attr: slog.Int("balance", 25000), want: "10k-1L"},
+		{name: "negative amount", attr: slog.Float64("daily_limit", -150000), want: ">=1L"},
+		{name: "amount as text", attr: slog.String("amount", "500"), want: "***"},
+		{name: "free text", attr: slog.String(slog.MessageKey, "sent ₹1,250.50 to ravi@okbank from 123456789012"), want: "sent ₹ *** to ra**@okbank from ********9012"},
+		{name: "rupee prefixes", attr: slog.String("note", "Rs. 500 and INR 20"), want: "Rs. *** and INR ***"},
+		{name: "error", attr: slog.Any("error", errors.New("payee ravi@okbank not found")), want: "payee ra**@okbank not found"},
+		{name: "other value", attr: slog.Any("payees", []string{"ravi@okbank"}), want: "[ra**@okbank]"},
+		{name: "number", attr: slog.Int("count", 123456789012), want: "123456789012"},
+		{name: "short digits", attr: slog.String("status", "failed after 3 tries"), want: "failed after 3 tries"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := redact(nil, tt.attr)
+			if got.Key != tt.attr.Key {
+				t.Errorf("key = %q, want %q", got.Key, tt.attr.Key)
+			}
+			if got.Value.String() != tt.want {
+				t.Errorf("value = %q, want %q", got.Value.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactLeavesGroups(t *testing.T) {
+	group := slog.Group("payee", slog.String("vpa", "ravi@okbank"))
+	if got := redact(nil, group); !got.Equal(group) {
+		t.Errorf("redact(group) = %v, want it unchanged", got)
+	}
+}
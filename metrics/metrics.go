@@ -0,0 +1,54 @@
+// Package metrics holds the service's Prometheus collectors. Labels are
+// limited to small fixed sets: route templates rather than paths, and never
+// user, VPA or transaction identifiers.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "upi_http_request_duration_seconds",
+		Help:    "HTTP request latency by method, route template and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	MongoOperationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "upi_mongo_operation_duration_seconds",
+		Help:    "MongoDB command latency by repository method and outcome.",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"method", "outcome"})
+
+	Payments = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "upi_payments_total",
+		Help: "Payments by final status; rejected payments never reached the switch.",
+	}, []string{"status"})
+
+	PaymentAmount = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "upi_payment_amount_rupees",
+		Help:    "Amounts of successful payments by payment type and funding account.",
+		Buckets: []float64{10, 50, 100, 500, 1000, 2000, 5000, 10000, 50000, 100000, 200000, 500000},
+	}, []string{"payment_type", "funding"})
+
+	Collects = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "upi_collect_requests_total",
+		Help: "Collect request lifecycle events: created, approved, declined and expired.",
+	}, []string{"event"})
+
+	ScheduledPaymentExecutions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "upi_scheduled_payment_executions_total",
+		Help: "Scheduled and recurring payment executions by schedule kind and run status.",
+	}, []string{"kind", "status"})
+
+	RateLimitDecisions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "upi_rate_limit_decisions_total",
+		Help: "Rate limit checks by rule, dimension and outcome: allowed, limited or error.",
+	}, []string{"rule", "dimension", "outcome"})
+
+	AuditAppendFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "upi_audit_append_failures_total",
+		Help: "Audit events that could not be queued and were logged in full instead.",
+	})
+)
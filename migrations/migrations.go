@@ -0,0 +1,215 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/banking-superapp/upi-service/repository"
+	"github.com/banking-superapp/upi-service/secure"
+	"github.com/banking-superapp/upi-service/service"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// All is every migration this build knows, in version order. Append new
+// ones; never renumber or edit one that has shipped.
+var All = []Migration{
+	{
+		Version: 1,
+		Name:    "create indexes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return repository.CreateIndexes(db)
+		},
+		Down: dropSecondaryIndexes,
+	},
+	{
+		Version: 2,
+		Name:    "backfill payment_type on transactions",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("upi_transactions").UpdateMany(ctx, untypedTxns, bson.M{"$set": bson.M{"payment_type": "P2P"}})
+			return err
+		},
+		Plan: func(ctx context.Context, db *mongo.Database) (string, error) {
+			n, err := db.Collection("upi_transactions").CountDocuments(ctx, untypedTxns)
+			return fmt.Sprintf("set payment_type P2P on %d transactions", n), err
+		},
+	},
+	{
+		Version: 3,
+		Name:    "index transactions by rrn",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("upi_transactions").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "rrn", Value: 1}},
+				Options: options.Index().SetName("rrn_1").SetSparse(true),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return db.Collection("upi_transactions").Indexes().DropOne(ctx, "rrn_1")
+		},
+	},
+	{
+		Version: 4,
+		Name:    "index admin audit log",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("admin_audit_log").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{Keys: bson.D{{Key: "at", Value: -1}}, Options: options.Index().SetName("at_-1")},
+				{Keys: bson.D{{Key: "target", Value: 1}, {Key: "at", Value: -1}}, Options: options.Index().SetName("target_1_at_-1")},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return db.Collection("admin_audit_log").Indexes().DropAll(ctx)
+		},
+	},
+	{
+		Version: 5,
+		Name:    "index audit trail",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("audit_trail").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				// Unique so concurrent appends cannot both take the next link.
+				{Keys: bson.D{{Key: "seq", Value: 1}}, Options: options.Index().SetName("seq_1").SetUnique(true)},
+				{Keys: bson.D{{Key: "entity_type", Value: 1}, {Key: "entity_id", Value: 1}, {Key: "seq", Value: -1}}, Options: options.Index().SetName("entity_type_1_entity_id_1_seq_-1")},
+				{Keys: bson.D{{Key: "actor.id", Value: 1}, {Key: "seq", Value: -1}}, Options: options.Index().SetName("actor.id_1_seq_-1")},
+				{Keys: bson.D{{Key: "at", Value: 1}}, Options: options.Index().SetName("at_1")},
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return db.Collection("audit_trail").Indexes().DropAll(ctx)
+		},
+	},
+	{
+		Version: 6,
+		Name:    "expire rate limit buckets",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("rate_limits").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "expires_at", Value: 1}},
+				Options: options.Index().SetName("expires_at_1").SetExpireAfterSeconds(0),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return db.Collection("rate_limits").Indexes().DropOne(ctx, "expires_at_1")
+		},
+	},
+	{
+		// Encrypted values differ however equal their plaintext, so lookups
+		// and uniqueness move to the blind index. The clear-text indexes stay
+		// for data written with encryption off.
+		Version: 7,
+		Name:    "encrypt sensitive fields",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			hasIndex := bson.M{"account_id.bi": bson.M{"$exists": true}}
+			_, err := db.Collection("vpas").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "account_id.bi", Value: 1}},
+				Options: options.Index().SetName("account_id.bi_1").SetPartialFilterExpression(hasIndex),
+			})
+			if err != nil {
+				return err
+			}
+			_, err = db.Collection("funding_sources").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "type", Value: 1}, {Key: "account_id.bi", Value: 1}},
+				Options: options.Index().SetName("user_id_1_type_1_account_id.bi_1").SetUnique(true).
+					SetPartialFilterExpression(bson.M{"status": "active", "account_id.bi": bson.M{"$exists": true}}),
+			})
+			if err != nil {
+				return err
+			}
+			if !secure.Enabled() {
+				slog.WarnContext(ctx, "field encryption is off; run upictl reencrypt once ENCRYPTION_KEY_FILE is set")
+				return nil
+			}
+			counts, err := repository.Reencrypt(ctx, db, false)
+			slog.InfoContext(ctx, "encrypted sensitive fields", "rewritten", counts)
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			// Values stay encrypted; they remain readable with the keys.
+			if err := db.Collection("vpas").Indexes().DropOne(ctx, "account_id.bi_1"); err != nil {
+				return err
+			}
+			return db.Collection("funding_sources").Indexes().DropOne(ctx, "user_id_1_type_1_account_id.bi_1")
+		},
+		Plan: func(ctx context.Context, db *mongo.Database) (string, error) {
+			if !secure.Enabled() {
+				return "create blind index indexes; encryption is off, so no values are encrypted", nil
+			}
+			counts, err := repository.Reencrypt(ctx, db, true)
+			return fmt.Sprintf("create blind index indexes and encrypt %v", counts), err
+		},
+	},
+	{
+		Version: 8,
+		Name:    "index erasure requests and retention runs",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("erasure_requests").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "status", Value: 1}}, Options: options.Index().SetName("user_id_1_status_1")},
+				{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}, Options: options.Index().SetName("status_1_created_at_1")},
+			})
+			if err != nil {
+				return err
+			}
+			_, err = db.Collection("retention_runs").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "started_at", Value: -1}},
+				Options: options.Index().SetName("started_at_-1"),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			if err := db.Collection("erasure_requests").Indexes().DropAll(ctx); err != nil {
+				return err
+			}
+			return db.Collection("retention_runs").Indexes().DropAll(ctx)
+		},
+	},
+	{
+		// Statements read the ledger, so transactions from before it existed
+		// are posted too. Backfilled entries look like any others, so there is
+		// no Down.
+		Version: 9,
+		Name:    "backfill ledger from transactions",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			posted, err := service.BackfillLedger(ctx, repository.NewVPARepo(db), repository.NewTxnRepo(db), repository.NewLedgerRepo(db), false)
+			slog.InfoContext(ctx, "backfilled ledger", "transactions", posted)
+			return err
+		},
+		Plan: func(ctx context.Context, db *mongo.Database) (string, error) {
+			n, err := service.BackfillLedger(ctx, repository.NewVPARepo(db), repository.NewTxnRepo(db), repository.NewLedgerRepo(db), true)
+			return fmt.Sprintf("post ledger entries for %d transactions", n), err
+		},
+	},
+	{
+		Version: 10,
+		Name:    "expire merchant daily volumes",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("merchant_daily_volumes").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "expires_at", Value: 1}},
+				Options: options.Index().SetName("expires_at_1").SetExpireAfterSeconds(0),
+			})
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return db.Collection("merchant_daily_volumes").Indexes().DropOne(ctx, "expires_at_1")
+		},
+	},
+}
+
+// untypedTxns predate P2P/P2M classification. None of them could have been
+// merchant payments, which were introduced together with it.
+var untypedTxns = bson.M{"payment_type": bson.M{"$exists": false}, "merchant_id": bson.M{"$exists": false}}
+
+func dropSecondaryIndexes(ctx context.Context, db *mongo.Database) error {
+	names, err := db.ListCollectionNames(ctx, bson.M{"name": bson.M{"$nin": bson.A{"schema_migrations", "migration_locks"}}})
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := db.Collection(name).Indexes().DropAll(ctx); err != nil {
+			return fmt.Errorf("dropping indexes on %s: %w", name, err)
+		}
+	}
+	return nil
+}
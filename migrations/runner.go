@@ -0,0 +1,273 @@
+// Package migrations applies ordered, versioned schema and data migrations
+// to the service's database. Applied versions are recorded in the
+// schema_migrations collection and a lease in migration_locks ensures only
+// one replica migrates at a time.
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+var (
+	ErrLocked       = errors.New("another process holds the migration lock")
+	ErrLockLost     = errors.New("migration lock could not be renewed")
+	ErrIrreversible = errors.New("migration cannot be rolled back")
+	ErrUnknown      = errors.New("database has migrations this build does not know")
+)
+
+const (
+	lockID    = "schema"
+	lockLease = 5 * time.Minute
+)
+
+// Migration is one versioned change. Down may be nil for changes that
+// cannot be undone. Plan, when set, describes what Up would change and is
+// used in dry runs.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(ctx context.Context, db *mongo.Database) error
+	Down    func(ctx context.Context, db *mongo.Database) error
+	Plan    func(ctx context.Context, db *mongo.Database) (string, error)
+}
+
+// Record is a schema_migrations document.
+type Record struct {
+	Version   int           `bson:"_id" json:"version"`
+	Name      string        `bson:"name" json:"name"`
+	AppliedAt time.Time     `bson:"applied_at" json:"applied_at"`
+	Duration  time.Duration `bson:"duration" json:"duration"`
+	AppliedBy string        `bson:"applied_by" json:"applied_by"`
+}
+
+// Status is a known migration and, if applied, its record.
+type Status struct {
+	Version int     `json:"version"`
+	Name    string  `json:"name"`
+	Applied *Record `json:"applied,omitempty"`
+}
+
+// Step is a migration a run applied, rolled back or, in a dry run, would have.
+type Step struct {
+	Version int    `json:"version"`
+	Name    string `json:"name"`
+	Plan    string `json:"plan,omitempty"`
+}
+
+type Runner struct {
+	db         *mongo.Database
+	migrations []Migration
+	owner      string
+	dryRun     bool
+	records    *mongo.Collection
+	locks      *mongo.Collection
+}
+
+// NewRunner sorts migrations by version. owner identifies this process in
+// the lock and in the records it writes.
+func NewRunner(db *mongo.Database, migrations []Migration, owner string, dryRun bool) *Runner {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	return &Runner{
+		db:         db,
+		migrations: sorted,
+		owner:      owner,
+		dryRun:     dryRun,
+		records:    db.Collection("schema_migrations"),
+		locks:      db.Collection("migration_locks"),
+	}
+}
+
+func (r *Runner) Status(ctx context.Context) ([]Status, error) {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(r.migrations))
+	for _, m := range r.migrations {
+		s := Status{Version: m.Version, Name: m.Name}
+		if rec, ok := applied[m.Version]; ok {
+			s.Applied = &rec
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// Up applies pending migrations up to and including target, or all of them
+// when target is 0.
+func (r *Runner) Up(ctx context.Context, target int) ([]Step, error) {
+	var steps []Step
+	err := r.locked(ctx, func(ctx context.Context) error {
+		applied, err := r.applied(ctx)
+		if err != nil {
+			return err
+		}
+		if err := r.checkKnown(applied); err != nil {
+			return err
+		}
+		for _, m := range r.migrations {
+			if _, done := applied[m.Version]; done || target > 0 && m.Version > target {
+				continue
+			}
+			step := Step{Version: m.Version, Name: m.Name}
+			if r.dryRun {
+				if m.Plan != nil {
+					if step.Plan, err = m.Plan(ctx, r.db); err != nil {
+						return fmt.Errorf("planning migration %d: %w", m.Version, err)
+					}
+				}
+				steps = append(steps, step)
+				continue
+			}
+
+			slog.InfoContext(ctx, "applying migration", "version", m.Version, "name", m.Name)
+			start := time.Now()
+			if err := m.Up(ctx, r.db); err != nil {
+				return fmt.Errorf("migration %d %s: %w", m.Version, m.Name, err)
+			}
+			rec := Record{Version: m.Version, Name: m.Name, AppliedAt: time.Now(), Duration: time.Since(start), AppliedBy: r.owner}
+			if _, err := r.records.InsertOne(ctx, rec); err != nil {
+				return fmt.Errorf("recording migration %d: %w", m.Version, err)
+			}
+			steps = append(steps, step)
+		}
+		return nil
+	})
+	return steps, err
+}
+
+// Down rolls back the most recently applied steps migrations, newest first.
+func (r *Runner) Down(ctx context.Context, steps int) ([]Step, error) {
+	var done []Step
+	err := r.locked(ctx, func(ctx context.Context) error {
+		applied, err := r.applied(ctx)
+		if err != nil {
+			return err
+		}
+		if err := r.checkKnown(applied); err != nil {
+			return err
+		}
+		for i := len(r.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			m := r.migrations[i]
+			if _, ok := applied[m.Version]; !ok {
+				continue
+			}
+			if m.Down == nil {
+				return fmt.Errorf("migration %d %s: %w", m.Version, m.Name, ErrIrreversible)
+			}
+			step := Step{Version: m.Version, Name: m.Name}
+			if r.dryRun {
+				done = append(done, step)
+				continue
+			}
+
+			slog.InfoContext(ctx, "rolling back migration", "version", m.Version, "name", m.Name)
+			if err := m.Down(ctx, r.db); err != nil {
+				return fmt.Errorf("rolling back migration %d %s: %w", m.Version, m.Name, err)
+			}
+			if _, err := r.records.DeleteOne(ctx, bson.M{"_id": m.Version}); err != nil {
+				return fmt.Errorf("unrecording migration %d: %w", m.Version, err)
+			}
+			done = append(done, step)
+		}
+		return nil
+	})
+	return done, err
+}
+
+func (r *Runner) applied(ctx context.Context) (map[int]Record, error) {
+	cursor, err := r.records.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var records []Record
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	applied := make(map[int]Record, len(records))
+	for _, rec := range records {
+		applied[rec.Version] = rec
+	}
+	return applied, nil
+}
+
+// checkKnown refuses to run an older build against a database migrated by a
+// newer one.
+func (r *Runner) checkKnown(applied map[int]Record) error {
+	known := make(map[int]bool, len(r.migrations))
+	for _, m := range r.migrations {
+		known[m.Version] = true
+	}
+	for v := range applied {
+		if !known[v] {
+			return fmt.Errorf("%w: version %d", ErrUnknown, v)
+		}
+	}
+	return nil
+}
+
+// locked runs fn while holding the migration lease, renewing it until fn
+// returns. If a renewal fails fn's context is cancelled, since another
+// replica may take the lease over once it expires. Dry runs take the lock too
+// so they see a consistent state.
+func (r *Runner) locked(ctx context.Context, fn func(context.Context) error) error {
+	if err := r.acquire(ctx); err != nil {
+		return err
+	}
+	defer func() {
+		if _, err := r.locks.DeleteOne(context.WithoutCancel(ctx), bson.M{"_id": lockID, "owner": r.owner}); err != nil {
+			slog.ErrorContext(ctx, "releasing migration lock failed", "error", err)
+		}
+	}()
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	go func() {
+		ticker := time.NewTicker(lockLease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := r.acquire(ctx); err != nil && ctx.Err() == nil {
+					slog.ErrorContext(ctx, "renewing migration lock failed", "error", err)
+					cancel(fmt.Errorf("%w: %w", ErrLockLost, err))
+					return
+				}
+			}
+		}
+	}()
+	err := fn(ctx)
+	if cause := context.Cause(ctx); err != nil && errors.Is(cause, ErrLockLost) {
+		return cause
+	}
+	return err
+}
+
+// acquire takes or renews the lease. An expired lease held by someone else
+// is taken over; a live one fails with ErrLocked.
+func (r *Runner) acquire(ctx context.Context) error {
+	now := time.Now()
+	filter := bson.M{"_id": lockID, "$or": bson.A{
+		bson.M{"owner": r.owner},
+		bson.M{"expires_at": bson.M{"$lt": now}},
+	}}
+	update := bson.M{"$set": bson.M{"owner": r.owner, "expires_at": now.Add(lockLease), "renewed_at": now}}
+	_, err := r.locks.UpdateOne(ctx, filter, update, options.UpdateOne().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrLocked
+	}
+	return err
+}
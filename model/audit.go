@@ -0,0 +1,78 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// AuditEntry records one operator action, whether it succeeded or not.
+type AuditEntry struct {
+	ID         bson.ObjectID     `bson:"_id,omitempty" json:"id"`
+	Actor      string            `bson:"actor" json:"actor"`
+	Host       string            `bson:"host" json:"host"`
+	RequestID  string            `bson:"request_id,omitempty" json:"request_id,omitempty"` // links to the audit trail events it caused
+	Action     string            `bson:"action" json:"action"`                             // e.g. txn.resolve, vpa.deactivate
+	Target     string            `bson:"target,omitempty" json:"target,omitempty"`
+	Params     map[string]string `bson:"params,omitempty" json:"params,omitempty"`
+	Reason     string            `bson:"reason,omitempty" json:"reason,omitempty"`
+	Outcome    string            `bson:"outcome" json:"outcome"` // started | ok | error; started if the CLI died mid-action
+	Error      string            `bson:"error,omitempty" json:"error,omitempty"`
+	At         time.Time         `bson:"at" json:"at"`
+	FinishedAt *time.Time        `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+}
+
+// AuditActor is who caused a change. IPDigest fingerprints IP, so the
+// address can be cleared by retention without breaking the hash chain.
+type AuditActor struct {
+	Type      string `bson:"type" json:"type"` // user | admin | operator | system
+	ID        string `bson:"id" json:"id"`
+	RequestID string `bson:"request_id,omitempty" json:"request_id,omitempty"`
+	IP        string `bson:"ip,omitempty" json:"ip,omitempty"`
+	IPDigest  string `bson:"ip_digest,omitempty" json:"ip_digest,omitempty"`
+}
+
+// AuditChange holds a field's JSON value before and after a change; From is
+// empty for created fields and To for removed ones.
+type AuditChange struct {
+	From json.RawMessage `bson:"from,omitempty" json:"from,omitempty"`
+	To   json.RawMessage `bson:"to,omitempty" json:"to,omitempty"`
+}
+
+// AuditEvent is one link of the audit trail. Hash covers the event and
+// PrevHash, the hash of event Seq-1, so editing or removing any event breaks
+// every hash after it.
+type AuditEvent struct {
+	ID         bson.ObjectID          `bson:"_id,omitempty" json:"id"`
+	Seq        int64                  `bson:"seq" json:"seq"`
+	Actor      AuditActor             `bson:"actor" json:"actor"`
+	Action     string                 `bson:"action" json:"action"`           // e.g. vpa.create, txn.resolve
+	EntityType string                 `bson:"entity_type" json:"entity_type"` // vpa | transaction | collect | mandate
+	EntityID   string                 `bson:"entity_id" json:"entity_id"`
+	Changes    map[string]AuditChange `bson:"changes,omitempty" json:"changes,omitempty"`
+	At         time.Time              `bson:"at" json:"at"`
+	PrevHash   string                 `bson:"prev_hash" json:"prev_hash"`
+	Hash       string                 `bson:"hash" json:"hash"`
+}
+
+// AuditQuery narrows an audit trail listing. Zero fields match everything.
+type AuditQuery struct {
+	EntityType string
+	EntityID   string
+	ActorID    string
+	Action     string
+	From       *time.Time
+	To         *time.Time
+	BeforeSeq  int64 // resumes a listing below this sequence number
+}
+
+// AuditVerification reports whether a stretch of the trail is intact.
+type AuditVerification struct {
+	FromSeq  int64  `json:"from_seq"`
+	ToSeq    int64  `json:"to_seq"`
+	Checked  int    `json:"checked"`
+	Valid    bool   `json:"valid"`
+	BrokenAt int64  `json:"broken_at,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
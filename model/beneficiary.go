@@ -0,0 +1,42 @@
+package model
+
+import (
+	"time"
+
+	"github.com/banking-superapp/upi-service/secure"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type Beneficiary struct {
+	ID           bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID       bson.ObjectID `bson:"user_id" json:"user_id"`
+	VPA          string        `bson:"vpa" json:"vpa"`
+	Nickname     secure.String `bson:"nickname" json:"nickname"`
+	VerifiedName secure.String `bson:"verified_name" json:"verified_name"` // from ValidateVPA when saved
+	IsFavorite   bool          `bson:"is_favorite" json:"is_favorite"`
+	LastPaidAt   *time.Time    `bson:"last_paid_at,omitempty" json:"last_paid_at,omitempty"`
+	CreatedAt    time.Time     `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time     `bson:"updated_at" json:"updated_at"`
+}
+
+type CreateBeneficiaryRequest struct {
+	VPA        string `json:"vpa"`
+	Nickname   string `json:"nickname"`
+	IsFavorite bool   `json:"is_favorite"`
+}
+
+// UpdateBeneficiaryRequest changes only the fields that are present.
+type UpdateBeneficiaryRequest struct {
+	Nickname   *string `json:"nickname"`
+	IsFavorite *bool   `json:"is_favorite"`
+}
+
+// PayeeSuggestion is a recently paid VPA, linked to its beneficiary when the
+// user has saved it.
+type PayeeSuggestion struct {
+	VPA           string         `bson:"_id" json:"vpa"`
+	LastPaidAt    time.Time      `bson:"last_paid_at" json:"last_paid_at"`
+	Count         int64          `bson:"count" json:"count"`
+	BeneficiaryID *bson.ObjectID `bson:"-" json:"beneficiary_id,omitempty"`
+	Nickname      string         `bson:"-" json:"nickname,omitempty"`
+}
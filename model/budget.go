@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Budget is a monthly spend limit for one category. AlertsSent holds the
+// thresholds (percent) already notified for AlertMonth.
+type Budget struct {
+	ID         bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     bson.ObjectID `bson:"user_id" json:"user_id"`
+	Category   string        `bson:"category" json:"category"`
+	Limit      float64       `bson:"limit" json:"limit"`
+	AlertMonth string        `bson:"alert_month,omitempty" json:"-"` // YYYY-MM in IST
+	AlertsSent []int         `bson:"alerts_sent,omitempty" json:"-"`
+	CreatedAt  time.Time     `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time     `bson:"updated_at" json:"updated_at"`
+}
+
+type SetBudgetRequest struct {
+	Category string  `json:"category"`
+	Limit    float64 `json:"limit"`
+}
+
+type BudgetUtilization struct {
+	Category  string  `json:"category"`
+	Month     string  `json:"month"`
+	Limit     float64 `json:"limit"`
+	Spent     float64 `json:"spent"`
+	Remaining float64 `json:"remaining"`
+	Percent   float64 `json:"percent"`
+}
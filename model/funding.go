@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+
+	"github.com/banking-superapp/upi-service/secure"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// FundingSource is an account UPI payments can be drawn from besides the
+// savings account behind the user's VPA.
+type FundingSource struct {
+	ID          bson.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID      bson.ObjectID  `bson:"user_id" json:"user_id"`
+	Type        string         `bson:"type" json:"type"`             // savings | credit_line | credit_card
+	AccountID   secure.Indexed `bson:"account_id" json:"account_id"` // account number, credit line ID or card reference
+	Issuer      string         `bson:"issuer" json:"issuer"`
+	CreditLimit float64        `bson:"credit_limit,omitempty" json:"credit_limit,omitempty"`
+	Outstanding float64        `bson:"outstanding,omitempty" json:"outstanding,omitempty"` // drawn against CreditLimit
+	Status      string         `bson:"status" json:"status"`                               // active | unlinked
+	CreatedAt   time.Time      `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time      `bson:"updated_at" json:"updated_at"`
+}
+
+type LinkFundingSourceRequest struct {
+	Type        string  `json:"type"`
+	AccountID   string  `json:"account_id"`
+	Issuer      string  `json:"issuer"`
+	CreditLimit float64 `json:"credit_limit"`
+}
+
+type RepayCreditRequest struct {
+	Amount        float64 `json:"amount"`
+	PINCredential string  `json:"pin_credential"`
+}
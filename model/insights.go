@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// CategoryOverride pins every payment a user makes to PayeeVPA to Category,
+// taking precedence over the built in rules.
+type CategoryOverride struct {
+	ID        bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    bson.ObjectID `bson:"user_id" json:"user_id"`
+	PayeeVPA  string        `bson:"payee_vpa" json:"payee_vpa"`
+	Category  string        `bson:"category" json:"category"`
+	UpdatedAt time.Time     `bson:"updated_at" json:"updated_at"`
+}
+
+type SetCategoryOverrideRequest struct {
+	PayeeVPA    string `json:"payee_vpa"`
+	Category    string `json:"category"`
+	ApplyToPast bool   `json:"apply_to_past"` // also recategorize earlier payments
+}
+
+// CategorySpend is the outgoing spend in one category for an IST month
+// (YYYY-MM).
+type CategorySpend struct {
+	Month    string  `bson:"month" json:"month"`
+	Category string  `bson:"category" json:"category"`
+	Amount   float64 `bson:"amount" json:"amount"`
+	Count    int64   `bson:"count" json:"count"`
+}
+
+type PayeeSpend struct {
+	VPA    string  `bson:"_id" json:"vpa"`
+	Amount float64 `bson:"amount" json:"amount"`
+	Count  int64   `bson:"count" json:"count"`
+}
+
+// CashFlowPoint totals money in and out for one period, formatted per the
+// requested interval (YYYY-MM, YYYY-Www or YYYY-MM-DD).
+type CashFlowPoint struct {
+	Period  string  `bson:"_id" json:"period"`
+	Inflow  float64 `bson:"inflow" json:"inflow"`
+	Outflow float64 `bson:"outflow" json:"outflow"`
+	Net     float64 `bson:"-" json:"net"`
+}
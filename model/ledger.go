@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"github.com/banking-superapp/upi-service/secure"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// LedgerEntry is one side of a posted payment from the point of view of
+// UserID. Amount is always positive; Direction gives the sign.
+type LedgerEntry struct {
+	ID           bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID       bson.ObjectID `bson:"user_id" json:"user_id"`
+	VPA          string        `bson:"vpa" json:"vpa"`
+	Account      string        `bson:"account,omitempty" json:"account,omitempty"` // empty for the VPA's account, lite for UPI Lite, type:id for a funding source
+	TxnID        string        `bson:"txn_id" json:"txn_id"`
+	Direction    string        `bson:"direction" json:"direction"` // debit | credit
+	Amount       float64       `bson:"amount" json:"amount"`
+	Counterparty secure.String `bson:"counterparty" json:"counterparty"`
+	Note         secure.String `bson:"note" json:"note"`
+	PostedAt     time.Time     `bson:"posted_at" json:"posted_at"`
+}
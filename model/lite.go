@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// LiteWallet is a user's UPI Lite balance. It is funded from the account
+// behind VPA and spent without a UPI PIN.
+type LiteWallet struct {
+	ID        bson.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID    bson.ObjectID  `bson:"user_id" json:"user_id"`
+	VPA       string         `bson:"vpa" json:"vpa"`
+	Balance   float64        `bson:"balance" json:"balance"`
+	AutoTopUp *LiteAutoTopUp `bson:"auto_top_up,omitempty" json:"auto_top_up,omitempty"`
+	CreatedAt time.Time      `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time      `bson:"updated_at" json:"updated_at"`
+}
+
+// LiteAutoTopUp adds Amount whenever a debit leaves the balance below
+// Threshold, under a PIN backed pre-authorisation.
+type LiteAutoTopUp struct {
+	Threshold    float64   `bson:"threshold" json:"threshold"`
+	Amount       float64   `bson:"amount" json:"amount"`
+	PreAuthToken string    `bson:"preauth_token" json:"-"`
+	PreAuthUntil time.Time `bson:"preauth_until" json:"preauth_until"`
+}
+
+type LiteTopUpRequest struct {
+	Amount        float64 `json:"amount"`
+	PINCredential string  `json:"pin_credential"`
+}
+
+type LiteAutoTopUpRequest struct {
+	Threshold     float64 `json:"threshold"`
+	Amount        float64 `json:"amount"`
+	PINCredential string  `json:"pin_credential"`
+}
+
+// LiteMismatch is a wallet whose balance disagrees with its ledger postings.
+type LiteMismatch struct {
+	UserID        bson.ObjectID `json:"user_id"`
+	WalletBalance float64       `json:"wallet_balance"`
+	LedgerBalance float64       `json:"ledger_balance"`
+	Difference    float64       `json:"difference"`
+}
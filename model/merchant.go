@@ -0,0 +1,52 @@
+package model
+
+import (
+	"time"
+
+	"github.com/banking-superapp/upi-service/secure"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type Merchant struct {
+	ID                bson.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID            bson.ObjectID  `bson:"user_id" json:"user_id"`
+	LegalName         secure.String  `bson:"legal_name" json:"legal_name"`
+	MCC               string         `bson:"mcc" json:"mcc"` // ISO 18245 merchant category code
+	SettlementAccount secure.Indexed `bson:"settlement_account" json:"settlement_account"`
+	VPA               string         `bson:"vpa" json:"vpa"` // shop@digitalbank
+	Verified          bool           `bson:"verified" json:"verified"`
+	IsActive          bool           `bson:"is_active" json:"is_active"`
+	TxnLimit          float64        `bson:"txn_limit,omitempty" json:"txn_limit,omitempty"`     // 0 uses the tier default
+	DailyLimit        float64        `bson:"daily_limit,omitempty" json:"daily_limit,omitempty"` // 0 uses the tier default
+	MDRBps            int64          `bson:"mdr_bps" json:"mdr_bps"`                             // merchant discount rate in basis points
+	CreatedAt         time.Time      `bson:"created_at" json:"created_at"`
+	UpdatedAt         time.Time      `bson:"updated_at" json:"updated_at"`
+}
+
+// MerchantSettlement is the per-day settlement summary of a merchant's P2M
+// collections. Date is the IST calendar day in YYYY-MM-DD form.
+type MerchantSettlement struct {
+	ID                bson.ObjectID  `bson:"_id,omitempty" json:"id"`
+	MerchantID        bson.ObjectID  `bson:"merchant_id" json:"merchant_id"`
+	VPA               string         `bson:"vpa" json:"vpa"`
+	SettlementAccount secure.Indexed `bson:"settlement_account" json:"settlement_account"`
+	Date              string         `bson:"date" json:"date"`
+	TxnCount          int64          `bson:"txn_count" json:"txn_count"`
+	GrossAmount       float64        `bson:"gross_amount" json:"gross_amount"`
+	MDRFee            float64        `bson:"mdr_fee" json:"mdr_fee"`
+	NetAmount         float64        `bson:"net_amount" json:"net_amount"`
+	GeneratedAt       time.Time      `bson:"generated_at" json:"generated_at"`
+}
+
+type OnboardMerchantRequest struct {
+	LegalName         string `json:"legal_name"`
+	MCC               string `json:"mcc"`
+	SettlementAccount string `json:"settlement_account"`
+	Handle            string `json:"handle"` // e.g., "shop" -> shop@digitalbank
+}
+
+type VerifyMerchantRequest struct {
+	TxnLimit   float64 `json:"txn_limit"`
+	DailyLimit float64 `json:"daily_limit"`
+	MDRBps     int64   `json:"mdr_bps"`
+}
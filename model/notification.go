@@ -0,0 +1,11 @@
+package model
+
+// Notification is a user facing message. Recipient is a VPA since the payer
+// side of a request may not be a customer of ours.
+type Notification struct {
+	Recipient string            `json:"recipient"`
+	Type      string            `json:"type"` // split_reminder | ...
+	Title     string            `json:"title"`
+	Body      string            `json:"body"`
+	Data      map[string]string `json:"data,omitempty"`
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+
+	"github.com/banking-superapp/upi-service/secure"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// MerchantOrder binds a dynamic QR to a merchant's order. The TxnRef is the
+// `tr` parameter of the QR and is echoed back by the payer's app on Pay.
+type MerchantOrder struct {
+	ID          bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID      bson.ObjectID `bson:"user_id" json:"user_id"`
+	OrderID     string        `bson:"order_id" json:"order_id"` // merchant supplied
+	TxnRef      string        `bson:"txn_ref" json:"txn_ref"`
+	PayeeVPA    string        `bson:"payee_vpa" json:"payee_vpa"`
+	Amount      float64       `bson:"amount" json:"amount"`
+	Note        secure.String `bson:"note" json:"note"`
+	CallbackURL string        `bson:"callback_url,omitempty" json:"callback_url,omitempty"`
+	Status      string        `bson:"status" json:"status"` // created | paid | expired
+	PaidTxnID   string        `bson:"paid_txn_id,omitempty" json:"paid_txn_id,omitempty"`
+	PaidAt      *time.Time    `bson:"paid_at,omitempty" json:"paid_at,omitempty"`
+	ExpiresAt   time.Time     `bson:"expires_at" json:"expires_at"`
+	CreatedAt   time.Time     `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time     `bson:"updated_at" json:"updated_at"`
+}
+
+type CreateOrderRequest struct {
+	OrderID     string  `json:"order_id"`
+	VPA         string  `json:"vpa"` // optional, defaults to the merchant's default VPA
+	Amount      float64 `json:"amount"`
+	Note        string  `json:"note"`
+	ExpiresIn   int64   `json:"expires_in"` // seconds, defaults to 15 minutes
+	CallbackURL string  `json:"callback_url"`
+}
+
+type OrderQRResponse struct {
+	Order *MerchantOrder `json:"order"`
+	QR    *QRCode        `json:"qr"`
+}
+
+// CallbackKeyResponse is the key order callbacks are signed with. The
+// X-Callback-Signature header is "sha256=" and the hex HMAC of the
+// X-Callback-Timestamp header, a ".", and the body.
+type CallbackKeyResponse struct {
+	Algorithm string `json:"algorithm"`
+	Key       string `json:"key"` // hex
+}
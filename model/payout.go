@@ -0,0 +1,54 @@
+package model
+
+import (
+	"time"
+
+	"github.com/banking-superapp/upi-service/secure"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type PayoutBatch struct {
+	ID          bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID      bson.ObjectID `bson:"user_id" json:"user_id"`
+	Reference   string        `bson:"reference" json:"reference"`
+	Status      string        `bson:"status" json:"status"` // processing | completed | completed_with_errors
+	TotalRows   int64         `bson:"total_rows" json:"total_rows"`
+	Succeeded   int64         `bson:"succeeded" json:"succeeded"`
+	Failed      int64         `bson:"failed" json:"failed"`
+	TotalAmount float64       `bson:"total_amount" json:"total_amount"`
+	PaidAmount  float64       `bson:"paid_amount" json:"paid_amount"`
+	CreatedAt   time.Time     `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time     `bson:"updated_at" json:"updated_at"`
+	CompletedAt *time.Time    `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	LeaseOwner  string        `bson:"lease_owner,omitempty" json:"-"` // the process executing the batch
+	LeaseUntil  *time.Time    `bson:"lease_until,omitempty" json:"-"`
+}
+
+type PayoutRow struct {
+	ID            bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	BatchID       bson.ObjectID `bson:"batch_id" json:"batch_id"`
+	Row           int64         `bson:"row" json:"row"` // 1-based position in the upload
+	VPA           string        `bson:"vpa" json:"vpa"`
+	Amount        float64       `bson:"amount" json:"amount"`
+	Note          secure.String `bson:"note" json:"note"`
+	Status        string        `bson:"status" json:"status"`                     // pending | processing | success | failed
+	TxnID         string        `bson:"txn_id,omitempty" json:"txn_id,omitempty"` // set when the row is claimed, before paying
+	FailureReason string        `bson:"failure_reason,omitempty" json:"failure_reason,omitempty"`
+	UpdatedAt     time.Time     `bson:"updated_at" json:"updated_at"`
+}
+
+type PayoutRowInput struct {
+	VPA    string  `json:"vpa"`
+	Amount float64 `json:"amount"`
+	Note   string  `json:"note"`
+}
+
+type CreatePayoutBatchRequest struct {
+	Reference string           `json:"reference"`
+	Rows      []PayoutRowInput `json:"rows"`
+}
+
+type PayoutRowError struct {
+	Row   int64  `json:"row"`
+	Error string `json:"error"`
+}
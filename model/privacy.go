@@ -0,0 +1,51 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// ErasureRequest is a data principal's request to have their personal data
+// erased. Records that must be kept for regulators are pseudonymized instead.
+type ErasureRequest struct {
+	ID            bson.ObjectID   `bson:"_id,omitempty" json:"id"`
+	UserID        bson.ObjectID   `bson:"user_id" json:"user_id"`
+	RequestedBy   AuditActor      `bson:"requested_by" json:"requested_by"`
+	Status        string          `bson:"status" json:"status"` // queued | processing | completed | failed
+	Report        []PrivacyAction `bson:"report,omitempty" json:"report,omitempty"`
+	Retained      []string        `bson:"retained,omitempty" json:"retained,omitempty"` // what was kept and why
+	FailureReason string          `bson:"failure_reason,omitempty" json:"failure_reason,omitempty"`
+	CreatedAt     time.Time       `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time       `bson:"updated_at" json:"updated_at"`
+	CompletedAt   *time.Time      `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+}
+
+// PrivacyAction reports what one erasure or retention step did to a
+// collection.
+type PrivacyAction struct {
+	Policy     string   `bson:"policy,omitempty" json:"policy,omitempty"` // retention only: notes | names | device_data
+	Collection string   `bson:"collection" json:"collection"`
+	Action     string   `bson:"action" json:"action"` // cleared | purged | closed | pseudonymized
+	Fields     []string `bson:"fields,omitempty" json:"fields,omitempty"`
+	Count      int64    `bson:"count" json:"count"`
+}
+
+// RetentionPeriods is how long each kind of personal data is kept. A zero
+// period keeps it indefinitely.
+type RetentionPeriods struct {
+	Notes      time.Duration // payment notes and mandate purposes
+	Names      time.Duration // beneficiaries, counterparty names, deregistered UPI numbers
+	DeviceData time.Duration // IP addresses in the audit trail
+}
+
+// RetentionRun is one pass of the retention policies. A dry run only counts
+// what would have changed.
+type RetentionRun struct {
+	ID         bson.ObjectID   `bson:"_id,omitempty" json:"id"`
+	DryRun     bool            `bson:"dry_run" json:"dry_run"`
+	Actions    []PrivacyAction `bson:"actions" json:"actions"`
+	Error      string          `bson:"error,omitempty" json:"error,omitempty"`
+	StartedAt  time.Time       `bson:"started_at" json:"started_at"`
+	FinishedAt time.Time       `bson:"finished_at" json:"finished_at"`
+}
@@ -0,0 +1,27 @@
+package model
+
+type GenerateQRRequest struct {
+	VPA    string  `json:"vpa"`    // optional, defaults to the user's default VPA
+	Name   string  `json:"name"`   // optional payee name shown to the payer
+	Amount float64 `json:"amount"` // optional fixed amount
+	Note   string  `json:"note"`
+}
+
+type QRCode struct {
+	Intent string `json:"intent"` // upi://pay?pa=...&pn=...
+	PNG    []byte `json:"png"`    // base64 encoded in JSON
+}
+
+type ParseQRRequest struct {
+	Payload string `json:"payload"` // decoded QR content or intent URI
+}
+
+type ParsedQR struct {
+	PayeeVPA     string        `json:"payee_vpa"`
+	PayeeName    string        `json:"payee_name"`
+	MerchantCode string        `json:"merchant_code,omitempty"`
+	TxnRef       string        `json:"txn_ref,omitempty"`
+	Signed       bool          `json:"signed"`
+	Verified     bool          `json:"verified"`
+	PayRequest   UPIPayRequest `json:"pay_request"`
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+
+	"github.com/banking-superapp/upi-service/secure"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type ScheduledPayment struct {
+	ID           bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID       bson.ObjectID `bson:"user_id" json:"user_id"`
+	ToVPA        string        `bson:"to_vpa" json:"to_vpa"`
+	Amount       float64       `bson:"amount" json:"amount"`
+	Note         secure.String `bson:"note" json:"note"`
+	Kind         string        `bson:"kind" json:"kind"`                     // one_time | recurring
+	Rule         string        `bson:"rule,omitempty" json:"rule,omitempty"` // 5 field cron expression evaluated in IST
+	EndDate      *time.Time    `bson:"end_date,omitempty" json:"end_date,omitempty"`
+	NextRunAt    *time.Time    `bson:"next_run_at,omitempty" json:"next_run_at,omitempty"`
+	SkipNext     bool          `bson:"skip_next" json:"skip_next"`
+	Status       string        `bson:"status" json:"status"` // active | paused | completed | cancelled
+	PreAuthToken string        `bson:"preauth_token" json:"-"`
+	PreAuthUntil time.Time     `bson:"preauth_until" json:"preauth_until"`
+	RunCount     int64         `bson:"run_count" json:"run_count"`
+	LastRunAt    *time.Time    `bson:"last_run_at,omitempty" json:"last_run_at,omitempty"`
+	CreatedAt    time.Time     `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time     `bson:"updated_at" json:"updated_at"`
+}
+
+type ScheduledPaymentRun struct {
+	ID            bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	ScheduleID    bson.ObjectID `bson:"schedule_id" json:"schedule_id"`
+	UserID        bson.ObjectID `bson:"user_id" json:"user_id"`
+	ScheduledFor  time.Time     `bson:"scheduled_for" json:"scheduled_for"`
+	Status        string        `bson:"status" json:"status"` // success | failed | skipped
+	TxnID         string        `bson:"txn_id,omitempty" json:"txn_id,omitempty"`
+	FailureReason string        `bson:"failure_reason,omitempty" json:"failure_reason,omitempty"`
+	ExecutedAt    time.Time     `bson:"executed_at" json:"executed_at"`
+}
+
+type CreateScheduleRequest struct {
+	ToVPA         string     `json:"to_vpa"`
+	Amount        float64    `json:"amount"`
+	Note          string     `json:"note"`
+	RunAt         *time.Time `json:"run_at"` // one-time payments
+	Rule          string     `json:"rule"`   // recurring payments, e.g. "0 9 1 * *" for 9am IST on the 1st
+	EndDate       *time.Time `json:"end_date"`
+	PINCredential string     `json:"pin_credential"` // encrypted UPI PIN block from the device
+}
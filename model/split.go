@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+
+	"github.com/banking-superapp/upi-service/secure"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type Split struct {
+	ID           bson.ObjectID      `bson:"_id,omitempty" json:"id"`
+	UserID       bson.ObjectID      `bson:"user_id" json:"user_id"`
+	ToVPA        string             `bson:"to_vpa" json:"to_vpa"`
+	TotalAmount  float64            `bson:"total_amount" json:"total_amount"`
+	SelfShare    float64            `bson:"self_share" json:"self_share"` // the creator's own part, not collected
+	Note         secure.String      `bson:"note" json:"note"`
+	Mode         string             `bson:"mode" json:"mode"`     // equal | custom | percentage
+	Status       string             `bson:"status" json:"status"` // open | settled | closed
+	Participants []SplitParticipant `bson:"participants" json:"participants"`
+	ExpiresAt    time.Time          `bson:"expires_at" json:"expires_at"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+type SplitParticipant struct {
+	VPA            string        `bson:"vpa" json:"vpa"`
+	Amount         float64       `bson:"amount" json:"amount"`
+	CollectID      bson.ObjectID `bson:"collect_id" json:"collect_id"`
+	Status         string        `bson:"status" json:"status"` // pending | paid | declined | expired
+	TxnID          string        `bson:"txn_id,omitempty" json:"txn_id,omitempty"`
+	RemindersSent  int           `bson:"reminders_sent" json:"reminders_sent"`
+	LastRemindedAt *time.Time    `bson:"last_reminded_at,omitempty" json:"last_reminded_at,omitempty"`
+}
+
+type SplitShareInput struct {
+	VPA        string  `json:"vpa"`
+	Amount     float64 `json:"amount"`     // custom mode
+	Percentage float64 `json:"percentage"` // percentage mode
+}
+
+type CreateSplitRequest struct {
+	TotalAmount  float64           `json:"total_amount"`
+	Note         string            `json:"note"`
+	Mode         string            `json:"mode"`         // equal | custom | percentage
+	IncludeSelf  bool              `json:"include_self"` // the creator keeps a share of the bill
+	ExpiresInHrs int               `json:"expires_in_hours"`
+	Participants []SplitShareInput `json:"participants"`
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type StatementJob struct {
+	ID            bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID        bson.ObjectID `bson:"user_id" json:"user_id"`
+	From          string        `bson:"from" json:"from"`     // YYYY-MM-DD in IST
+	To            string        `bson:"to" json:"to"`         // inclusive
+	Format        string        `bson:"format" json:"format"` // csv | pdf | json
+	Status        string        `bson:"status" json:"status"` // queued | processing | ready | failed
+	Entries       int64         `bson:"entries" json:"entries"`
+	FailureReason string        `bson:"failure_reason,omitempty" json:"failure_reason,omitempty"`
+	Content       []byte        `bson:"content,omitempty" json:"-"`
+	Size          int64         `bson:"size,omitempty" json:"size,omitempty"`
+	CreatedAt     time.Time     `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time     `bson:"updated_at" json:"updated_at"`
+	CompletedAt   *time.Time    `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	ExpiresAt     time.Time     `bson:"expires_at" json:"expires_at"`
+}
+
+// Statement is the rendered content of a statement, also served as the JSON
+// format.
+type Statement struct {
+	From           string             `json:"from"`
+	To             string             `json:"to"`
+	GeneratedAt    time.Time          `json:"generated_at"`
+	OpeningBalance float64            `json:"opening_balance"`
+	TotalDebits    float64            `json:"total_debits"`
+	TotalCredits   float64            `json:"total_credits"`
+	ClosingBalance float64            `json:"closing_balance"`
+	Sections       []StatementSection `json:"sections"`
+}
+
+// StatementSection holds the entries posted against one VPA and account.
+type StatementSection struct {
+	VPA          string        `json:"vpa"`
+	Account      string        `json:"account,omitempty"` // lite for UPI Lite
+	TotalDebits  float64       `json:"total_debits"`
+	TotalCredits float64       `json:"total_credits"`
+	Entries      []LedgerEntry `json:"entries"`
+}
+
+type CreateStatementRequest struct {
+	From   string `json:"from"` // YYYY-MM-DD
+	To     string `json:"to"`   // YYYY-MM-DD, inclusive
+	Format string `json:"format"`
+}
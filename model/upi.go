@@ -3,61 +3,76 @@ package model
 import (
 	"time"
 
+	"github.com/banking-superapp/upi-service/secure"
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
 type VPA struct {
-	ID          bson.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID      bson.ObjectID `bson:"user_id" json:"user_id"`
-	Address     string        `bson:"address" json:"address"` // user@bankname
-	AccountID   string        `bson:"account_id" json:"account_id"`
-	IsDefault   bool          `bson:"is_default" json:"is_default"`
-	IsActive    bool          `bson:"is_active" json:"is_active"`
-	CreatedAt   time.Time     `bson:"created_at" json:"created_at"`
-	UpdatedAt   time.Time     `bson:"updated_at" json:"updated_at"`
+	ID        bson.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID    bson.ObjectID  `bson:"user_id" json:"user_id"`
+	Address   string         `bson:"address" json:"address"` // user@bankname
+	AccountID secure.Indexed `bson:"account_id" json:"account_id"`
+	IsDefault bool           `bson:"is_default" json:"is_default"`
+	IsActive  bool           `bson:"is_active" json:"is_active"`
+	CreatedAt time.Time      `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time      `bson:"updated_at" json:"updated_at"`
 }
 
 type UPITransaction struct {
-	ID              bson.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID          bson.ObjectID `bson:"user_id" json:"user_id"`
-	TxnID           string        `bson:"txn_id" json:"txn_id"`
-	Type            string        `bson:"type" json:"type"` // pay | collect
-	FromVPA         string        `bson:"from_vpa" json:"from_vpa"`
-	ToVPA           string        `bson:"to_vpa" json:"to_vpa"`
-	Amount          float64       `bson:"amount" json:"amount"`
-	Note            string        `bson:"note" json:"note"`
-	Status          string        `bson:"status" json:"status"` // pending | success | failed | declined
-	FailureReason   string        `bson:"failure_reason,omitempty" json:"failure_reason,omitempty"`
-	TransactionDate time.Time     `bson:"transaction_date" json:"transaction_date"`
-	CreatedAt       time.Time     `bson:"created_at" json:"created_at"`
+	ID              bson.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID          bson.ObjectID  `bson:"user_id" json:"user_id"`
+	TxnID           string         `bson:"txn_id" json:"txn_id"`
+	RRN             string         `bson:"rrn,omitempty" json:"rrn,omitempty"` // 12 digit retrieval reference number
+	Type            string         `bson:"type" json:"type"`                   // pay | collect | refund
+	Direction       string         `bson:"-" json:"direction,omitempty"`       // sent | received, relative to the viewer
+	PaymentType     string         `bson:"payment_type" json:"payment_type"`   // P2P | P2M
+	FromVPA         string         `bson:"from_vpa" json:"from_vpa"`
+	ToVPA           string         `bson:"to_vpa" json:"to_vpa"`
+	Amount          float64        `bson:"amount" json:"amount"`
+	Note            secure.Text    `bson:"note" json:"note"`
+	TxnRef          string         `bson:"txn_ref,omitempty" json:"txn_ref,omitempty"` // merchant order reference (tr)
+	MerchantID      *bson.ObjectID `bson:"merchant_id,omitempty" json:"merchant_id,omitempty"`
+	MCC             string         `bson:"mcc,omitempty" json:"mcc,omitempty"`
+	MDRFee          float64        `bson:"mdr_fee,omitempty" json:"mdr_fee,omitempty"`
+	Category        string         `bson:"category,omitempty" json:"category,omitempty"` // payer's spend category
+	Channel         string         `bson:"channel,omitempty" json:"channel,omitempty"`   // lite for PIN-less UPI Lite debits
+	FundingSourceID *bson.ObjectID `bson:"funding_source_id,omitempty" json:"funding_source_id,omitempty"`
+	FundingType     string         `bson:"funding_type,omitempty" json:"funding_type,omitempty"` // savings | credit_line | credit_card; empty for the VPA's account
+	Status          string         `bson:"status" json:"status"`                                 // pending | success | failed | declined
+	FailureReason   string         `bson:"failure_reason,omitempty" json:"failure_reason,omitempty"`
+	TransactionDate time.Time      `bson:"transaction_date" json:"transaction_date"`
+	CreatedAt       time.Time      `bson:"created_at" json:"created_at"`
 }
 
 type Mandate struct {
-	ID            bson.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID        bson.ObjectID `bson:"user_id" json:"user_id"`
-	MandateID     string        `bson:"mandate_id" json:"mandate_id"`
-	PayerVPA      string        `bson:"payer_vpa" json:"payer_vpa"`
-	PayeeVPA      string        `bson:"payee_vpa" json:"payee_vpa"`
-	Amount        float64       `bson:"amount" json:"amount"`
-	Frequency     string        `bson:"frequency" json:"frequency"` // daily | weekly | monthly | yearly | as_presented
-	StartDate     time.Time     `bson:"start_date" json:"start_date"`
-	EndDate       time.Time     `bson:"end_date" json:"end_date"`
-	Purpose       string        `bson:"purpose" json:"purpose"`
-	Status        string        `bson:"status" json:"status"` // active | paused | revoked | expired
-	CreatedAt     time.Time     `bson:"created_at" json:"created_at"`
-	UpdatedAt     time.Time     `bson:"updated_at" json:"updated_at"`
+	ID        bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    bson.ObjectID `bson:"user_id" json:"user_id"`
+	MandateID string        `bson:"mandate_id" json:"mandate_id"`
+	PayerVPA  string        `bson:"payer_vpa" json:"payer_vpa"`
+	PayeeVPA  string        `bson:"payee_vpa" json:"payee_vpa"`
+	Amount    float64       `bson:"amount" json:"amount"`
+	Frequency string        `bson:"frequency" json:"frequency"` // daily | weekly | monthly | yearly | as_presented
+	StartDate time.Time     `bson:"start_date" json:"start_date"`
+	EndDate   time.Time     `bson:"end_date" json:"end_date"`
+	Purpose   secure.String `bson:"purpose" json:"purpose"`
+	Status    string        `bson:"status" json:"status"` // active | paused | revoked | expired
+	CreatedAt time.Time     `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time     `bson:"updated_at" json:"updated_at"`
 }
 
 type CollectRequest struct {
-	ID          bson.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID      bson.ObjectID `bson:"user_id" json:"user_id"`
-	FromVPA     string        `bson:"from_vpa" json:"from_vpa"`
-	ToVPA       string        `bson:"to_vpa" json:"to_vpa"`
-	Amount      float64       `bson:"amount" json:"amount"`
-	Note        string        `bson:"note" json:"note"`
-	Status      string        `bson:"status" json:"status"` // pending | approved | declined | expired
-	ExpiresAt   time.Time     `bson:"expires_at" json:"expires_at"`
-	CreatedAt   time.Time     `bson:"created_at" json:"created_at"`
+	ID        bson.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID    bson.ObjectID  `bson:"user_id" json:"user_id"`
+	FromVPA   string         `bson:"from_vpa" json:"from_vpa"`
+	ToVPA     string         `bson:"to_vpa" json:"to_vpa"`
+	Amount    float64        `bson:"amount" json:"amount"`
+	Note      secure.String  `bson:"note" json:"note"`
+	Status    string         `bson:"status" json:"status"` // pending | processing | approved | declined | expired
+	TxnID     string         `bson:"txn_id,omitempty" json:"txn_id,omitempty"`
+	SplitID   *bson.ObjectID `bson:"split_id,omitempty" json:"split_id,omitempty"`
+	ExpiresAt time.Time      `bson:"expires_at" json:"expires_at"`
+	ClaimedAt *time.Time     `bson:"claimed_at,omitempty" json:"-"` // when approval started paying it
+	CreatedAt time.Time      `bson:"created_at" json:"created_at"`
 }
 
 // Request/Response types
@@ -71,9 +86,13 @@ type ValidateVPARequest struct {
 }
 
 type UPIPayRequest struct {
-	ToVPA  string  `json:"to_vpa"`
-	Amount float64 `json:"amount"`
-	Note   string  `json:"note"`
+	ToVPA           string  `json:"to_vpa"`
+	BeneficiaryID   string  `json:"beneficiary_id,omitempty"` // instead of to_vpa
+	Amount          float64 `json:"amount"`
+	Note            string  `json:"note"`
+	TxnRef          string  `json:"txn_ref,omitempty"`           // tr from a dynamic merchant QR
+	Lite            bool    `json:"lite,omitempty"`              // pay from the UPI Lite wallet
+	FundingSourceID string  `json:"funding_source_id,omitempty"` // defaults to the VPA's account
 }
 
 type CollectRequestInput struct {
@@ -82,6 +101,41 @@ type CollectRequestInput struct {
 	Note    string  `json:"note"`
 }
 
+// TxnFilter narrows a user's transaction history. Zero values do not filter.
+type TxnFilter struct {
+	From         *time.Time
+	To           *time.Time
+	Status       string
+	Type         string
+	Direction    string // sent | received
+	Counterparty string // VPA on the other side
+	MinAmount    *float64
+	MaxAmount    *float64
+	Query        string // free text matched against notes
+}
+
+// TxnPageQuery selects a page of history. A non-empty Cursor takes precedence
+// over Page.
+type TxnPageQuery struct {
+	Cursor    string
+	Page      int64
+	Limit     int64
+	WithTotal bool
+}
+
+// TxnCursor is the decoded position of the last transaction on a page.
+type TxnCursor struct {
+	Date time.Time
+	ID   bson.ObjectID
+}
+
+type TxnPage struct {
+	Transactions []UPITransaction `json:"transactions"`
+	NextCursor   string           `json:"next_cursor,omitempty"`
+	Page         int64            `json:"page,omitempty"`
+	Total        *int64           `json:"total,omitempty"`
+}
+
 type CreateMandateRequest struct {
 	PayeeVPA  string    `json:"payee_vpa"`
 	Amount    float64   `json:"amount"`
@@ -92,7 +146,8 @@ type CreateMandateRequest struct {
 }
 
 type VPAValidateResponse struct {
-	VPA   string `json:"vpa"`
-	Name  string `json:"name"`
-	Valid bool   `json:"valid"`
+	VPA       string `json:"vpa"`
+	UPINumber string `json:"upi_number,omitempty"` // set when resolved through the mapper
+	Name      string `json:"name"`
+	Valid     bool   `json:"valid"`
 }
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// UPINumber maps a mobile number or numeric UPI ID to a VPA so payers can use
+// the number in place of the address.
+type UPINumber struct {
+	ID        bson.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    bson.ObjectID `bson:"user_id" json:"user_id"`
+	Number    string        `bson:"number" json:"number"`
+	Kind      string        `bson:"kind" json:"kind"` // mobile | numeric
+	VPA       string        `bson:"vpa" json:"vpa"`
+	Status    string        `bson:"status" json:"status"` // active | deregistered
+	CreatedAt time.Time     `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time     `bson:"updated_at" json:"updated_at"`
+}
+
+type RegisterUPINumberRequest struct {
+	Number string `json:"number"`
+	VPA    string `json:"vpa"`
+}
+
+type PortUPINumberRequest struct {
+	VPA string `json:"vpa"`
+}
@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// MongoStore shares buckets between replicas in the rate_limits collection.
+// Each Take is a single atomic update; a TTL index drops buckets once they
+// have refilled.
+type MongoStore struct {
+	col *mongo.Collection
+}
+
+func NewMongoStore(db *mongo.Database) *MongoStore {
+	return &MongoStore{col: db.Collection("rate_limits")}
+}
+
+type mongoBucket struct {
+	Tokens  float64 `bson:"tokens"`
+	Allowed bool    `bson:"allowed"`
+}
+
+func (s *MongoStore) Take(ctx context.Context, key string, rate float64, burst int) (bool, time.Duration, error) {
+	now := time.Now()
+	elapsed := bson.M{"$divide": bson.A{bson.M{"$subtract": bson.A{now, bson.M{"$ifNull": bson.A{"$updated_at", now}}}}, 1000}}
+	refilled := bson.M{"$min": bson.A{burst, bson.M{"$add": bson.A{
+		bson.M{"$ifNull": bson.A{"$tokens", burst}},
+		bson.M{"$multiply": bson.A{elapsed, rate}},
+	}}}}
+	pipeline := bson.A{
+		bson.M{"$set": bson.M{"tokens": refilled, "updated_at": now}},
+		bson.M{"$set": bson.M{"allowed": bson.M{"$gte": bson.A{"$tokens", 1}}}},
+		bson.M{"$set": bson.M{"tokens": bson.M{"$cond": bson.A{"$allowed", bson.M{"$subtract": bson.A{"$tokens", 1}}, "$tokens"}}}},
+		bson.M{"$set": bson.M{"expires_at": bson.M{"$add": bson.A{now, bson.M{"$multiply": bson.A{
+			bson.M{"$divide": bson.A{bson.M{"$subtract": bson.A{burst, "$tokens"}}, rate}}, 1000,
+		}}}}}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var b mongoBucket
+	if err := s.col.FindOneAndUpdate(ctx, bson.M{"_id": key}, pipeline, opts).Decode(&b); err != nil {
+		return false, 0, err
+	}
+	if b.Allowed {
+		return true, 0, nil
+	}
+	return false, time.Duration((1 - b.Tokens) / rate * float64(time.Second)), nil
+}
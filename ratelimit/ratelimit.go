@@ -0,0 +1,171 @@
+// Package ratelimit throttles requests with token buckets kept in a
+// pluggable Store. Each rule names a route and the limits that apply to it
+// per user, per device and per client IP.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/banking-superapp/upi-service/metrics"
+)
+
+// Store keeps token buckets. Take spends one token from the bucket named
+// key, which holds up to burst tokens and regains rate tokens per second;
+// when it is empty Take reports how long until a token is available.
+type Store interface {
+	Take(ctx context.Context, key string, rate float64, burst int) (bool, time.Duration, error)
+}
+
+// Dimensions a limit can be counted per.
+const (
+	User   = "user"
+	Device = "device"
+	IP     = "ip"
+)
+
+// Limit allows Count requests per Period, in bursts of up to Count.
+type Limit struct {
+	Dimension string
+	Count     int
+	Period    time.Duration
+}
+
+// Rule applies its limits to requests matching Method and Path. Path is a
+// route template such as /v1/upi/collect/:collectId/approve; a Pattern of
+// "*" matches every request.
+type Rule struct {
+	Pattern string
+	Method  string
+	Path    string
+	Limits  []Limit
+}
+
+// ParseRules reads rules written as
+//
+//	METHOD /path=dimension:count/period,...;*=dimension:count/period
+//
+// where period is s, m or h.
+func ParseRules(spec string) ([]Rule, error) {
+	var rules []Rule
+	for _, part := range strings.Split(spec, ";") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		pattern, limits, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("rate limit rule %q: missing =", part)
+		}
+		r := Rule{Pattern: strings.TrimSpace(pattern)}
+		if r.Pattern != "*" {
+			method, path, ok := strings.Cut(r.Pattern, " ")
+			if !ok || !strings.HasPrefix(path, "/") {
+				return nil, fmt.Errorf("rate limit rule %q: pattern must be * or METHOD /path", part)
+			}
+			r.Method, r.Path = strings.ToUpper(method), path
+		}
+		for _, l := range strings.Split(limits, ",") {
+			limit, err := parseLimit(strings.TrimSpace(l))
+			if err != nil {
+				return nil, fmt.Errorf("rate limit rule %q: %w", part, err)
+			}
+			r.Limits = append(r.Limits, limit)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+var periods = map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}
+
+func parseLimit(s string) (Limit, error) {
+	dimension, rate, ok := strings.Cut(s, ":")
+	count, period, ok2 := strings.Cut(rate, "/")
+	if !ok || !ok2 {
+		return Limit{}, fmt.Errorf("limit %q must look like user:10/m", s)
+	}
+	if dimension != User && dimension != Device && dimension != IP {
+		return Limit{}, fmt.Errorf("unknown dimension %q", dimension)
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("limit %q needs a positive count", s)
+	}
+	p, ok := periods[period]
+	if !ok {
+		return Limit{}, fmt.Errorf("limit %q needs a period of s, m or h", s)
+	}
+	return Limit{Dimension: dimension, Count: n, Period: p}, nil
+}
+
+func (r *Rule) matches(method, path string) bool {
+	if r.Pattern == "*" {
+		return true
+	}
+	if r.Method != method {
+		return false
+	}
+	want := strings.Split(strings.Trim(r.Path, "/"), "/")
+	got := strings.Split(strings.Trim(path, "/"), "/")
+	if len(want) != len(got) {
+		return false
+	}
+	for i := range want {
+		if !strings.HasPrefix(want[i], ":") && want[i] != got[i] {
+			return false
+		}
+	}
+	return true
+}
+
+type Limiter struct {
+	store Store
+	rules []Rule
+}
+
+func New(store Store, rules []Rule) *Limiter {
+	return &Limiter{store: store, rules: rules}
+}
+
+// Allow spends a token from every bucket the request falls in and returns
+// how long the caller must wait when any of them was empty. ids maps each
+// dimension to the caller's identity in it; dimensions without one, such as
+// the device of a client that sends no device ID, are not limited.
+//
+// A store error lets the request through: failing closed would turn a store
+// outage into a payments outage.
+func (l *Limiter) Allow(ctx context.Context, method, path string, ids map[string]string) (time.Duration, bool) {
+	var wait time.Duration
+	allowed := true
+	for i := range l.rules {
+		r := &l.rules[i]
+		if !r.matches(method, path) {
+			continue
+		}
+		for _, limit := range r.Limits {
+			id := ids[limit.Dimension]
+			if id == "" {
+				continue
+			}
+			key := r.Pattern + "|" + limit.Dimension + ":" + id
+			rate := float64(limit.Count) / limit.Period.Seconds()
+			ok, retry, err := l.store.Take(ctx, key, rate, limit.Count)
+			switch {
+			case err != nil:
+				slog.WarnContext(ctx, "rate limit store failed", "rule", r.Pattern, "error", err)
+				metrics.RateLimitDecisions.WithLabelValues(r.Pattern, limit.Dimension, "error").Inc()
+			case !ok:
+				allowed = false
+				wait = max(wait, retry)
+				metrics.RateLimitDecisions.WithLabelValues(r.Pattern, limit.Dimension, "limited").Inc()
+			default:
+				metrics.RateLimitDecisions.WithLabelValues(r.Pattern, limit.Dimension, "allowed").Inc()
+			}
+		}
+	}
+	return wait, allowed
+}
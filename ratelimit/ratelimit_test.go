@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    []Rule
+		wantErr bool
+	}{
+		{name: "empty", spec: " ; "},
+		{
+			name: "catch all",
+			spec: "*=user:300/m,ip:1200/m",
+			want: []Rule{{Pattern: "*", Limits: []Limit{{User, 300, time.Minute}, {IP, 1200, time.Minute}}}},
+		},
+		{
+			name: "routes in order",
+			spec: "post /v1/upi/pay=user:10/m, device:5/s ; GET /v1/upi/collect/:collectId=ip:100/h;",
+			want: []Rule{
+				{Pattern: "post /v1/upi/pay", Method: "POST", Path: "/v1/upi/pay", Limits: []Limit{{User, 10, time.Minute}, {Device, 5, time.Second}}},
+				{Pattern: "GET /v1/upi/collect/:collectId", Method: "GET", Path: "/v1/upi/collect/:collectId", Limits: []Limit{{IP, 100, time.Hour}}},
+			},
+		},
+		{name: "missing =", spec: "*", wantErr: true},
+		{name: "pattern without method", spec: "/v1/upi/pay=user:1/m", wantErr: true},
+		{name: "path without slash", spec: "POST v1/upi/pay=user:1/m", wantErr: true},
+		{name: "no limits", spec: "*=", wantErr: true},
+		{name: "limit without dimension", spec: "*=10/m", wantErr: true},
+		{name: "limit without period", spec: "*=user:10", wantErr: true},
+		{name: "unknown dimension", spec: "*=account:10/m", wantErr: true},
+		{name: "zero count", spec: "*=user:0/m", wantErr: true},
+		{name: "count not a number", spec: "*=user:ten/m", wantErr: true},
+		{name: "unknown period", spec: "*=user:10/d", wantErr: true},
+		{name: "one bad rule fails all", spec: "*=user:10/m;POST /v1/upi/pay=user:1/w", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRules(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rules = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleMatches(t *testing.T) {
+	rules, err := ParseRules("POST /v1/upi/collect/:collectId/approve=user:1/m;*=user:1/m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		method, path string
+		want         bool
+	}{
+		{"POST", "/v1/upi/collect/abc/approve", true},
+		{"POST", "/v1/upi/collect/abc/approve/", true},
+		{"GET", "/v1/upi/collect/abc/approve", false},
+		{"POST", "/v1/upi/collect/abc/decline", false},
+		{"POST", "/v1/upi/collect/approve", false},
+	}
+	for _, tt := range tests {
+		if got := rules[0].matches(tt.method, tt.path); got != tt.want {
+			t.Errorf("matches(%s %s) = %v, want %v", tt.method, tt.path, got, tt.want)
+		}
+		if !rules[1].matches(tt.method, tt.path) {
+			t.Errorf("* does not match %s %s", tt.method, tt.path)
+		}
+	}
+}
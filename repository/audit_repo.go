@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/banking-superapp/upi-service/model"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type AuditRepo interface {
+	Record(ctx context.Context, e *model.AuditEntry) error
+	// Finish sets the outcome of an entry recorded as started.
+	Finish(ctx context.Context, id bson.ObjectID, outcome, errMsg string) error
+	// FindRecent lists the newest entries first, optionally for one target.
+	FindRecent(ctx context.Context, target string, limit int64) ([]model.AuditEntry, error)
+}
+
+type auditRepo struct{ col *mongo.Collection }
+
+func NewAuditRepo(db *mongo.Database) AuditRepo {
+	return &auditRepo{col: db.Collection("admin_audit_log")}
+}
+
+func (r *auditRepo) Record(ctx context.Context, e *model.AuditEntry) error {
+	e.At = time.Now()
+	res, err := r.col.InsertOne(ctx, e)
+	if err != nil {
+		return err
+	}
+	e.ID, _ = res.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+func (r *auditRepo) Finish(ctx context.Context, id bson.ObjectID, outcome, errMsg string) error {
+	set := bson.M{"outcome": outcome, "finished_at": time.Now()}
+	if errMsg != "" {
+		set["error"] = errMsg
+	}
+	res, err := r.col.UpdateOne(ctx, bson.M{"_id": id, "outcome": "started"}, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *auditRepo) FindRecent(ctx context.Context, target string, limit int64) ([]model.AuditEntry, error) {
+	filter := bson.M{}
+	if target != "" {
+		filter["target"] = target
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "at", Value: -1}, {Key: "_id", Value: -1}}).SetLimit(limit)
+	cursor, err := r.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var entries []model.AuditEntry
+	cursor.All(ctx, &entries)
+	return entries, nil
+}
+
+// AuditTrailRepo is append only: events are never updated or deleted, apart
+// from retention clearing actor IPs, which the hash does not cover. Events
+// wait in an outbox until they are linked into the trail. In production: the
+// service's database role only has insert and find on the trail collection,
+// and retention runs under a role of its own.
+type AuditTrailRepo interface {
+	// Enqueue stores e in the outbox, assigning the ID it keeps in the trail.
+	Enqueue(ctx context.Context, e *model.AuditEvent) error
+	// Queued lists up to limit outbox events, oldest first.
+	Queued(ctx context.Context, limit int64) ([]model.AuditEvent, error)
+	// Dequeue removes an event from the outbox once it is in the trail.
+	Dequeue(ctx context.Context, id bson.ObjectID) error
+	// Append inserts e, or returns a duplicate key error when e.Seq or e.ID
+	// is taken.
+	Append(ctx context.Context, e *model.AuditEvent) error
+	// Appended reports whether the trail holds the event with id.
+	Appended(ctx context.Context, id bson.ObjectID) (bool, error)
+	// Last returns the event with the highest sequence number.
+	Last(ctx context.Context) (*model.AuditEvent, error)
+	// Find lists events matching q, newest first.
+	Find(ctx context.Context, q *model.AuditQuery, limit int64) ([]model.AuditEvent, error)
+	// FindFrom lists events from sequence number seq on, oldest first.
+	FindFrom(ctx context.Context, seq, limit int64) ([]model.AuditEvent, error)
+}
+
+type auditTrailRepo struct {
+	col    *mongo.Collection
+	outbox *mongo.Collection
+}
+
+func NewAuditTrailRepo(db *mongo.Database) AuditTrailRepo {
+	return &auditTrailRepo{col: db.Collection("audit_trail"), outbox: db.Collection("audit_outbox")}
+}
+
+func (r *auditTrailRepo) Enqueue(ctx context.Context, e *model.AuditEvent) error {
+	e.ID = bson.NewObjectID()
+	_, err := r.outbox.InsertOne(ctx, e)
+	return err
+}
+
+func (r *auditTrailRepo) Queued(ctx context.Context, limit int64) ([]model.AuditEvent, error) {
+	cursor, err := r.outbox.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var events []model.AuditEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *auditTrailRepo) Dequeue(ctx context.Context, id bson.ObjectID) error {
+	_, err := r.outbox.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (r *auditTrailRepo) Append(ctx context.Context, e *model.AuditEvent) error {
+	res, err := r.col.InsertOne(ctx, e)
+	if err != nil {
+		return err
+	}
+	e.ID, _ = res.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+func (r *auditTrailRepo) Appended(ctx context.Context, id bson.ObjectID) (bool, error) {
+	n, err := r.col.CountDocuments(ctx, bson.M{"_id": id}, options.Count().SetLimit(1))
+	return n > 0, err
+}
+
+func (r *auditTrailRepo) Last(ctx context.Context) (*model.AuditEvent, error) {
+	var e model.AuditEvent
+	opts := options.FindOne().SetSort(bson.D{{Key: "seq", Value: -1}})
+	if err := r.col.FindOne(ctx, bson.M{}, opts).Decode(&e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *auditTrailRepo) Find(ctx context.Context, q *model.AuditQuery, limit int64) ([]model.AuditEvent, error) {
+	filter := bson.M{}
+	if q.EntityType != "" {
+		filter["entity_type"] = q.EntityType
+	}
+	if q.EntityID != "" {
+		filter["entity_id"] = q.EntityID
+	}
+	if q.ActorID != "" {
+		filter["actor.id"] = q.ActorID
+	}
+	if q.Action != "" {
+		filter["action"] = q.Action
+	}
+	if q.From != nil || q.To != nil {
+		at := bson.M{}
+		if q.From != nil {
+			at["$gte"] = *q.From
+		}
+		if q.To != nil {
+			at["$lt"] = *q.To
+		}
+		filter["at"] = at
+	}
+	if q.BeforeSeq > 0 {
+		filter["seq"] = bson.M{"$lt": q.BeforeSeq}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "seq", Value: -1}}).SetLimit(limit)
+	return r.find(ctx, filter, opts)
+}
+
+func (r *auditTrailRepo) FindFrom(ctx context.Context, seq, limit int64) ([]model.AuditEvent, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "seq", Value: 1}}).SetLimit(limit)
+	return r.find(ctx, bson.M{"seq": bson.M{"$gte": seq}}, opts)
+}
+
+func (r *auditTrailRepo) find(ctx context.Context, filter bson.M, opts *options.FindOptionsBuilder) ([]model.AuditEvent, error) {
+	cursor, err := r.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var events []model.AuditEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/banking-superapp/upi-service/model"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type BeneficiaryRepo interface {
+	Create(ctx context.Context, b *model.Beneficiary) error
+	FindByID(ctx context.Context, userID, id bson.ObjectID) (*model.Beneficiary, error)
+	// FindByUserID lists favorites first, then the most recently paid.
+	FindByUserID(ctx context.Context, userID bson.ObjectID, favoritesOnly bool) ([]model.Beneficiary, error)
+	Update(ctx context.Context, userID, id bson.ObjectID, set bson.M) (*model.Beneficiary, error)
+	Delete(ctx context.Context, userID, id bson.ObjectID) error
+	// TouchLastPaid stamps the user's beneficiary for vpa, if there is one.
+	TouchLastPaid(ctx context.Context, userID bson.ObjectID, vpa string, at time.Time) error
+}
+
+type beneficiaryRepo struct{ col *mongo.Collection }
+
+func NewBeneficiaryRepo(db *mongo.Database) BeneficiaryRepo {
+	return &beneficiaryRepo{col: db.Collection("beneficiaries")}
+}
+
+func (r *beneficiaryRepo) Create(ctx context.Context, b *model.Beneficiary) error {
+	b.CreatedAt = time.Now()
+	b.UpdatedAt = b.CreatedAt
+	res, err := r.col.InsertOne(ctx, b)
+	if err != nil {
+		return err
+	}
+	b.ID, _ = res.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+func (r *beneficiaryRepo) FindByID(ctx context.Context, userID, id bson.ObjectID) (*model.Beneficiary, error) {
+	var b model.Beneficiary
+	if err := r.col.FindOne(ctx, bson.M{"_id": id, "user_id": userID}).Decode(&b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *beneficiaryRepo) FindByUserID(ctx context.Context, userID bson.ObjectID, favoritesOnly bool) ([]model.Beneficiary, error) {
+	filter := bson.M{"user_id": userID}
+	if favoritesOnly {
+		filter["is_favorite"] = true
+	}
+	opts := options.Find().SetSort(bson.D{
+		{Key: "is_favorite", Value: -1},
+		{Key: "last_paid_at", Value: -1},
+	})
+	cursor, err := r.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var beneficiaries []model.Beneficiary
+	cursor.All(ctx, &beneficiaries)
+	// Nicknames are encrypted, so the order is finished here rather than by
+	// the query.
+	sort.SliceStable(beneficiaries, func(i, j int) bool {
+		a, b := &beneficiaries[i], &beneficiaries[j]
+		if a.IsFavorite != b.IsFavorite {
+			return a.IsFavorite
+		}
+		if !sameTime(a.LastPaidAt, b.LastPaidAt) {
+			return b.LastPaidAt == nil || a.LastPaidAt != nil && a.LastPaidAt.After(*b.LastPaidAt)
+		}
+		return strings.ToLower(string(a.Nickname)) < strings.ToLower(string(b.Nickname))
+	})
+	return beneficiaries, nil
+}
+
+func (r *beneficiaryRepo) Update(ctx context.Context, userID, id bson.ObjectID, set bson.M) (*model.Beneficiary, error) {
+	set["updated_at"] = time.Now()
+	var b model.Beneficiary
+	err := r.col.FindOneAndUpdate(ctx, bson.M{"_id": id, "user_id": userID},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&b)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *beneficiaryRepo) Delete(ctx context.Context, userID, id bson.ObjectID) error {
+	res, err := r.col.DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *beneficiaryRepo) TouchLastPaid(ctx context.Context, userID bson.ObjectID, vpa string, at time.Time) error {
+	_, err := r.col.UpdateOne(ctx, bson.M{"user_id": userID, "vpa": vpa},
+		bson.M{"$max": bson.M{"last_paid_at": at}})
+	return err
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/banking-superapp/upi-service/model"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type BudgetRepo interface {
+	// Upsert sets the limit for the budget's category and restarts its alerts.
+	Upsert(ctx context.Context, b *model.Budget) error
+	Find(ctx context.Context, userID bson.ObjectID, category string) (*model.Budget, error)
+	FindByUserID(ctx context.Context, userID bson.ObjectID) ([]model.Budget, error)
+	// Delete returns mongo.ErrNoDocuments when there was no budget.
+	Delete(ctx context.Context, userID bson.ObjectID, category string) error
+	// ClaimAlert records threshold as sent for month. It reports false when it
+	// already was, so each alert fires once even with concurrent payments.
+	ClaimAlert(ctx context.Context, budgetID bson.ObjectID, month string, threshold int) (bool, error)
+}
+
+type budgetRepo struct{ col *mongo.Collection }
+
+func NewBudgetRepo(db *mongo.Database) BudgetRepo {
+	return &budgetRepo{col: db.Collection("budgets")}
+}
+
+func (r *budgetRepo) Upsert(ctx context.Context, b *model.Budget) error {
+	now := time.Now()
+	return r.col.FindOneAndUpdate(ctx,
+		bson.M{"user_id": b.UserID, "category": b.Category},
+		bson.M{
+			"$set":         bson.M{"limit": b.Limit, "updated_at": now},
+			"$unset":       bson.M{"alert_month": "", "alerts_sent": ""},
+			"$setOnInsert": bson.M{"created_at": now},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(b)
+}
+
+func (r *budgetRepo) Find(ctx context.Context, userID bson.ObjectID, category string) (*model.Budget, error) {
+	var b model.Budget
+	if err := r.col.FindOne(ctx, bson.M{"user_id": userID, "category": category}).Decode(&b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *budgetRepo) FindByUserID(ctx context.Context, userID bson.ObjectID) ([]model.Budget, error) {
+	cursor, err := r.col.Find(ctx, bson.M{"user_id": userID}, options.Find().SetSort(bson.D{{Key: "category", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var budgets []model.Budget
+	cursor.All(ctx, &budgets)
+	return budgets, nil
+}
+
+func (r *budgetRepo) Delete(ctx context.Context, userID bson.ObjectID, category string) error {
+	res, err := r.col.DeleteOne(ctx, bson.M{"user_id": userID, "category": category})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *budgetRepo) ClaimAlert(ctx context.Context, budgetID bson.ObjectID, month string, threshold int) (bool, error) {
+	// Two rounds cover a concurrent payment starting the month in between.
+	for range 2 {
+		res, err := r.col.UpdateOne(ctx,
+			bson.M{"_id": budgetID, "alert_month": month, "alerts_sent": bson.M{"$ne": threshold}},
+			bson.M{"$push": bson.M{"alerts_sent": threshold}})
+		if err != nil || res.ModifiedCount == 1 {
+			return err == nil, err
+		}
+		// The first alert of a new month starts the list over.
+		res, err = r.col.UpdateOne(ctx,
+			bson.M{"_id": budgetID, "alert_month": bson.M{"$ne": month}},
+			bson.M{"$set": bson.M{"alert_month": month, "alerts_sent": bson.A{threshold}}})
+		if err != nil || res.ModifiedCount == 1 {
+			return err == nil, err
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/banking-superapp/upi-service/model"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type CategoryOverrideRepo interface {
+	Upsert(ctx context.Context, o *model.CategoryOverride) error
+	Find(ctx context.Context, userID bson.ObjectID, payeeVPA string) (*model.CategoryOverride, error)
+	FindByUserID(ctx context.Context, userID bson.ObjectID) ([]model.CategoryOverride, error)
+	// Delete returns mongo.ErrNoDocuments when there was no override.
+	Delete(ctx context.Context, userID bson.ObjectID, payeeVPA string) error
+}
+
+type categoryOverrideRepo struct{ col *mongo.Collection }
+
+func NewCategoryOverrideRepo(db *mongo.Database) CategoryOverrideRepo {
+	return &categoryOverrideRepo{col: db.Collection("category_overrides")}
+}
+
+func (r *categoryOverrideRepo) Upsert(ctx context.Context, o *model.CategoryOverride) error {
+	o.UpdatedAt = time.Now()
+	return r.col.FindOneAndUpdate(ctx,
+		bson.M{"user_id": o.UserID, "payee_vpa": o.PayeeVPA},
+		bson.M{"$set": bson.M{"category": o.Category, "updated_at": o.UpdatedAt}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(o)
+}
+
+func (r *categoryOverrideRepo) Find(ctx context.Context, userID bson.ObjectID, payeeVPA string) (*model.CategoryOverride, error) {
+	var o model.CategoryOverride
+	if err := r.col.FindOne(ctx, bson.M{"user_id": userID, "payee_vpa": payeeVPA}).Decode(&o); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (r *categoryOverrideRepo) FindByUserID(ctx context.Context, userID bson.ObjectID) ([]model.CategoryOverride, error) {
+	cursor, err := r.col.Find(ctx, bson.M{"user_id": userID}, options.Find().SetSort(bson.D{{Key: "payee_vpa", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var overrides []model.CategoryOverride
+	cursor.All(ctx, &overrides)
+	return overrides, nil
+}
+
+func (r *categoryOverrideRepo) Delete(ctx context.Context, userID bson.ObjectID, payeeVPA string) error {
+	res, err := r.col.DeleteOne(ctx, bson.M{"user_id": userID, "payee_vpa": payeeVPA})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/banking-superapp/upi-service/secure"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// encryptedFields lists every stored field typed secure.String, Indexed or
+// Text. VPAs stay in clear: payments are routed and looked up by them.
+var encryptedFields = []struct {
+	collection, field string
+	decode            func() interface{}
+}{
+	{"vpas", "account_id", newIndexed},
+	{"funding_sources", "account_id", newIndexed},
+	{"merchants", "settlement_account", newIndexed},
+	{"merchants", "legal_name", newString},
+	{"merchant_settlements", "settlement_account", newIndexed},
+	{"beneficiaries", "nickname", newString},
+	{"beneficiaries", "verified_name", newString},
+	{"upi_transactions", "note", newText},
+	{"collect_requests", "note", newString},
+	{"mandates", "purpose", newString},
+	{"ledger_entries", "note", newString},
+	{"ledger_entries", "counterparty", newString},
+	{"merchant_orders", "note", newString},
+	{"payout_rows", "note", newString},
+	{"scheduled_payments", "note", newString},
+	{"splits", "note", newString},
+}
+
+func newString() interface{}  { return new(secure.String) }
+func newIndexed() interface{} { return new(secure.Indexed) }
+func newText() interface{}    { return new(secure.Text) }
+
+// Reencrypt seals values still stored in clear and rewraps those sealed
+// under a key other than the current one, returning how many it rewrote (or
+// with dryRun, would have) per collection.field. Each write is conditional
+// on the value being unchanged, so it is safe alongside live traffic.
+func Reencrypt(ctx context.Context, db *mongo.Database, dryRun bool) (map[string]int64, error) {
+	if !secure.Enabled() {
+		return nil, secure.ErrNoKeys
+	}
+	counts := make(map[string]int64)
+	for _, f := range encryptedFields {
+		col := db.Collection(f.collection)
+		filter := bson.M{"$or": bson.A{
+			bson.M{f.field: bson.M{"$type": "string", "$ne": ""}},
+			bson.M{f.field + ".k": bson.M{"$exists": true, "$ne": secure.CurrentKeyID()}},
+		}}
+		name := f.collection + "." + f.field
+		if dryRun {
+			n, err := col.CountDocuments(ctx, filter)
+			if err != nil {
+				return counts, err
+			}
+			counts[name] = n
+			continue
+		}
+
+		cursor, err := col.Find(ctx, filter, options.Find().SetProjection(bson.M{f.field: 1}))
+		if err != nil {
+			return counts, err
+		}
+		for cursor.Next(ctx) {
+			id := cursor.Current.Lookup("_id")
+			old := cursor.Current.Lookup(f.field)
+			value := f.decode()
+			if err := old.Unmarshal(value); err != nil {
+				cursor.Close(ctx)
+				return counts, fmt.Errorf("%s %s: %w", name, id, err)
+			}
+			res, err := col.UpdateOne(ctx, bson.M{"_id": id, f.field: old}, bson.M{"$set": bson.M{f.field: value}})
+			if err != nil {
+				cursor.Close(ctx)
+				return counts, err
+			}
+			counts[name] += res.ModifiedCount
+		}
+		err = cursor.Err()
+		cursor.Close(ctx)
+		if err != nil {
+			return counts, err
+		}
+	}
+	return counts, nil
+}
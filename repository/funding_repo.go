@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/banking-superapp/upi-service/model"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type FundingSourceRepo interface {
+	Create(ctx context.Context, f *model.FundingSource) error
+	FindActive(ctx context.Context, userID, id bson.ObjectID) (*model.FundingSource, error)
+	// FindByUserID lists active sources and unlinked ones that still owe, so
+	// they can be repaid.
+	FindByUserID(ctx context.Context, userID bson.ObjectID) ([]model.FundingSource, error)
+	FindByID(ctx context.Context, userID, id bson.ObjectID) (*model.FundingSource, error)
+	// Unlink unlinks an active source with nothing outstanding, returning
+	// mongo.ErrNoDocuments otherwise.
+	Unlink(ctx context.Context, userID, id bson.ObjectID) error
+	// Draw adds amount to the outstanding of an active credit source unless
+	// it would exceed the credit limit, returning mongo.ErrNoDocuments if so.
+	// Release reverses a draw.
+	Draw(ctx context.Context, id bson.ObjectID, amount float64) error
+	Release(ctx context.Context, id bson.ObjectID, amount float64) error
+	// Repay takes amount off the outstanding of a source, linked or not,
+	// returning mongo.ErrNoDocuments if it is more than is owed.
+	Repay(ctx context.Context, userID, id bson.ObjectID, amount float64) (*model.FundingSource, error)
+}
+
+type fundingSourceRepo struct{ col *mongo.Collection }
+
+func NewFundingSourceRepo(db *mongo.Database) FundingSourceRepo {
+	return &fundingSourceRepo{col: db.Collection("funding_sources")}
+}
+
+func (r *fundingSourceRepo) Create(ctx context.Context, f *model.FundingSource) error {
+	f.Status = "active"
+	f.CreatedAt = time.Now()
+	f.UpdatedAt = f.CreatedAt
+	res, err := r.col.InsertOne(ctx, f)
+	if err != nil {
+		return err
+	}
+	f.ID, _ = res.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+func (r *fundingSourceRepo) FindActive(ctx context.Context, userID, id bson.ObjectID) (*model.FundingSource, error) {
+	var f model.FundingSource
+	if err := r.col.FindOne(ctx, bson.M{"_id": id, "user_id": userID, "status": "active"}).Decode(&f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (r *fundingSourceRepo) FindByUserID(ctx context.Context, userID bson.ObjectID) ([]model.FundingSource, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.col.Find(ctx, bson.M{
+		"user_id": userID,
+		"$or":     bson.A{bson.M{"status": "active"}, bson.M{"outstanding": bson.M{"$gt": 0}}},
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var sources []model.FundingSource
+	cursor.All(ctx, &sources)
+	return sources, nil
+}
+
+func (r *fundingSourceRepo) FindByID(ctx context.Context, userID, id bson.ObjectID) (*model.FundingSource, error) {
+	var f model.FundingSource
+	if err := r.col.FindOne(ctx, bson.M{"_id": id, "user_id": userID}).Decode(&f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (r *fundingSourceRepo) Unlink(ctx context.Context, userID, id bson.ObjectID) error {
+	res, err := r.col.UpdateOne(ctx, bson.M{"_id": id, "user_id": userID, "status": "active", "outstanding": bson.M{"$not": bson.M{"$gt": 0}}},
+		bson.M{"$set": bson.M{"status": "unlinked", "updated_at": time.Now()}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *fundingSourceRepo) Draw(ctx context.Context, id bson.ObjectID, amount float64) error {
+	res, err := r.col.UpdateOne(ctx, bson.M{
+		"_id":    id,
+		"status": "active",
+		"$expr":  bson.M{"$lte": bson.A{bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$outstanding", 0}}, amount}}, "$credit_limit"}},
+	}, bson.M{
+		"$inc": bson.M{"outstanding": amount},
+		"$set": bson.M{"updated_at": time.Now()},
+	})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *fundingSourceRepo) Release(ctx context.Context, id bson.ObjectID, amount float64) error {
+	_, err := r.col.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$inc": bson.M{"outstanding": -amount},
+		"$set": bson.M{"updated_at": time.Now()},
+	})
+	return err
+}
+
+func (r *fundingSourceRepo) Repay(ctx context.Context, userID, id bson.ObjectID, amount float64) (*model.FundingSource, error) {
+	var f model.FundingSource
+	err := r.col.FindOneAndUpdate(ctx, bson.M{
+		"_id":         id,
+		"user_id":     userID,
+		"outstanding": bson.M{"$gte": amount},
+	}, bson.M{
+		"$inc": bson.M{"outstanding": -amount},
+		"$set": bson.M{"updated_at": time.Now()},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&f)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/banking-superapp/upi-service/model"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type LedgerRepo interface {
+	// Post inserts entries. Entries already posted for the same txn and
+	// direction are ignored, so posting is safe to retry.
+	Post(ctx context.Context, entries []model.LedgerEntry) error
+	// Balance returns credits minus debits posted to the user before t.
+	Balance(ctx context.Context, userID bson.ObjectID, before time.Time) (float64, error)
+	// AccountBalance returns credits minus debits posted to one of the user's
+	// accounts, such as their UPI Lite wallet.
+	AccountBalance(ctx context.Context, userID bson.ObjectID, account string) (float64, error)
+	FindRange(ctx context.Context, userID bson.ObjectID, from, to time.Time) ([]model.LedgerEntry, error)
+	CountRange(ctx context.Context, userID bson.ObjectID, from, to time.Time) (int64, error)
+	FindByTxnID(ctx context.Context, txnID string) ([]model.LedgerEntry, error)
+}
+
+type ledgerRepo struct{ col *mongo.Collection }
+
+func NewLedgerRepo(db *mongo.Database) LedgerRepo {
+	return &ledgerRepo{col: db.Collection("ledger_entries")}
+}
+
+func (r *ledgerRepo) Post(ctx context.Context, entries []model.LedgerEntry) error {
+	docs := make([]interface{}, len(entries))
+	for i := range entries {
+		docs[i] = entries[i]
+	}
+	_, err := r.col.InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if err != nil && mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+func (r *ledgerRepo) Balance(ctx context.Context, userID bson.ObjectID, before time.Time) (float64, error) {
+	return r.sum(ctx, bson.M{"user_id": userID, "posted_at": bson.M{"$lt": before}})
+}
+
+func (r *ledgerRepo) AccountBalance(ctx context.Context, userID bson.ObjectID, account string) (float64, error) {
+	return r.sum(ctx, bson.M{"user_id": userID, "account": account})
+}
+
+func (r *ledgerRepo) sum(ctx context.Context, match bson.M) (float64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "balance": bson.M{"$sum": bson.M{
+			"$cond": bson.A{bson.M{"$eq": bson.A{"$direction", "credit"}}, "$amount", bson.M{"$multiply": bson.A{"$amount", -1}}},
+		}}}}},
+	}
+	cursor, err := r.col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+	var res []struct {
+		Balance float64 `bson:"balance"`
+	}
+	if err := cursor.All(ctx, &res); err != nil || len(res) == 0 {
+		return 0, err
+	}
+	return res[0].Balance, nil
+}
+
+// FindRange returns the user's entries posted in [from, to), oldest first.
+func (r *ledgerRepo) FindRange(ctx context.Context, userID bson.ObjectID, from, to time.Time) ([]model.LedgerEntry, error) {
+	filter := bson.M{"user_id": userID, "posted_at": bson.M{"$gte": from, "$lt": to}}
+	opts := options.Find().SetSort(bson.D{{Key: "posted_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var entries []model.LedgerEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *ledgerRepo) FindByTxnID(ctx context.Context, txnID string) ([]model.LedgerEntry, error) {
+	cursor, err := r.col.Find(ctx, bson.M{"txn_id": txnID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var entries []model.LedgerEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *ledgerRepo) CountRange(ctx context.Context, userID bson.ObjectID, from, to time.Time) (int64, error) {
+	return r.col.CountDocuments(ctx, bson.M{"user_id": userID, "posted_at": bson.M{"$gte": from, "$lt": to}})
+}
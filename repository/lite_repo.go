@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/banking-superapp/upi-service/model"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type LiteWalletRepo interface {
+	Create(ctx context.Context, w *model.LiteWallet) error
+	FindByUserID(ctx context.Context, userID bson.ObjectID) (*model.LiteWallet, error)
+	FindAll(ctx context.Context) ([]model.LiteWallet, error)
+	// Credit adds amount unless the balance would exceed limit. Debit
+	// subtracts it unless the balance would go negative. Both return
+	// mongo.ErrNoDocuments when the wallet is missing or the bound is hit.
+	Credit(ctx context.Context, userID bson.ObjectID, amount, limit float64) (*model.LiteWallet, error)
+	Debit(ctx context.Context, userID bson.ObjectID, amount float64) (*model.LiteWallet, error)
+	SetAutoTopUp(ctx context.Context, userID bson.ObjectID, rule *model.LiteAutoTopUp) (*model.LiteWallet, error)
+}
+
+type liteWalletRepo struct{ col *mongo.Collection }
+
+func NewLiteWalletRepo(db *mongo.Database) LiteWalletRepo {
+	return &liteWalletRepo{col: db.Collection("lite_wallets")}
+}
+
+func (r *liteWalletRepo) Create(ctx context.Context, w *model.LiteWallet) error {
+	w.CreatedAt = time.Now()
+	w.UpdatedAt = w.CreatedAt
+	res, err := r.col.InsertOne(ctx, w)
+	if err != nil {
+		return err
+	}
+	w.ID, _ = res.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+func (r *liteWalletRepo) FindByUserID(ctx context.Context, userID bson.ObjectID) (*model.LiteWallet, error) {
+	var w model.LiteWallet
+	if err := r.col.FindOne(ctx, bson.M{"user_id": userID}).Decode(&w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (r *liteWalletRepo) FindAll(ctx context.Context) ([]model.LiteWallet, error) {
+	cursor, err := r.col.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var wallets []model.LiteWallet
+	if err := cursor.All(ctx, &wallets); err != nil {
+		return nil, err
+	}
+	return wallets, nil
+}
+
+func (r *liteWalletRepo) Credit(ctx context.Context, userID bson.ObjectID, amount, limit float64) (*model.LiteWallet, error) {
+	return r.inc(ctx, bson.M{"user_id": userID, "balance": bson.M{"$lte": limit - amount}}, amount)
+}
+
+func (r *liteWalletRepo) Debit(ctx context.Context, userID bson.ObjectID, amount float64) (*model.LiteWallet, error) {
+	return r.inc(ctx, bson.M{"user_id": userID, "balance": bson.M{"$gte": amount}}, -amount)
+}
+
+func (r *liteWalletRepo) inc(ctx context.Context, filter bson.M, amount float64) (*model.LiteWallet, error) {
+	var w model.LiteWallet
+	err := r.col.FindOneAndUpdate(ctx, filter,
+		bson.M{"$inc": bson.M{"balance": amount}, "$set": bson.M{"updated_at": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&w)
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// SetAutoTopUp replaces the rule; a nil rule turns auto top-up off.
+func (r *liteWalletRepo) SetAutoTopUp(ctx context.Context, userID bson.ObjectID, rule *model.LiteAutoTopUp) (*model.LiteWallet, error) {
+	update := bson.M{"$set": bson.M{"auto_top_up": rule, "updated_at": time.Now()}}
+	if rule == nil {
+		update = bson.M{"$unset": bson.M{"auto_top_up": ""}, "$set": bson.M{"updated_at": time.Now()}}
+	}
+	var w model.LiteWallet
+	err := r.col.FindOneAndUpdate(ctx, bson.M{"user_id": userID}, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&w)
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
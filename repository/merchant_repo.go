@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/banking-superapp/upi-service/model"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type MerchantRepo interface {
+	Create(ctx context.Context, m *model.Merchant) error
+	FindByID(ctx context.Context, id bson.ObjectID) (*model.Merchant, error)
+	FindByVPA(ctx context.Context, vpa string) (*model.Merchant, error)
+	FindByUserID(ctx context.Context, userID bson.ObjectID) ([]model.Merchant, error)
+	Verify(ctx context.Context, id bson.ObjectID, req *model.VerifyMerchantRequest) (*model.Merchant, error)
+	// ReserveDaily adds amount to what the merchant has taken on the IST
+	// date unless that would exceed limit, returning mongo.ErrNoDocuments if
+	// so. A zero limit always reserves. ReleaseDaily reverses a reservation.
+	ReserveDaily(ctx context.Context, merchantID bson.ObjectID, date string, amount, limit float64) error
+	ReleaseDaily(ctx context.Context, merchantID bson.ObjectID, date string, amount float64) error
+}
+
+type SettlementRepo interface {
+	Upsert(ctx context.Context, s *model.MerchantSettlement) error
+	FindByMerchant(ctx context.Context, merchantID bson.ObjectID, limit int64) ([]model.MerchantSettlement, error)
+}
+
+type merchantRepo struct{ col, volumes *mongo.Collection }
+type settlementRepo struct{ col *mongo.Collection }
+
+// dailyVolumeTTL keeps a day's volume past the end of the day it counts.
+const dailyVolumeTTL = 48 * time.Hour
+
+func NewMerchantRepo(db *mongo.Database) MerchantRepo {
+	return &merchantRepo{col: db.Collection("merchants"), volumes: db.Collection("merchant_daily_volumes")}
+}
+func NewSettlementRepo(db *mongo.Database) SettlementRepo {
+	return &settlementRepo{col: db.Collection("merchant_settlements")}
+}
+
+func (r *merchantRepo) Create(ctx context.Context, m *model.Merchant) error {
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = time.Now()
+	res, err := r.col.InsertOne(ctx, m)
+	if err != nil {
+		return err
+	}
+	m.ID, _ = res.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+func (r *merchantRepo) FindByID(ctx context.Context, id bson.ObjectID) (*model.Merchant, error) {
+	var m model.Merchant
+	if err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *merchantRepo) FindByVPA(ctx context.Context, vpa string) (*model.Merchant, error) {
+	var m model.Merchant
+	if err := r.col.FindOne(ctx, bson.M{"vpa": vpa, "is_active": true}).Decode(&m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *merchantRepo) FindByUserID(ctx context.Context, userID bson.ObjectID) ([]model.Merchant, error) {
+	cursor, err := r.col.Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var merchants []model.Merchant
+	cursor.All(ctx, &merchants)
+	return merchants, nil
+}
+
+func (r *merchantRepo) Verify(ctx context.Context, id bson.ObjectID, req *model.VerifyMerchantRequest) (*model.Merchant, error) {
+	update := bson.M{"$set": bson.M{
+		"verified":    true,
+		"txn_limit":   req.TxnLimit,
+		"daily_limit": req.DailyLimit,
+		"mdr_bps":     req.MDRBps,
+		"updated_at":  time.Now(),
+	}}
+	var m model.Merchant
+	err := r.col.FindOneAndUpdate(ctx, bson.M{"_id": id}, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&m)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *merchantRepo) ReserveDaily(ctx context.Context, merchantID bson.ObjectID, date string, amount, limit float64) error {
+	filter := bson.M{"_id": merchantID.Hex() + ":" + date}
+	if limit > 0 {
+		if amount > limit {
+			return mongo.ErrNoDocuments
+		}
+		filter["amount"] = bson.M{"$lte": limit - amount}
+	}
+	_, err := r.volumes.UpdateOne(ctx, filter, bson.M{
+		"$inc":         bson.M{"amount": amount},
+		"$setOnInsert": bson.M{"merchant_id": merchantID, "date": date, "expires_at": time.Now().Add(dailyVolumeTTL)},
+	}, options.UpdateOne().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		// The day's document exists but is too full to match.
+		return mongo.ErrNoDocuments
+	}
+	return err
+}
+
+func (r *merchantRepo) ReleaseDaily(ctx context.Context, merchantID bson.ObjectID, date string, amount float64) error {
+	_, err := r.volumes.UpdateOne(ctx, bson.M{"_id": merchantID.Hex() + ":" + date}, bson.M{"$inc": bson.M{"amount": -amount}})
+	return err
+}
+
+func (r *settlementRepo) Upsert(ctx context.Context, s *model.MerchantSettlement) error {
+	s.GeneratedAt = time.Now()
+	filter := bson.M{"merchant_id": s.MerchantID, "date": s.Date}
+	update := bson.M{"$set": bson.M{
+		"vpa":                s.VPA,
+		"settlement_account": s.SettlementAccount,
+		"txn_count":          s.TxnCount,
+		"gross_amount":       s.GrossAmount,
+		"mdr_fee":            s.MDRFee,
+		"net_amount":         s.NetAmount,
+		"generated_at":       s.GeneratedAt,
+	}}
+	var saved model.MerchantSettlement
+	err := r.col.FindOneAndUpdate(ctx, filter, update,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).Decode(&saved)
+	if err != nil {
+		return err
+	}
+	s.ID = saved.ID
+	return nil
+}
+
+func (r *settlementRepo) FindByMerchant(ctx context.Context, merchantID bson.ObjectID, limit int64) ([]model.MerchantSettlement, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: -1}}).SetLimit(limit)
+	cursor, err := r.col.Find(ctx, bson.M{"merchant_id": merchantID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var settlements []model.MerchantSettlement
+	cursor.All(ctx, &settlements)
+	return settlements, nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/banking-superapp/upi-service/metrics"
+	"go.mongodb.org/mongo-driver/v2/event"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const repoPkg = "github.com/banking-superapp/upi-service/repository."
+
+var tracer = otel.Tracer("github.com/banking-superapp/upi-service/repository")
+
+type command struct {
+	method string
+	span   trace.Span
+}
+
+// commandMonitor times and traces every Mongo command and attributes it to
+// the repository method that issued it. Spans carry the command name and
+// collection but never the command document.
+func commandMonitor() *event.CommandMonitor {
+	var inflight sync.Map // request ID -> command
+	finish := func(requestID int64, d time.Duration, failure error) {
+		v, ok := inflight.LoadAndDelete(requestID)
+		if !ok {
+			return
+		}
+		cmd := v.(command)
+		outcome := "ok"
+		if failure != nil {
+			outcome = "error"
+			cmd.span.RecordError(failure)
+			cmd.span.SetStatus(codes.Error, failure.Error())
+		}
+		cmd.span.End()
+		metrics.MongoOperationDuration.WithLabelValues(cmd.method, outcome).Observe(d.Seconds())
+	}
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, e *event.CommandStartedEvent) {
+			method := callingRepoMethod()
+			_, span := tracer.Start(ctx, "mongo "+method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+				attribute.String("db.system", "mongodb"),
+				attribute.String("db.operation.name", e.CommandName),
+				attribute.String("db.collection.name", commandCollection(e)),
+			))
+			inflight.Store(e.RequestID, command{method: method, span: span})
+		},
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			finish(e.RequestID, e.Duration, nil)
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			finish(e.RequestID, e.Duration, e.Failure)
+		},
+	}
+}
+
+// commandCollection reads the collection name, which is the value of the
+// command's first element for collection level commands.
+func commandCollection(e *event.CommandStartedEvent) string {
+	elem, err := e.Command.IndexErr(0)
+	if err != nil {
+		return ""
+	}
+	name, _ := elem.Value().StringValueOK()
+	return name
+}
+
+// callingRepoMethod returns the outermost repository function on the stack,
+// such as ledgerRepo.Post, so helpers shared between methods are reported
+// under the public method. Started events fire on the calling goroutine.
+func callingRepoMethod() string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	method := "other"
+	for {
+		frame, more := frames.Next()
+		if name, ok := strings.CutPrefix(frame.Function, repoPkg); ok {
+			name, _, _ = strings.Cut(name, ".func")
+			name = strings.NewReplacer("(*", "", ")", "").Replace(name)
+			if name != "commandMonitor" && name != "callingRepoMethod" {
+				method = name
+			}
+		}
+		if !more {
+			return method
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/banking-superapp/upi-service/model"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type MerchantOrderRepo interface {
+	Create(ctx context.Context, o *model.MerchantOrder) error
+	FindByTxnRef(ctx context.Context, txnRef string) (*model.MerchantOrder, error)
+	FindByOrderID(ctx context.Context, userID bson.ObjectID, orderID string) (*model.MerchantOrder, error)
+	// MarkPaid atomically moves an unexpired created order to paid. It returns
+	// mongo.ErrNoDocuments when the order is already paid, expired or unknown.
+	MarkPaid(ctx context.Context, txnRef, txnID string) (*model.MerchantOrder, error)
+	// Reopen reverts MarkPaid when the payment could not be recorded.
+	Reopen(ctx context.Context, txnRef, txnID string) error
+}
+
+type orderRepo struct{ col *mongo.Collection }
+
+func NewMerchantOrderRepo(db *mongo.Database) MerchantOrderRepo {
+	return &orderRepo{col: db.Collection("merchant_orders")}
+}
+
+func (r *orderRepo) Create(ctx context.Context, o *model.MerchantOrder) error {
+	o.CreatedAt = time.Now()
+	o.UpdatedAt = time.Now()
+	res, err := r.col.InsertOne(ctx, o)
+	if err != nil {
+		return err
+	}
+	o.ID, _ = res.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+func (r *orderRepo) FindByTxnRef(ctx context.Context, txnRef string) (*model.MerchantOrder, error) {
+	var o model.MerchantOrder
+	if err := r.col.FindOne(ctx, bson.M{"txn_ref": txnRef}).Decode(&o); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (r *orderRepo) FindByOrderID(ctx context.Context, userID bson.ObjectID, orderID string) (*model.MerchantOrder, error) {
+	var o model.MerchantOrder
+	if err := r.col.FindOne(ctx, bson.M{"user_id": userID, "order_id": orderID}).Decode(&o); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (r *orderRepo) MarkPaid(ctx context.Context, txnRef, txnID string) (*model.MerchantOrder, error) {
+	now := time.Now()
+	filter := bson.M{"txn_ref": txnRef, "status": "created", "expires_at": bson.M{"$gt": now}}
+	update := bson.M{"$set": bson.M{"status": "paid", "paid_txn_id": txnID, "paid_at": now, "updated_at": now}}
+	var o model.MerchantOrder
+	err := r.col.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&o)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+func (r *orderRepo) Reopen(ctx context.Context, txnRef, txnID string) error {
+	_, err := r.col.UpdateOne(ctx,
+		bson.M{"txn_ref": txnRef, "status": "paid", "paid_txn_id": txnID},
+		bson.M{
+			"$set":   bson.M{"status": "created", "updated_at": time.Now()},
+			"$unset": bson.M{"paid_txn_id": "", "paid_at": ""},
+		})
+	return err
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/banking-superapp/upi-service/model"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type PayoutRepo interface {
+	CreateBatch(ctx context.Context, b *model.PayoutBatch, rows []model.PayoutRow) error
+	FindBatch(ctx context.Context, userID, batchID bson.ObjectID) (*model.PayoutBatch, error)
+	FindBatchesByStatus(ctx context.Context, status string) ([]model.PayoutBatch, error)
+	RecordRowResult(ctx context.Context, batchID bson.ObjectID, row *model.PayoutRow) error
+	CompleteBatch(ctx context.Context, batchID bson.ObjectID) (*model.PayoutBatch, error)
+	FindRows(ctx context.Context, batchID bson.ObjectID, status string) ([]model.PayoutRow, error)
+	MarkRowProcessing(ctx context.Context, rowID bson.ObjectID, txnID string) (bool, error)
+	// ClaimBatch takes or renews the lease on a processing batch for owner.
+	// It reports false while another owner's lease is live or once the
+	// batch is no longer processing. ReleaseBatch gives the lease up.
+	ClaimBatch(ctx context.Context, batchID bson.ObjectID, owner string, until time.Time) (bool, error)
+	ReleaseBatch(ctx context.Context, batchID bson.ObjectID, owner string) error
+}
+
+type payoutRepo struct {
+	batches *mongo.Collection
+	rows    *mongo.Collection
+}
+
+func NewPayoutRepo(db *mongo.Database) PayoutRepo {
+	return &payoutRepo{batches: db.Collection("payout_batches"), rows: db.Collection("payout_rows")}
+}
+
+func (r *payoutRepo) CreateBatch(ctx context.Context, b *model.PayoutBatch, rows []model.PayoutRow) error {
+	b.CreatedAt = time.Now()
+	b.UpdatedAt = time.Now()
+	res, err := r.batches.InsertOne(ctx, b)
+	if err != nil {
+		return err
+	}
+	b.ID, _ = res.InsertedID.(bson.ObjectID)
+
+	docs := make([]interface{}, len(rows))
+	for i := range rows {
+		rows[i].BatchID = b.ID
+		rows[i].UpdatedAt = b.CreatedAt
+		docs[i] = rows[i]
+	}
+	if _, err = r.rows.InsertMany(ctx, docs); err != nil {
+		// Without all its rows the batch must not be picked up and paid, so
+		// it goes, along with any rows that made it in.
+		cleanup := context.WithoutCancel(ctx)
+		r.rows.DeleteMany(cleanup, bson.M{"batch_id": b.ID})
+		r.batches.DeleteOne(cleanup, bson.M{"_id": b.ID})
+		return err
+	}
+	return nil
+}
+
+func (r *payoutRepo) FindBatch(ctx context.Context, userID, batchID bson.ObjectID) (*model.PayoutBatch, error) {
+	var b model.PayoutBatch
+	if err := r.batches.FindOne(ctx, bson.M{"_id": batchID, "user_id": userID}).Decode(&b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *payoutRepo) FindBatchesByStatus(ctx context.Context, status string) ([]model.PayoutBatch, error) {
+	cursor, err := r.batches.Find(ctx, bson.M{"status": status})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var batches []model.PayoutBatch
+	cursor.All(ctx, &batches)
+	return batches, nil
+}
+
+func (r *payoutRepo) ClaimBatch(ctx context.Context, batchID bson.ObjectID, owner string, until time.Time) (bool, error) {
+	res, err := r.batches.UpdateOne(ctx, bson.M{
+		"_id":    batchID,
+		"status": "processing",
+		"$or": bson.A{
+			bson.M{"lease_owner": owner},
+			bson.M{"lease_until": bson.M{"$not": bson.M{"$gte": time.Now()}}},
+		},
+	}, bson.M{"$set": bson.M{"lease_owner": owner, "lease_until": until}})
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount == 1, nil
+}
+
+func (r *payoutRepo) ReleaseBatch(ctx context.Context, batchID bson.ObjectID, owner string) error {
+	_, err := r.batches.UpdateOne(ctx, bson.M{"_id": batchID, "lease_owner": owner},
+		bson.M{"$unset": bson.M{"lease_owner": "", "lease_until": ""}})
+	return err
+}
+
+// MarkRowProcessing claims a pending row, recording the ID its payment will
+// be made under. It reports false when the row was already claimed.
+func (r *payoutRepo) MarkRowProcessing(ctx context.Context, rowID bson.ObjectID, txnID string) (bool, error) {
+	res, err := r.rows.UpdateOne(ctx,
+		bson.M{"_id": rowID, "status": "pending"},
+		bson.M{"$set": bson.M{"status": "processing", "txn_id": txnID, "updated_at": time.Now()}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+// RecordRowResult stores the outcome of a processing row and rolls it into
+// the batch counters. A row that already has an outcome is left alone, so
+// each row is counted once.
+func (r *payoutRepo) RecordRowResult(ctx context.Context, batchID bson.ObjectID, row *model.PayoutRow) error {
+	row.UpdatedAt = time.Now()
+	res, err := r.rows.UpdateOne(ctx, bson.M{"_id": row.ID, "status": "processing"}, bson.M{"$set": bson.M{
+		"status":         row.Status,
+		"txn_id":         row.TxnID,
+		"failure_reason": row.FailureReason,
+		"updated_at":     row.UpdatedAt,
+	}})
+	if err != nil || res.ModifiedCount == 0 {
+		return err
+	}
+
+	inc := bson.M{"failed": 1}
+	if row.Status == "success" {
+		inc = bson.M{"succeeded": 1, "paid_amount": row.Amount}
+	}
+	_, err = r.batches.UpdateOne(ctx, bson.M{"_id": batchID},
+		bson.M{"$inc": inc, "$set": bson.M{"updated_at": row.UpdatedAt}})
+	return err
+}
+
+// CompleteBatch closes a batch once every row has an outcome. It returns
+// mongo.ErrNoDocuments while rows are still pending or processing, which
+// leaves the batch to whoever records the last of them.
+func (r *payoutRepo) CompleteBatch(ctx context.Context, batchID bson.ObjectID) (*model.PayoutBatch, error) {
+	now := time.Now()
+	filter := bson.M{
+		"_id":    batchID,
+		"status": "processing",
+		"$expr":  bson.M{"$eq": bson.A{bson.M{"$add": bson.A{"$succeeded", "$failed"}}, "$total_rows"}},
+	}
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"status": bson.M{"$cond": bson.A{
+			bson.M{"$gt": bson.A{"$failed", 0}}, "completed_with_errors", "completed",
+		}},
+		"completed_at": now,
+		"updated_at":   now,
+	}}}}
+	var b model.PayoutBatch
+	err := r.batches.FindOneAndUpdate(ctx, filter, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&b)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *payoutRepo) FindRows(ctx context.Context, batchID bson.ObjectID, status string) ([]model.PayoutRow, error) {
+	filter := bson.M{"batch_id": batchID}
+	if status != "" {
+		filter["status"] = status
+	}
+	cursor, err := r.rows.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "row", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var rows []model.PayoutRow
+	cursor.All(ctx, &rows)
+	return rows, nil
+}
@@ -0,0 +1,368 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/banking-superapp/upi-service/model"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// PrivacyRepo applies retention policies and erasures across collections,
+// and keeps erasure requests and retention runs.
+type PrivacyRepo interface {
+	// ErasureBlocker returns why userID cannot be erased yet, one of
+	// pending_transactions, lite_balance or outstanding_credit, or "".
+	ErasureBlocker(ctx context.Context, userID bson.ObjectID) (string, error)
+	// Erase removes userID's personal data and moves what must be kept to
+	// pseudonym. Every step is idempotent, so a failed erasure can be run
+	// again with the same pseudonym. The report covers the steps done.
+	Erase(ctx context.Context, userID, pseudonym bson.ObjectID) ([]model.PrivacyAction, error)
+	// ApplyRetention clears or purges personal data older than its period,
+	// or with dryRun counts what it would change.
+	ApplyRetention(ctx context.Context, p model.RetentionPeriods, now time.Time, dryRun bool) ([]model.PrivacyAction, error)
+
+	CreateErasure(ctx context.Context, e *model.ErasureRequest) error
+	FindErasure(ctx context.Context, userID, id bson.ObjectID) (*model.ErasureRequest, error)
+	// FindOpenErasure returns the user's queued or processing request.
+	FindOpenErasure(ctx context.Context, userID bson.ObjectID) (*model.ErasureRequest, error)
+	// ClaimErasure moves the oldest queued request, or one left processing
+	// since before staleBefore, to processing. It returns
+	// mongo.ErrNoDocuments when there is nothing to do.
+	ClaimErasure(ctx context.Context, staleBefore time.Time) (*model.ErasureRequest, error)
+	CompleteErasure(ctx context.Context, id bson.ObjectID, report []model.PrivacyAction, retained []string) error
+	FailErasure(ctx context.Context, id bson.ObjectID, reason string) error
+
+	RecordRetentionRun(ctx context.Context, run *model.RetentionRun) error
+	FindRetentionRuns(ctx context.Context, limit int64) ([]model.RetentionRun, error)
+}
+
+type privacyRepo struct {
+	db       *mongo.Database
+	erasures *mongo.Collection
+	runs     *mongo.Collection
+}
+
+func NewPrivacyRepo(db *mongo.Database) PrivacyRepo {
+	return &privacyRepo{
+		db:       db,
+		erasures: db.Collection("erasure_requests"),
+		runs:     db.Collection("retention_runs"),
+	}
+}
+
+// privacyStep is one change to a collection: fields cleared, documents
+// purged, or, for update steps, action applied with update.
+type privacyStep struct {
+	policy     string
+	collection string
+	filter     bson.M
+	clear      []string
+	purge      bool
+	update     bson.M
+	action     string
+}
+
+// erasureBlockers are the records that keep a user from being erased until
+// the money they stand for is settled.
+var erasureBlockers = []struct {
+	reason, collection string
+	filter             bson.M
+}{
+	{"pending_transactions", "upi_transactions", bson.M{"status": "pending"}},
+	{"lite_balance", "lite_wallets", bson.M{"balance": bson.M{"$gt": 0}}},
+	{"outstanding_credit", "funding_sources", bson.M{"outstanding": bson.M{"$gt": 0}}}, // unlinked sources can still owe
+}
+
+func (r *privacyRepo) ErasureBlocker(ctx context.Context, userID bson.ObjectID) (string, error) {
+	for _, b := range erasureBlockers {
+		filter := bson.M{"user_id": userID}
+		for k, v := range b.filter {
+			filter[k] = v
+		}
+		n, err := r.db.Collection(b.collection).CountDocuments(ctx, filter, options.Count().SetLimit(1))
+		if err != nil {
+			return "", err
+		}
+		if n > 0 {
+			return b.reason, nil
+		}
+	}
+	return "", nil
+}
+
+// pseudonymizedCollections keep their documents after an erasure, under the
+// pseudonym instead of the user ID.
+var pseudonymizedCollections = []string{
+	"upi_transactions", "ledger_entries", "collect_requests", "mandates",
+	"scheduled_payments", "scheduled_payment_runs", "splits", "merchant_orders",
+	"payout_batches", "merchants", "funding_sources", "lite_wallets", "vpas",
+}
+
+// erasureSteps stop whatever could still act for the user, delete what has
+// no regulatory purpose, strip free text from the rest and finally
+// pseudonymize it. Transaction IDs, RRNs, amounts, VPAs, statuses and dates
+// are kept throughout.
+func erasureSteps(userID, pseudonym bson.ObjectID, now time.Time) []privacyStep {
+	mine := func(extra bson.M) bson.M {
+		filter := bson.M{"user_id": userID}
+		for k, v := range extra {
+			filter[k] = v
+		}
+		return filter
+	}
+	closeTo := func(status string) bson.M {
+		return bson.M{"$set": bson.M{"status": status, "updated_at": now}}
+	}
+	activeOrPaused := bson.M{"status": bson.M{"$in": bson.A{"active", "paused"}}}
+	steps := []privacyStep{
+		{collection: "mandates", filter: mine(activeOrPaused), update: closeTo("revoked"), action: "closed"},
+		{collection: "scheduled_payments", filter: mine(activeOrPaused), update: closeTo("cancelled"), action: "closed"},
+		{collection: "splits", filter: mine(bson.M{"status": "open"}), update: closeTo("closed"), action: "closed"},
+		{collection: "collect_requests", filter: mine(bson.M{"status": "pending"}), update: bson.M{"$set": bson.M{"status": "expired"}}, action: "closed"},
+		{collection: "funding_sources", filter: mine(bson.M{"status": "active"}), update: closeTo("unlinked"), action: "closed"},
+		{collection: "vpas", filter: mine(bson.M{"is_active": true}), update: bson.M{"$set": bson.M{"is_active": false, "updated_at": now}}, action: "closed"},
+		{collection: "lite_wallets", filter: mine(bson.M{"auto_top_up": bson.M{"$exists": true}}), update: bson.M{"$unset": bson.M{"auto_top_up": ""}, "$set": bson.M{"updated_at": now}}, action: "closed"},
+
+		{collection: "beneficiaries", filter: mine(nil), purge: true},
+		{collection: "category_overrides", filter: mine(nil), purge: true},
+		{collection: "budgets", filter: mine(nil), purge: true},
+		{collection: "statement_jobs", filter: mine(nil), purge: true},
+		{collection: "upi_numbers", filter: mine(nil), purge: true},
+
+		{collection: "upi_transactions", filter: mine(nil), clear: []string{"note"}},
+		{collection: "ledger_entries", filter: mine(nil), clear: []string{"note", "counterparty"}},
+		{collection: "collect_requests", filter: mine(nil), clear: []string{"note"}},
+		{collection: "mandates", filter: mine(nil), clear: []string{"purpose"}},
+		{collection: "scheduled_payments", filter: mine(nil), clear: []string{"note"}},
+		{collection: "splits", filter: mine(nil), clear: []string{"note"}},
+		{collection: "merchant_orders", filter: mine(nil), clear: []string{"note"}},
+	}
+	for _, c := range pseudonymizedCollections {
+		steps = append(steps, privacyStep{
+			collection: c,
+			filter:     mine(nil),
+			update:     bson.M{"$set": bson.M{"user_id": pseudonym}},
+			action:     "pseudonymized",
+		})
+	}
+	return steps
+}
+
+func (r *privacyRepo) Erase(ctx context.Context, userID, pseudonym bson.ObjectID) ([]model.PrivacyAction, error) {
+	return r.apply(ctx, erasureSteps(userID, pseudonym, time.Now()), false)
+}
+
+// retentionSteps are the policies with a period set. Records still in use,
+// such as pending collects or active mandates, are left until they close.
+func retentionSteps(p model.RetentionPeriods, now time.Time) []privacyStep {
+	var steps []privacyStep
+	if p.Notes > 0 {
+		before := bson.M{"$lt": now.Add(-p.Notes)}
+		steps = append(steps, withPolicy("notes",
+			privacyStep{collection: "upi_transactions", filter: bson.M{"transaction_date": before, "status": bson.M{"$ne": "pending"}}, clear: []string{"note"}},
+			privacyStep{collection: "ledger_entries", filter: bson.M{"posted_at": before}, clear: []string{"note"}},
+			privacyStep{collection: "collect_requests", filter: bson.M{"created_at": before, "status": bson.M{"$nin": bson.A{"pending", "processing"}}}, clear: []string{"note"}},
+			privacyStep{collection: "merchant_orders", filter: bson.M{"expires_at": before}, clear: []string{"note"}},
+			privacyStep{collection: "payout_rows", filter: bson.M{"updated_at": before, "status": bson.M{"$in": bson.A{"success", "failed"}}}, clear: []string{"note"}},
+			privacyStep{collection: "splits", filter: bson.M{"updated_at": before, "status": bson.M{"$ne": "open"}}, clear: []string{"note"}},
+			privacyStep{collection: "scheduled_payments", filter: bson.M{"updated_at": before, "status": bson.M{"$in": bson.A{"completed", "cancelled"}}}, clear: []string{"note"}},
+			privacyStep{collection: "mandates", filter: bson.M{"updated_at": before, "status": bson.M{"$in": bson.A{"revoked", "expired"}}}, clear: []string{"purpose"}},
+		)...)
+	}
+	if p.Names > 0 {
+		before := bson.M{"$lt": now.Add(-p.Names)}
+		steps = append(steps, withPolicy("names",
+			// Beneficiaries neither paid nor edited within the period.
+			privacyStep{collection: "beneficiaries", filter: bson.M{"updated_at": before, "$or": bson.A{
+				bson.M{"last_paid_at": bson.M{"$exists": false}},
+				bson.M{"last_paid_at": before},
+			}}, purge: true},
+			privacyStep{collection: "ledger_entries", filter: bson.M{"posted_at": before}, clear: []string{"counterparty"}},
+			privacyStep{collection: "upi_numbers", filter: bson.M{"status": "deregistered", "updated_at": before}, purge: true},
+		)...)
+	}
+	if p.DeviceData > 0 {
+		// Only events whose hash covers an IP digest rather than the IP.
+		steps = append(steps, withPolicy("device_data",
+			privacyStep{collection: "audit_trail", filter: bson.M{
+				"at":              bson.M{"$lt": now.Add(-p.DeviceData)},
+				"actor.ip_digest": bson.M{"$exists": true},
+			}, clear: []string{"actor.ip"}},
+		)...)
+	}
+	return steps
+}
+
+func withPolicy(policy string, steps ...privacyStep) []privacyStep {
+	for i := range steps {
+		steps[i].policy = policy
+	}
+	return steps
+}
+
+func (r *privacyRepo) ApplyRetention(ctx context.Context, p model.RetentionPeriods, now time.Time, dryRun bool) ([]model.PrivacyAction, error) {
+	return r.apply(ctx, retentionSteps(p, now), dryRun)
+}
+
+func (r *privacyRepo) apply(ctx context.Context, steps []privacyStep, dryRun bool) ([]model.PrivacyAction, error) {
+	report := make([]model.PrivacyAction, 0, len(steps))
+	for _, st := range steps {
+		col := r.db.Collection(st.collection)
+		a := model.PrivacyAction{Policy: st.policy, Collection: st.collection, Fields: st.clear, Action: st.action}
+		var err error
+		switch {
+		case st.purge:
+			a.Action = "purged"
+			if dryRun {
+				a.Count, err = col.CountDocuments(ctx, st.filter)
+				break
+			}
+			var res *mongo.DeleteResult
+			if res, err = col.DeleteMany(ctx, st.filter); err == nil {
+				a.Count = res.DeletedCount
+			}
+		case len(st.clear) > 0:
+			a.Action = "cleared"
+			filter := clearable(st.filter, st.clear)
+			if dryRun {
+				a.Count, err = col.CountDocuments(ctx, filter)
+				break
+			}
+			set := bson.M{}
+			for _, f := range st.clear {
+				set[f] = ""
+			}
+			var res *mongo.UpdateResult
+			if res, err = col.UpdateMany(ctx, filter, bson.M{"$set": set}); err == nil {
+				a.Count = res.ModifiedCount
+			}
+		default:
+			if dryRun {
+				a.Count, err = col.CountDocuments(ctx, st.filter)
+				break
+			}
+			var res *mongo.UpdateResult
+			if res, err = col.UpdateMany(ctx, st.filter, st.update); err == nil {
+				a.Count = res.ModifiedCount
+			}
+		}
+		if err != nil {
+			return report, fmt.Errorf("%s %s: %w", a.Action, st.collection, err)
+		}
+		report = append(report, a)
+	}
+	return report, nil
+}
+
+// clearable narrows filter to documents with at least one of fields set.
+func clearable(filter bson.M, fields []string) bson.M {
+	held := make(bson.A, 0, len(fields))
+	for _, f := range fields {
+		held = append(held, bson.M{f: bson.M{"$nin": bson.A{"", nil}}})
+	}
+	return bson.M{"$and": bson.A{filter, bson.M{"$or": held}}}
+}
+
+func (r *privacyRepo) CreateErasure(ctx context.Context, e *model.ErasureRequest) error {
+	e.CreatedAt = time.Now()
+	e.UpdatedAt = e.CreatedAt
+	res, err := r.erasures.InsertOne(ctx, e)
+	if err != nil {
+		return err
+	}
+	e.ID, _ = res.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+func (r *privacyRepo) FindErasure(ctx context.Context, userID, id bson.ObjectID) (*model.ErasureRequest, error) {
+	var e model.ErasureRequest
+	if err := r.erasures.FindOne(ctx, bson.M{"_id": id, "user_id": userID}).Decode(&e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *privacyRepo) FindOpenErasure(ctx context.Context, userID bson.ObjectID) (*model.ErasureRequest, error) {
+	var e model.ErasureRequest
+	filter := bson.M{"user_id": userID, "status": bson.M{"$in": bson.A{"queued", "processing"}}}
+	if err := r.erasures.FindOne(ctx, filter).Decode(&e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *privacyRepo) ClaimErasure(ctx context.Context, staleBefore time.Time) (*model.ErasureRequest, error) {
+	filter := bson.M{"$or": []bson.M{
+		{"status": "queued"},
+		{"status": "processing", "updated_at": bson.M{"$lt": staleBefore}},
+	}}
+	var e model.ErasureRequest
+	err := r.erasures.FindOneAndUpdate(ctx, filter,
+		bson.M{"$set": bson.M{"status": "processing", "updated_at": time.Now()}},
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "created_at", Value: 1}}).
+			SetReturnDocument(options.After)).Decode(&e)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *privacyRepo) CompleteErasure(ctx context.Context, id bson.ObjectID, report []model.PrivacyAction, retained []string) error {
+	now := time.Now()
+	return r.finishErasure(ctx, id, bson.M{
+		"status":       "completed",
+		"report":       report,
+		"retained":     retained,
+		"completed_at": now,
+		"updated_at":   now,
+	})
+}
+
+func (r *privacyRepo) FailErasure(ctx context.Context, id bson.ObjectID, reason string) error {
+	now := time.Now()
+	return r.finishErasure(ctx, id, bson.M{
+		"status":         "failed",
+		"failure_reason": reason,
+		"completed_at":   now,
+		"updated_at":     now,
+	})
+}
+
+func (r *privacyRepo) finishErasure(ctx context.Context, id bson.ObjectID, set bson.M) error {
+	res, err := r.erasures.UpdateOne(ctx, bson.M{"_id": id, "status": "processing"}, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *privacyRepo) RecordRetentionRun(ctx context.Context, run *model.RetentionRun) error {
+	res, err := r.runs.InsertOne(ctx, run)
+	if err != nil {
+		return err
+	}
+	run.ID, _ = res.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+func (r *privacyRepo) FindRetentionRuns(ctx context.Context, limit int64) ([]model.RetentionRun, error) {
+	cursor, err := r.runs.Find(ctx, bson.M{},
+		options.Find().SetSort(bson.D{{Key: "started_at", Value: -1}}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var runs []model.RetentionRun
+	if err := cursor.All(ctx, &runs); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
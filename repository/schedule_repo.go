@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/banking-superapp/upi-service/model"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type ScheduleRepo interface {
+	Create(ctx context.Context, s *model.ScheduledPayment) error
+	FindByID(ctx context.Context, userID, id bson.ObjectID) (*model.ScheduledPayment, error)
+	FindByUserID(ctx context.Context, userID bson.ObjectID) ([]model.ScheduledPayment, error)
+	FindDue(ctx context.Context, now time.Time, limit int64) ([]model.ScheduledPayment, error)
+	// Advance claims the occurrence at runAt by moving the schedule to its next
+	// occurrence (nil when none remain). It reports false when another worker
+	// claimed the occurrence first or skip_next no longer matches skipNext.
+	Advance(ctx context.Context, id bson.ObjectID, runAt time.Time, skipNext bool, next *time.Time, status string) (bool, error)
+	Update(ctx context.Context, userID, id bson.ObjectID, fromStatus []string, set bson.M) (*model.ScheduledPayment, error)
+	CreateRun(ctx context.Context, run *model.ScheduledPaymentRun) error
+	FindRuns(ctx context.Context, scheduleID bson.ObjectID, limit int64) ([]model.ScheduledPaymentRun, error)
+}
+
+type scheduleRepo struct {
+	col  *mongo.Collection
+	runs *mongo.Collection
+}
+
+func NewScheduleRepo(db *mongo.Database) ScheduleRepo {
+	return &scheduleRepo{col: db.Collection("scheduled_payments"), runs: db.Collection("scheduled_payment_runs")}
+}
+
+func (r *scheduleRepo) Create(ctx context.Context, s *model.ScheduledPayment) error {
+	s.CreatedAt = time.Now()
+	s.UpdatedAt = time.Now()
+	if s.ID.IsZero() {
+		s.ID = bson.NewObjectID()
+	}
+	_, err := r.col.InsertOne(ctx, s)
+	return err
+}
+
+func (r *scheduleRepo) FindByID(ctx context.Context, userID, id bson.ObjectID) (*model.ScheduledPayment, error) {
+	var s model.ScheduledPayment
+	if err := r.col.FindOne(ctx, bson.M{"_id": id, "user_id": userID}).Decode(&s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *scheduleRepo) FindByUserID(ctx context.Context, userID bson.ObjectID) ([]model.ScheduledPayment, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.col.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var schedules []model.ScheduledPayment
+	cursor.All(ctx, &schedules)
+	return schedules, nil
+}
+
+func (r *scheduleRepo) FindDue(ctx context.Context, now time.Time, limit int64) ([]model.ScheduledPayment, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "next_run_at", Value: 1}}).SetLimit(limit)
+	cursor, err := r.col.Find(ctx, bson.M{"status": "active", "next_run_at": bson.M{"$lte": now}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var schedules []model.ScheduledPayment
+	cursor.All(ctx, &schedules)
+	return schedules, nil
+}
+
+func (r *scheduleRepo) Advance(ctx context.Context, id bson.ObjectID, runAt time.Time, skipNext bool, next *time.Time, status string) (bool, error) {
+	now := time.Now()
+	set := bson.M{"status": status, "skip_next": false, "last_run_at": now, "updated_at": now}
+	update := bson.M{"$set": set, "$inc": bson.M{"run_count": 1}}
+	if next != nil {
+		set["next_run_at"] = *next
+	} else {
+		update["$unset"] = bson.M{"next_run_at": ""}
+	}
+	res, err := r.col.UpdateOne(ctx, bson.M{"_id": id, "status": "active", "next_run_at": runAt, "skip_next": skipNext}, update)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+func (r *scheduleRepo) Update(ctx context.Context, userID, id bson.ObjectID, fromStatus []string, set bson.M) (*model.ScheduledPayment, error) {
+	set["updated_at"] = time.Now()
+	filter := bson.M{"_id": id, "user_id": userID, "status": bson.M{"$in": fromStatus}}
+	var s model.ScheduledPayment
+	err := r.col.FindOneAndUpdate(ctx, filter, bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&s)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *scheduleRepo) CreateRun(ctx context.Context, run *model.ScheduledPaymentRun) error {
+	run.ExecutedAt = time.Now()
+	_, err := r.runs.InsertOne(ctx, run)
+	return err
+}
+
+func (r *scheduleRepo) FindRuns(ctx context.Context, scheduleID bson.ObjectID, limit int64) ([]model.ScheduledPaymentRun, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "scheduled_for", Value: -1}}).SetLimit(limit)
+	cursor, err := r.runs.Find(ctx, bson.M{"schedule_id": scheduleID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var runs []model.ScheduledPaymentRun
+	cursor.All(ctx, &runs)
+	return runs, nil
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/banking-superapp/upi-service/model"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type SplitRepo interface {
+	Create(ctx context.Context, s *model.Split) error
+	FindByID(ctx context.Context, id bson.ObjectID) (*model.Split, error)
+	FindByUserID(ctx context.Context, userID bson.ObjectID) ([]model.Split, error)
+	FindOpen(ctx context.Context) ([]model.Split, error)
+	// UpdateParticipant moves a pending participant to status and returns the
+	// updated split. It returns mongo.ErrNoDocuments when the participant is
+	// not pending.
+	UpdateParticipant(ctx context.Context, splitID, collectID bson.ObjectID, status, txnID string) (*model.Split, error)
+	MarkReminded(ctx context.Context, splitID, collectID bson.ObjectID) error
+	SetStatus(ctx context.Context, splitID bson.ObjectID, status string) error
+}
+
+type splitRepo struct{ col *mongo.Collection }
+
+func NewSplitRepo(db *mongo.Database) SplitRepo {
+	return &splitRepo{col: db.Collection("splits")}
+}
+
+func (r *splitRepo) Create(ctx context.Context, s *model.Split) error {
+	s.CreatedAt = time.Now()
+	s.UpdatedAt = time.Now()
+	if s.ID.IsZero() {
+		s.ID = bson.NewObjectID()
+	}
+	_, err := r.col.InsertOne(ctx, s)
+	return err
+}
+
+func (r *splitRepo) FindByID(ctx context.Context, id bson.ObjectID) (*model.Split, error) {
+	var s model.Split
+	if err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *splitRepo) FindByUserID(ctx context.Context, userID bson.ObjectID) ([]model.Split, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.col.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var splits []model.Split
+	cursor.All(ctx, &splits)
+	return splits, nil
+}
+
+func (r *splitRepo) FindOpen(ctx context.Context) ([]model.Split, error) {
+	cursor, err := r.col.Find(ctx, bson.M{"status": "open"})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var splits []model.Split
+	cursor.All(ctx, &splits)
+	return splits, nil
+}
+
+func (r *splitRepo) UpdateParticipant(ctx context.Context, splitID, collectID bson.ObjectID, status, txnID string) (*model.Split, error) {
+	filter := bson.M{
+		"_id":          splitID,
+		"participants": bson.M{"$elemMatch": bson.M{"collect_id": collectID, "status": "pending"}},
+	}
+	set := bson.M{"participants.$.status": status, "updated_at": time.Now()}
+	if txnID != "" {
+		set["participants.$.txn_id"] = txnID
+	}
+	var s model.Split
+	err := r.col.FindOneAndUpdate(ctx, filter, bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&s)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *splitRepo) MarkReminded(ctx context.Context, splitID, collectID bson.ObjectID) error {
+	_, err := r.col.UpdateOne(ctx,
+		bson.M{"_id": splitID, "participants.collect_id": collectID},
+		bson.M{
+			"$inc": bson.M{"participants.$.reminders_sent": 1},
+			"$set": bson.M{"participants.$.last_reminded_at": time.Now()},
+		})
+	return err
+}
+
+func (r *splitRepo) SetStatus(ctx context.Context, splitID bson.ObjectID, status string) error {
+	_, err := r.col.UpdateOne(ctx, bson.M{"_id": splitID, "status": "open"},
+		bson.M{"$set": bson.M{"status": status, "updated_at": time.Now()}})
+	return err
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/banking-superapp/upi-service/model"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+type StatementRepo interface {
+	Create(ctx context.Context, j *model.StatementJob) error
+	FindByID(ctx context.Context, userID, jobID bson.ObjectID) (*model.StatementJob, error)
+	// Claim moves the oldest queued job, or one left processing since before
+	// staleBefore, to processing. It returns mongo.ErrNoDocuments when there
+	// is nothing to do.
+	Claim(ctx context.Context, staleBefore time.Time) (*model.StatementJob, error)
+	Complete(ctx context.Context, jobID bson.ObjectID, content []byte) (*model.StatementJob, error)
+	Fail(ctx context.Context, jobID bson.ObjectID, reason string) (*model.StatementJob, error)
+}
+
+type statementRepo struct{ col *mongo.Collection }
+
+func NewStatementRepo(db *mongo.Database) StatementRepo {
+	return &statementRepo{col: db.Collection("statement_jobs")}
+}
+
+func (r *statementRepo) Create(ctx context.Context, j *model.StatementJob) error {
+	j.CreatedAt = time.Now()
+	j.UpdatedAt = j.CreatedAt
+	res, err := r.col.InsertOne(ctx, j)
+	if err != nil {
+		return err
+	}
+	j.ID, _ = res.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+func (r *statementRepo) FindByID(ctx context.Context, userID, jobID bson.ObjectID) (*model.StatementJob, error) {
+	var j model.StatementJob
+	if err := r.col.FindOne(ctx, bson.M{"_id": jobID, "user_id": userID}).Decode(&j); err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+func (r *statementRepo) Claim(ctx context.Context, staleBefore time.Time) (*model.StatementJob, error) {
+	filter := bson.M{"$or": []bson.M{
+		{"status": "queued"},
+		{"status": "processing", "updated_at": bson.M{"$lt": staleBefore}},
+	}}
+	var j model.StatementJob
+	err := r.col.FindOneAndUpdate(ctx, filter,
+		bson.M{"$set": bson.M{"status": "processing", "updated_at": time.Now()}},
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "created_at", Value: 1}}).
+			SetReturnDocument(options.After)).Decode(&j)
+	if err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+func (r *statementRepo) Complete(ctx context.Context, jobID bson.ObjectID, content []byte) (*model.StatementJob, error) {
+	now := time.Now()
+	return r.finish(ctx, jobID, bson.M{
+		"status":       "ready",
+		"content":      content,
+		"size":         int64(len(content)),
+		"completed_at": now,
+		"updated_at":   now,
+	})
+}
+
+func (r *statementRepo) Fail(ctx context.Context, jobID bson.ObjectID, reason string) (*model.StatementJob, error) {
+	now := time.Now()
+	return r.finish(ctx, jobID, bson.M{
+		"status":         "failed",
+		"failure_reason": reason,
+		"completed_at":   now,
+		"updated_at":     now,
+	})
+}
+
+func (r *statementRepo) finish(ctx context.Context, jobID bson.ObjectID, set bson.M) (*model.StatementJob, error) {
+	var j model.StatementJob
+	err := r.col.FindOneAndUpdate(ctx, bson.M{"_id": jobID, "status": "processing"},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&j)
+	if err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
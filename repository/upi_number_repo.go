@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/banking-superapp/upi-service/model"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// UPINumberRepo stores mapper entries. At most one active entry may exist per
+// number and per VPA and kind; deregistered entries are kept for history.
+type UPINumberRepo interface {
+	Create(ctx context.Context, n *model.UPINumber) error
+	FindActive(ctx context.Context, number string) (*model.UPINumber, error)
+	FindByUserID(ctx context.Context, userID bson.ObjectID) ([]model.UPINumber, error)
+	// Relink points the user's active number at another VPA.
+	Relink(ctx context.Context, userID bson.ObjectID, number, vpa string) (*model.UPINumber, error)
+	Deregister(ctx context.Context, userID bson.ObjectID, number string) (*model.UPINumber, error)
+}
+
+type upiNumberRepo struct{ col *mongo.Collection }
+
+func NewUPINumberRepo(db *mongo.Database) UPINumberRepo {
+	return &upiNumberRepo{col: db.Collection("upi_numbers")}
+}
+
+func (r *upiNumberRepo) Create(ctx context.Context, n *model.UPINumber) error {
+	n.CreatedAt = time.Now()
+	n.UpdatedAt = n.CreatedAt
+	res, err := r.col.InsertOne(ctx, n)
+	if err != nil {
+		return err
+	}
+	n.ID, _ = res.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+func (r *upiNumberRepo) FindActive(ctx context.Context, number string) (*model.UPINumber, error) {
+	var n model.UPINumber
+	if err := r.col.FindOne(ctx, bson.M{"number": number, "status": "active"}).Decode(&n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+func (r *upiNumberRepo) FindByUserID(ctx context.Context, userID bson.ObjectID) ([]model.UPINumber, error) {
+	cursor, err := r.col.Find(ctx, bson.M{"user_id": userID, "status": "active"},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var numbers []model.UPINumber
+	cursor.All(ctx, &numbers)
+	return numbers, nil
+}
+
+func (r *upiNumberRepo) Relink(ctx context.Context, userID bson.ObjectID, number, vpa string) (*model.UPINumber, error) {
+	return r.update(ctx, userID, number, bson.M{"vpa": vpa})
+}
+
+func (r *upiNumberRepo) Deregister(ctx context.Context, userID bson.ObjectID, number string) (*model.UPINumber, error) {
+	return r.update(ctx, userID, number, bson.M{"status": "deregistered"})
+}
+
+func (r *upiNumberRepo) update(ctx context.Context, userID bson.ObjectID, number string, set bson.M) (*model.UPINumber, error) {
+	set["updated_at"] = time.Now()
+	var n model.UPINumber
+	err := r.col.FindOneAndUpdate(ctx,
+		bson.M{"user_id": userID, "number": number, "status": "active"},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&n)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
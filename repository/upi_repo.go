@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/secure"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
@@ -14,20 +15,64 @@ type VPARepo interface {
 	Create(ctx context.Context, v *model.VPA) error
 	FindByAddress(ctx context.Context, address string) (*model.VPA, error)
 	FindByUserID(ctx context.Context, userID bson.ObjectID) ([]model.VPA, error)
+	Deactivate(ctx context.Context, address string) error
+	FindByAccountID(ctx context.Context, accountID string) ([]model.VPA, error)
 }
 
 type UPITransactionRepo interface {
 	Create(ctx context.Context, t *model.UPITransaction) error
-	FindByUserID(ctx context.Context, userID bson.ObjectID, page, limit int64) ([]model.UPITransaction, int64, error)
+	FindByTxnID(ctx context.Context, txnID string) (*model.UPITransaction, error)
+	FindByRRN(ctx context.Context, rrn string) (*model.UPITransaction, error)
+	// Resolve moves a pending transaction to status and returns it, or
+	// mongo.ErrNoDocuments when it is not pending.
+	Resolve(ctx context.Context, txnID, status, reason string) (*model.UPITransaction, error)
+	// FindByUserID returns transactions the user sent, plus those received on
+	// any of vpas, narrowed by f, newest first. A non-nil after resumes the
+	// listing past that position; otherwise skip rows are skipped.
+	FindByUserID(ctx context.Context, userID bson.ObjectID, vpas []string, f *model.TxnFilter, after *model.TxnCursor, skip, limit int64) ([]model.UPITransaction, error)
+	CountByUserID(ctx context.Context, userID bson.ObjectID, vpas []string, f *model.TxnFilter) (int64, error)
+	MerchantTotals(ctx context.Context, merchantID bson.ObjectID, from, to time.Time) (*model.MerchantSettlement, error)
+	// SetCategory recategorizes every payment from userID to toVPA.
+	SetCategory(ctx context.Context, userID bson.ObjectID, toVPA, category string) (int64, error)
+	// The insight aggregations below cover successful payments in [from, to)
+	// and leave out transfers between the user's own VPAs.
+	SpendByCategory(ctx context.Context, userID bson.ObjectID, vpas []string, from, to time.Time) ([]model.CategorySpend, error)
+	TopPayees(ctx context.Context, userID bson.ObjectID, vpas []string, from, to time.Time, limit int64) ([]model.PayeeSpend, error)
+	// RecentPayees returns the VPAs the user paid most recently.
+	RecentPayees(ctx context.Context, userID bson.ObjectID, vpas []string, limit int64) ([]model.PayeeSuggestion, error)
+	// CashFlow buckets inflow and outflow by period, a $dateToString format
+	// evaluated in IST.
+	CashFlow(ctx context.Context, userID bson.ObjectID, vpas []string, from, to time.Time, period string) ([]model.CashFlowPoint, error)
+	// FindUnposted returns pending and successful transactions with no ledger
+	// debit, in _id order after after.
+	FindUnposted(ctx context.Context, after bson.ObjectID, limit int64) ([]model.UPITransaction, error)
+	CountUnposted(ctx context.Context) (int64, error)
 }
 
 type MandateRepo interface {
 	Create(ctx context.Context, m *model.Mandate) error
 	FindByUserID(ctx context.Context, userID bson.ObjectID) ([]model.Mandate, error)
+	FindByMandateID(ctx context.Context, mandateID string) (*model.Mandate, error)
+	// Transition moves a mandate in one of the from statuses to status and
+	// returns mongo.ErrNoDocuments when it is in none of them.
+	Transition(ctx context.Context, mandateID string, from []string, status string) (*model.Mandate, error)
 }
 
 type CollectRepo interface {
 	Create(ctx context.Context, c *model.CollectRequest) error
+	FindByID(ctx context.Context, id bson.ObjectID) (*model.CollectRequest, error)
+	FindPendingByPayer(ctx context.Context, fromVPAs []string) ([]model.CollectRequest, error)
+	// Transition atomically moves a request from one status to another and
+	// returns mongo.ErrNoDocuments when it is not in the expected status.
+	Transition(ctx context.Context, id bson.ObjectID, from, to, txnID string) error
+	// Claim moves a pending request to processing along with the ID it is
+	// about to be paid under, returning mongo.ErrNoDocuments when it is not
+	// pending. Release returns a processing request to pending.
+	Claim(ctx context.Context, id bson.ObjectID, txnID string) error
+	Release(ctx context.Context, id bson.ObjectID) error
+	// FindStaleClaims lists requests claimed before claimedBefore that are
+	// still processing.
+	FindStaleClaims(ctx context.Context, claimedBefore time.Time) ([]model.CollectRequest, error)
 }
 
 type vpaRepo struct{ col *mongo.Collection }
@@ -73,27 +118,311 @@ func (r *vpaRepo) FindByUserID(ctx context.Context, userID bson.ObjectID) ([]mod
 	return vpas, nil
 }
 
+func (r *vpaRepo) FindByAccountID(ctx context.Context, accountID string) ([]model.VPA, error) {
+	cursor, err := r.col.Find(ctx, secure.Equal("account_id", accountID))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var vpas []model.VPA
+	if err := cursor.All(ctx, &vpas); err != nil {
+		return nil, err
+	}
+	return vpas, nil
+}
+
+func (r *vpaRepo) Deactivate(ctx context.Context, address string) error {
+	_, err := r.col.UpdateOne(ctx, bson.M{"address": address},
+		bson.M{"$set": bson.M{"is_active": false, "updated_at": time.Now()}})
+	return err
+}
+
 func (r *txnRepo) Create(ctx context.Context, t *model.UPITransaction) error {
 	t.CreatedAt = time.Now()
 	_, err := r.col.InsertOne(ctx, t)
 	return err
 }
 
-func (r *txnRepo) FindByUserID(ctx context.Context, userID bson.ObjectID, page, limit int64) ([]model.UPITransaction, int64, error) {
-	filter := bson.M{"user_id": userID}
-	total, _ := r.col.CountDocuments(ctx, filter)
+func (r *txnRepo) FindByTxnID(ctx context.Context, txnID string) (*model.UPITransaction, error) {
+	var t model.UPITransaction
+	if err := r.col.FindOne(ctx, bson.M{"txn_id": txnID}).Decode(&t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *txnRepo) FindByRRN(ctx context.Context, rrn string) (*model.UPITransaction, error) {
+	var t model.UPITransaction
+	if err := r.col.FindOne(ctx, bson.M{"rrn": rrn}).Decode(&t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *txnRepo) Resolve(ctx context.Context, txnID, status, reason string) (*model.UPITransaction, error) {
+	set := bson.M{"status": status}
+	if reason != "" {
+		set["failure_reason"] = reason
+	}
+	var t model.UPITransaction
+	err := r.col.FindOneAndUpdate(ctx, bson.M{"txn_id": txnID, "status": "pending"}, bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&t)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *txnRepo) FindByUserID(ctx context.Context, userID bson.ObjectID, vpas []string, f *model.TxnFilter, after *model.TxnCursor, skip, limit int64) ([]model.UPITransaction, error) {
+	filter := txnFilter(userID, vpas, f)
 	opts := options.Find().
-		SetSort(bson.D{{Key: "transaction_date", Value: -1}}).
-		SetSkip((page - 1) * limit).
+		SetSort(bson.D{{Key: "transaction_date", Value: -1}, {Key: "_id", Value: -1}}).
 		SetLimit(limit)
+	if after != nil {
+		filter["$and"] = append(filter["$and"].([]bson.M), bson.M{"$or": []bson.M{
+			{"transaction_date": bson.M{"$lt": after.Date}},
+			{"transaction_date": after.Date, "_id": bson.M{"$lt": after.ID}},
+		}})
+	} else {
+		opts.SetSkip(skip)
+	}
 	cursor, err := r.col.Find(ctx, filter, opts)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var txns []model.UPITransaction
+	if err := cursor.All(ctx, &txns); err != nil {
+		return nil, err
+	}
+	return txns, nil
+}
+
+func (r *txnRepo) CountByUserID(ctx context.Context, userID bson.ObjectID, vpas []string, f *model.TxnFilter) (int64, error) {
+	return r.col.CountDocuments(ctx, txnFilter(userID, vpas, f))
+}
+
+func txnFilter(userID bson.ObjectID, vpas []string, f *model.TxnFilter) bson.M {
+	sent := bson.M{"user_id": userID}
+	received := bson.M{"to_vpa": bson.M{"$in": vpas}}
+	if f.Counterparty != "" {
+		sent["to_vpa"] = f.Counterparty
+		received["from_vpa"] = f.Counterparty
+	}
+
+	var and []bson.M
+	switch f.Direction {
+	case "sent":
+		and = append(and, sent)
+	case "received":
+		and = append(and, received)
+	default:
+		and = append(and, bson.M{"$or": []bson.M{sent, received}})
+	}
+
+	if f.From != nil || f.To != nil {
+		date := bson.M{}
+		if f.From != nil {
+			date["$gte"] = *f.From
+		}
+		if f.To != nil {
+			date["$lt"] = *f.To
+		}
+		and = append(and, bson.M{"transaction_date": date})
+	}
+	if f.MinAmount != nil || f.MaxAmount != nil {
+		amount := bson.M{}
+		if f.MinAmount != nil {
+			amount["$gte"] = *f.MinAmount
+		}
+		if f.MaxAmount != nil {
+			amount["$lte"] = *f.MaxAmount
+		}
+		and = append(and, bson.M{"amount": amount})
+	}
+	if f.Status != "" {
+		and = append(and, bson.M{"status": f.Status})
+	}
+	if f.Type != "" {
+		and = append(and, bson.M{"type": f.Type})
+	}
+
+	filter := bson.M{"$and": and}
+	if f.Query != "" {
+		for k, v := range secure.Search("note", f.Query) {
+			filter[k] = v
+		}
+	}
+	return filter
+}
+
+// MerchantTotals aggregates a merchant's successful P2M payments in [from, to).
+func (r *txnRepo) MerchantTotals(ctx context.Context, merchantID bson.ObjectID, from, to time.Time) (*model.MerchantSettlement, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"merchant_id":      merchantID,
+			"status":           "success",
+			"transaction_date": bson.M{"$gte": from, "$lt": to},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":          nil,
+			"txn_count":    bson.M{"$sum": 1},
+			"gross_amount": bson.M{"$sum": "$amount"},
+			"mdr_fee":      bson.M{"$sum": "$mdr_fee"},
+		}}},
+	}
+	cursor, err := r.col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
 	}
 	defer cursor.Close(ctx)
+	var res []model.MerchantSettlement
+	if err := cursor.All(ctx, &res); err != nil {
+		return nil, err
+	}
+	totals := &model.MerchantSettlement{MerchantID: merchantID}
+	if len(res) > 0 {
+		totals.TxnCount = res[0].TxnCount
+		totals.GrossAmount = res[0].GrossAmount
+		totals.MDRFee = res[0].MDRFee
+	}
+	return totals, nil
+}
+
+func (r *txnRepo) SetCategory(ctx context.Context, userID bson.ObjectID, toVPA, category string) (int64, error) {
+	res, err := r.col.UpdateMany(ctx, bson.M{"user_id": userID, "to_vpa": toVPA},
+		bson.M{"$set": bson.M{"category": category}})
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+func (r *txnRepo) SpendByCategory(ctx context.Context, userID bson.ObjectID, vpas []string, from, to time.Time) ([]model.CategorySpend, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: spendMatch(userID, vpas, from, to)}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"month":    bson.M{"$dateToString": bson.M{"format": "%Y-%m", "date": "$transaction_date", "timezone": istOffset}},
+				"category": bson.M{"$ifNull": bson.A{"$category", "uncategorized"}},
+			},
+			"amount": bson.M{"$sum": "$amount"},
+			"count":  bson.M{"$sum": 1},
+		}}},
+		{{Key: "$project", Value: bson.M{"_id": 0, "month": "$_id.month", "category": "$_id.category", "amount": 1, "count": 1}}},
+		{{Key: "$sort", Value: bson.D{{Key: "month", Value: 1}, {Key: "amount", Value: -1}}}},
+	}
+	var res []model.CategorySpend
+	return res, r.aggregate(ctx, pipeline, &res)
+}
+
+func (r *txnRepo) TopPayees(ctx context.Context, userID bson.ObjectID, vpas []string, from, to time.Time, limit int64) ([]model.PayeeSpend, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: spendMatch(userID, vpas, from, to)}},
+		{{Key: "$group", Value: bson.M{"_id": "$to_vpa", "amount": bson.M{"$sum": "$amount"}, "count": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "amount", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+	var res []model.PayeeSpend
+	return res, r.aggregate(ctx, pipeline, &res)
+}
+
+func (r *txnRepo) CashFlow(ctx context.Context, userID bson.ObjectID, vpas []string, from, to time.Time, period string) ([]model.CashFlowPoint, error) {
+	sent := bson.M{"$eq": bson.A{"$user_id", userID}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"$or": []bson.M{
+				{"user_id": userID, "to_vpa": bson.M{"$nin": vpas}},
+				{"to_vpa": bson.M{"$in": vpas}, "user_id": bson.M{"$ne": userID}},
+			},
+			"status":           "success",
+			"transaction_date": bson.M{"$gte": from, "$lt": to},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     bson.M{"$dateToString": bson.M{"format": period, "date": "$transaction_date", "timezone": istOffset}},
+			"inflow":  bson.M{"$sum": bson.M{"$cond": bson.A{sent, 0, "$amount"}}},
+			"outflow": bson.M{"$sum": bson.M{"$cond": bson.A{sent, "$amount", 0}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	}
+	var res []model.CashFlowPoint
+	return res, r.aggregate(ctx, pipeline, &res)
+}
+
+func (r *txnRepo) RecentPayees(ctx context.Context, userID bson.ObjectID, vpas []string, limit int64) ([]model.PayeeSuggestion, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userID, "to_vpa": bson.M{"$nin": vpas}, "status": "success"}}},
+		{{Key: "$sort", Value: bson.D{{Key: "transaction_date", Value: -1}}}},
+		{{Key: "$limit", Value: 500}}, // only recent history matters
+		{{Key: "$group", Value: bson.M{
+			"_id":          "$to_vpa",
+			"last_paid_at": bson.M{"$first": "$transaction_date"},
+			"count":        bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "last_paid_at", Value: -1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+	var res []model.PayeeSuggestion
+	return res, r.aggregate(ctx, pipeline, &res)
+}
+
+const istOffset = "+05:30"
+
+func spendMatch(userID bson.ObjectID, vpas []string, from, to time.Time) bson.M {
+	return bson.M{
+		"user_id":          userID,
+		"to_vpa":           bson.M{"$nin": vpas},
+		"status":           "success",
+		"transaction_date": bson.M{"$gte": from, "$lt": to},
+	}
+}
+
+func (r *txnRepo) FindUnposted(ctx context.Context, after bson.ObjectID, limit int64) ([]model.UPITransaction, error) {
+	pipeline := append(unpostedTxns(after),
+		bson.D{{Key: "$sort", Value: bson.M{"_id": 1}}},
+		bson.D{{Key: "$limit", Value: limit}},
+		bson.D{{Key: "$project", Value: bson.M{"posted": 0}}},
+	)
 	var txns []model.UPITransaction
-	cursor.All(ctx, &txns)
-	return txns, total, nil
+	if err := r.aggregate(ctx, pipeline, &txns); err != nil {
+		return nil, err
+	}
+	return txns, nil
+}
+
+func (r *txnRepo) CountUnposted(ctx context.Context) (int64, error) {
+	var out []struct {
+		N int64 `bson:"n"`
+	}
+	if err := r.aggregate(ctx, append(unpostedTxns(bson.ObjectID{}), bson.D{{Key: "$count", Value: "n"}}), &out); err != nil || len(out) == 0 {
+		return 0, err
+	}
+	return out[0].N, nil
+}
+
+// unpostedTxns matches transactions that moved money but have no ledger
+// debit, such as those made before the ledger existed.
+func unpostedTxns(after bson.ObjectID) mongo.Pipeline {
+	return mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"_id": bson.M{"$gt": after}, "status": bson.M{"$in": bson.A{"pending", "success"}}}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "ledger_entries",
+			"localField":   "txn_id",
+			"foreignField": "txn_id",
+			"pipeline":     bson.A{bson.M{"$match": bson.M{"direction": "debit"}}, bson.M{"$limit": 1}},
+			"as":           "posted",
+		}}},
+		{{Key: "$match", Value: bson.M{"posted": bson.M{"$size": 0}}}},
+	}
+}
+
+func (r *txnRepo) aggregate(ctx context.Context, pipeline mongo.Pipeline, out interface{}) error {
+	cursor, err := r.col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	return cursor.All(ctx, out)
 }
 
 func (r *mandateRepo) Create(ctx context.Context, m *model.Mandate) error {
@@ -114,8 +443,105 @@ func (r *mandateRepo) FindByUserID(ctx context.Context, userID bson.ObjectID) ([
 	return mandates, nil
 }
 
+func (r *mandateRepo) FindByMandateID(ctx context.Context, mandateID string) (*model.Mandate, error) {
+	var m model.Mandate
+	if err := r.col.FindOne(ctx, bson.M{"mandate_id": mandateID}).Decode(&m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *mandateRepo) Transition(ctx context.Context, mandateID string, from []string, status string) (*model.Mandate, error) {
+	var m model.Mandate
+	err := r.col.FindOneAndUpdate(ctx,
+		bson.M{"mandate_id": mandateID, "status": bson.M{"$in": from}},
+		bson.M{"$set": bson.M{"status": status, "updated_at": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&m)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
 func (r *collectRepo) Create(ctx context.Context, c *model.CollectRequest) error {
 	c.CreatedAt = time.Now()
-	_, err := r.col.InsertOne(ctx, c)
+	res, err := r.col.InsertOne(ctx, c)
+	if err != nil {
+		return err
+	}
+	c.ID, _ = res.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+func (r *collectRepo) FindByID(ctx context.Context, id bson.ObjectID) (*model.CollectRequest, error) {
+	var c model.CollectRequest
+	if err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *collectRepo) FindPendingByPayer(ctx context.Context, fromVPAs []string) ([]model.CollectRequest, error) {
+	filter := bson.M{
+		"from_vpa":   bson.M{"$in": fromVPAs},
+		"status":     "pending",
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var requests []model.CollectRequest
+	cursor.All(ctx, &requests)
+	return requests, nil
+}
+
+func (r *collectRepo) Transition(ctx context.Context, id bson.ObjectID, from, to, txnID string) error {
+	set := bson.M{"status": to}
+	if txnID != "" {
+		set["txn_id"] = txnID
+	}
+	res, err := r.col.UpdateOne(ctx, bson.M{"_id": id, "status": from}, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *collectRepo) Claim(ctx context.Context, id bson.ObjectID, txnID string) error {
+	res, err := r.col.UpdateOne(ctx, bson.M{"_id": id, "status": "pending"},
+		bson.M{"$set": bson.M{"status": "processing", "txn_id": txnID, "claimed_at": time.Now()}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *collectRepo) Release(ctx context.Context, id bson.ObjectID) error {
+	_, err := r.col.UpdateOne(ctx, bson.M{"_id": id, "status": "processing"}, bson.M{
+		"$set":   bson.M{"status": "pending"},
+		"$unset": bson.M{"txn_id": "", "claimed_at": ""},
+	})
 	return err
 }
+
+func (r *collectRepo) FindStaleClaims(ctx context.Context, claimedBefore time.Time) ([]model.CollectRequest, error) {
+	cursor, err := r.col.Find(ctx, bson.M{"status": "processing", "claimed_at": bson.M{"$lt": claimedBefore}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var requests []model.CollectRequest
+	if err := cursor.All(ctx, &requests); err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
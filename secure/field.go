@@ -0,0 +1,177 @@
+// Package secure encrypts sensitive model fields at rest. Fields typed
+// String, Indexed or Text are stored as envelopes: the value is sealed with
+// a fresh data key, and the data key is wrapped with a key encryption key
+// whose ID is kept alongside, so keys can be rotated without touching the
+// ciphertext. Indexed and Text also store blind indexes, keyed hashes that
+// let equal values and words be found without decrypting anything.
+//
+// Encryption is off until Use is called; values are then stored in clear.
+// Clear values are always readable, so data written before encryption was
+// turned on keeps working until it is re-encrypted.
+package secure
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/x/bsonx/bsoncore"
+)
+
+var ErrNoKeys = errors.New("encrypted value but no key provider configured")
+
+var provider KeyProvider
+
+// Use turns on encryption with keys from p. It must be called before any
+// model is read or written.
+func Use(p KeyProvider) { provider = p }
+
+func Enabled() bool { return provider != nil }
+
+// CurrentKeyID is the key new values are wrapped with, or "" when
+// encryption is off.
+func CurrentKeyID() string {
+	if provider == nil {
+		return ""
+	}
+	return provider.CurrentKeyID()
+}
+
+// String is encrypted at rest and plain everywhere else, JSON included.
+type String string
+
+// Indexed is a String that can be looked up by exact value with Equal.
+type Indexed string
+
+// Text is a String that can be searched by word with Search.
+type Text string
+
+// envelope is how an encrypted value is stored.
+type envelope struct {
+	KeyID      string   `bson:"k"`
+	DataKey    []byte   `bson:"dk"` // wrapped with KeyID
+	Ciphertext []byte   `bson:"c"`  // nonce and AES-256-GCM ciphertext
+	Index      string   `bson:"bi,omitempty"`
+	Terms      []string `bson:"t,omitempty"`
+}
+
+func (s String) MarshalBSONValue() (byte, []byte, error) {
+	return marshal(string(s), nil)
+}
+
+func (s *String) UnmarshalBSONValue(t byte, data []byte) error {
+	v, err := unmarshal(t, data)
+	*s = String(v)
+	return err
+}
+
+func (s Indexed) MarshalBSONValue() (byte, []byte, error) {
+	return marshal(string(s), func(e *envelope) { e.Index = IndexOf(string(s)) })
+}
+
+func (s *Indexed) UnmarshalBSONValue(t byte, data []byte) error {
+	v, err := unmarshal(t, data)
+	*s = Indexed(v)
+	return err
+}
+
+func (s Text) MarshalBSONValue() (byte, []byte, error) {
+	return marshal(string(s), func(e *envelope) { e.Terms = TermsOf(string(s)) })
+}
+
+func (s *Text) UnmarshalBSONValue(t byte, data []byte) error {
+	v, err := unmarshal(t, data)
+	*s = Text(v)
+	return err
+}
+
+// Fingerprint implements Sensitive.
+func (s String) Fingerprint() string  { return Fingerprint(string(s)) }
+func (s Indexed) Fingerprint() string { return Fingerprint(string(s)) }
+func (s Text) Fingerprint() string    { return Fingerprint(string(s)) }
+
+// Sensitive is implemented by the encrypted field types, so code that copies
+// models elsewhere, such as the audit trail, can leave the plaintext out.
+type Sensitive interface {
+	Fingerprint() string
+}
+
+// marshal stores empty values in clear; there is nothing to hide and it
+// keeps omitempty and equality on "" working.
+func marshal(plaintext string, index func(*envelope)) (byte, []byte, error) {
+	if provider == nil || plaintext == "" {
+		return byte(bson.TypeString), bsoncore.AppendString(nil, plaintext), nil
+	}
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return 0, nil, err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return 0, nil, err
+	}
+	e := envelope{KeyID: provider.CurrentKeyID()}
+	if e.Ciphertext, err = seal(aead, []byte(plaintext)); err != nil {
+		return 0, nil, err
+	}
+	// In production: data keys are cached per key ID for a while rather than
+	// wrapped per value, which would otherwise be a KMS call each.
+	if e.DataKey, err = provider.Wrap(e.KeyID, dataKey); err != nil {
+		return 0, nil, fmt.Errorf("wrapping data key: %w", err)
+	}
+	if index != nil {
+		index(&e)
+	}
+	doc, err := bson.Marshal(e)
+	return byte(bson.TypeEmbeddedDocument), doc, err
+}
+
+func unmarshal(t byte, data []byte) (string, error) {
+	switch bson.Type(t) {
+	case bson.TypeString:
+		s, _, ok := bsoncore.ReadString(data)
+		if !ok {
+			return "", errors.New("malformed string")
+		}
+		return s, nil
+	case bson.TypeNull, bson.TypeUndefined:
+		return "", nil
+	case bson.TypeEmbeddedDocument:
+		var e envelope
+		if err := bson.Unmarshal(data, &e); err != nil {
+			return "", err
+		}
+		return decrypt(&e)
+	}
+	return "", fmt.Errorf("cannot decode %s into an encrypted string", bson.Type(t))
+}
+
+func decrypt(e *envelope) (string, error) {
+	if provider == nil {
+		return "", ErrNoKeys
+	}
+	dataKey, err := provider.Unwrap(e.KeyID, e.DataKey)
+	if err != nil {
+		return "", fmt.Errorf("unwrapping data key %s: %w", e.KeyID, err)
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(aead, e.Ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,99 @@
+package secure
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// IndexOf is the blind index of v: a keyed hash of its normalised form,
+// truncated to 128 bits. Distinct values can share an index, so a match on
+// the index is a candidate only and callers must compare the decrypted value
+// before relying on it. "" when encryption is off.
+func IndexOf(v string) string {
+	return blind("eq", strings.ToLower(strings.TrimSpace(v)))
+}
+
+// TermsOf returns the blind index of each distinct word of v.
+func TermsOf(v string) []string {
+	if provider == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var terms []string
+	for _, w := range words(v) {
+		if t := blind("term", w); !seen[t] {
+			seen[t] = true
+			terms = append(terms, t)
+		}
+	}
+	return terms
+}
+
+func words(v string) []string {
+	return strings.FieldsFunc(strings.ToLower(v), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func blind(domain, v string) string {
+	if provider == nil || v == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, provider.IndexKey())
+	mac.Write([]byte(domain + ":" + v))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// Fingerprint identifies a value without revealing it, for records such as
+// the audit trail that must show a field changed but not what it holds.
+func Fingerprint(v string) string {
+	if v == "" {
+		return ""
+	}
+	if provider == nil {
+		sum := sha256.Sum256([]byte(v))
+		return "sha256:" + hex.EncodeToString(sum[:8])
+	}
+	return "bidx:" + blind("fp", v)
+}
+
+// Equal filters field, an Indexed field, to value. Matches can include other
+// values with the same index; see IndexOf.
+func Equal(field, value string) bson.M {
+	if provider == nil {
+		return bson.M{field: value}
+	}
+	return bson.M{field + ".bi": IndexOf(value)}
+}
+
+// Search filters field, a Text field, to values containing any word of q.
+// With encryption off it falls back to the collection's text index.
+func Search(field, q string) bson.M {
+	if provider == nil {
+		return bson.M{"$text": bson.M{"$search": q}}
+	}
+	terms := make([]string, 0)
+	for _, w := range words(q) {
+		terms = append(terms, blind("term", w))
+	}
+	return bson.M{field + ".t": bson.M{"$in": terms}}
+}
+
+// Pseudonym derives a stable stand-in for id, so that records kept after an
+// erasure still link to each other but not to the person. With encryption
+// on only the index key can link them back; with it off anyone holding id
+// can.
+func Pseudonym(id string) []byte {
+	if provider == nil {
+		sum := sha256.Sum256([]byte("pseudonym:" + id))
+		return sum[:]
+	}
+	mac := hmac.New(sha256.New, provider.IndexKey())
+	mac.Write([]byte("pseudonym:" + id))
+	return mac.Sum(nil)
+}
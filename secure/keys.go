@@ -0,0 +1,136 @@
+package secure
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+var ErrUnknownKey = errors.New("unknown key ID")
+
+// KeyProvider holds the key encryption keys (KEKs) that wrap each value's
+// data key. Keys are looked up by ID, so a rotated-out key still opens what
+// it wrapped. In production: a KMS implements this and the KEKs never leave it.
+type KeyProvider interface {
+	// CurrentKeyID names the KEK new data keys are wrapped with.
+	CurrentKeyID() string
+	Wrap(keyID string, dataKey []byte) ([]byte, error)
+	Unwrap(keyID string, wrapped []byte) ([]byte, error)
+	// IndexKey is the HMAC key for blind indexes. It is never rotated, since
+	// every index would have to be recomputed from the plaintext.
+	IndexKey() []byte
+}
+
+// LocalKeyProvider reads its keys from a JSON file:
+//
+//	{"current": "2025-01", "keys": {"2024-06": "<base64>", "2025-01": "<base64>"}, "index_key": "<base64>"}
+//
+// Every key is 32 random bytes. To rotate, add a key, make it current and
+// run `upictl reencrypt`; drop the old key once that has finished.
+type LocalKeyProvider struct {
+	current  string
+	keys     map[string]cipher.AEAD
+	indexKey []byte
+}
+
+func LoadKeyFile(path string) (*LocalKeyProvider, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f struct {
+		Current  string            `json:"current"`
+		Keys     map[string]string `json:"keys"`
+		IndexKey string            `json:"index_key"`
+	}
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, fmt.Errorf("key file: %w", err)
+	}
+	p := &LocalKeyProvider{current: f.Current, keys: make(map[string]cipher.AEAD)}
+	for id, encoded := range f.Keys {
+		key, err := decodeKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+		block, _ := aes.NewCipher(key)
+		p.keys[id], _ = cipher.NewGCM(block)
+	}
+	if _, ok := p.keys[p.current]; !ok {
+		return nil, fmt.Errorf("current key %q: %w", p.current, ErrUnknownKey)
+	}
+	if p.indexKey, err = decodeKey(f.IndexKey); err != nil {
+		return nil, fmt.Errorf("index key: %w", err)
+	}
+	return p, nil
+}
+
+func decodeKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != 32 {
+		return nil, errors.New("key must be 32 bytes")
+	}
+	return key, nil
+}
+
+func (p *LocalKeyProvider) CurrentKeyID() string { return p.current }
+func (p *LocalKeyProvider) IndexKey() []byte     { return p.indexKey }
+
+func (p *LocalKeyProvider) Wrap(keyID string, dataKey []byte) ([]byte, error) {
+	kek, ok := p.keys[keyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return seal(kek, dataKey)
+}
+
+func (p *LocalKeyProvider) Unwrap(keyID string, wrapped []byte) ([]byte, error) {
+	kek, ok := p.keys[keyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return open(kek, wrapped)
+}
+
+// seal returns the nonce followed by the AES-GCM ciphertext of plaintext.
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(aead cipher.AEAD, sealed []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	return aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+}
+
+// Setup turns on encryption with the keys in keyFile. With no key file it
+// fails, unless disabled opts out for local development, in which case
+// encryption stays off and it warns.
+func Setup(keyFile string, disabled bool) error {
+	if keyFile == "" {
+		if !disabled {
+			return errors.New("ENCRYPTION_KEY_FILE is not set; set ENCRYPTION_DISABLED=true to store sensitive fields in clear for local development")
+		}
+		slog.Warn("ENCRYPTION_DISABLED is set; sensitive fields are stored in clear")
+		return nil
+	}
+	keys, err := LoadKeyFile(keyFile)
+	if err != nil {
+		return err
+	}
+	Use(keys)
+	return nil
+}
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/banking-superapp/upi-service/metrics"
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/repository"
+	"github.com/banking-superapp/upi-service/secure"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var ErrInvalidAuditQuery = errors.New("invalid audit query")
+
+const (
+	maxAuditPage     = 200
+	maxAuditVerify   = 10000
+	auditAppendTries = 5
+	auditDrainBatch  = 100
+)
+
+// AuditService keeps the hash-chained trail of changes to VPAs,
+// transactions, collects and mandates.
+type AuditService interface {
+	// Record queues an event for a change from before to after; either may
+	// be nil for creations and deletions. The change has already happened,
+	// so failures are logged rather than returned.
+	Record(ctx context.Context, action, entityType, entityID string, before, after interface{})
+	// Drain links queued events into the trail.
+	Drain(ctx context.Context) error
+	Query(ctx context.Context, q *model.AuditQuery, limit int64) ([]model.AuditEvent, error)
+	// Verify recomputes up to limit hashes from sequence number fromSeq on.
+	Verify(ctx context.Context, fromSeq, limit int64) (*model.AuditVerification, error)
+}
+
+type auditService struct {
+	trailRepo repository.AuditTrailRepo
+}
+
+func NewAuditService(ar repository.AuditTrailRepo) AuditService {
+	return &auditService{trailRepo: ar}
+}
+
+type auditActorKey struct{}
+
+// WithAuditActor attributes changes made with ctx to actor.
+func WithAuditActor(ctx context.Context, actor model.AuditActor) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+// AuditActorFrom returns the actor set by WithAuditActor, or the service
+// itself for work such as scheduled payments that no request started.
+func AuditActorFrom(ctx context.Context) model.AuditActor {
+	if actor, ok := ctx.Value(auditActorKey{}).(model.AuditActor); ok {
+		return actor
+	}
+	return model.AuditActor{Type: "system", ID: "upi-service"}
+}
+
+func (s *auditService) Record(ctx context.Context, action, entityType, entityID string, before, after interface{}) {
+	actor := AuditActorFrom(ctx)
+	if actor.IP != "" {
+		actor.IPDigest = secure.Fingerprint(actor.IP)
+	}
+	e := &model.AuditEvent{
+		Actor:      actor,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    auditDiff(before, after),
+	}
+	// The audit trail must not lose a change because the request finished.
+	// Queueing is a single insert, so requests never wait on each other for
+	// a place in the chain.
+	ctx = context.WithoutCancel(ctx)
+	if err := s.trailRepo.Enqueue(ctx, e); err != nil {
+		// The whole event is logged so it can be appended by hand.
+		metrics.AuditAppendFailures.Inc()
+		slog.ErrorContext(ctx, "audit trail append failed", "event", e, "error", err)
+	}
+}
+
+func (s *auditService) Drain(ctx context.Context) error {
+	for {
+		queued, err := s.trailRepo.Queued(ctx, auditDrainBatch)
+		if err != nil {
+			return err
+		}
+		for i := range queued {
+			if err := s.append(ctx, &queued[i]); err != nil {
+				return err
+			}
+			if err := s.trailRepo.Dequeue(ctx, queued[i].ID); err != nil {
+				return err
+			}
+		}
+		if len(queued) < auditDrainBatch {
+			return nil
+		}
+	}
+}
+
+// append links e after the current last event. Another replica draining at
+// the same time can take the next sequence number first, and then append
+// re-reads the tip and tries again, a few times at most; the event stays
+// queued for the next drain if it still loses. e keeps its outbox ID, so an
+// event the other replica appended, or one appended before a crash left it
+// queued, is not appended twice.
+func (s *auditService) append(ctx context.Context, e *model.AuditEvent) error {
+	for tries := 1; ; tries++ {
+		err := s.link(ctx, e)
+		if err == nil || !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+		if appended, aerr := s.trailRepo.Appended(ctx, e.ID); aerr != nil || appended {
+			return aerr
+		}
+		if tries == auditAppendTries {
+			return err
+		}
+	}
+}
+
+func (s *auditService) link(ctx context.Context, e *model.AuditEvent) error {
+	e.Seq, e.PrevHash = 1, ""
+	last, err := s.trailRepo.Last(ctx)
+	if err == nil {
+		e.Seq, e.PrevHash = last.Seq+1, last.Hash
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+	// Mongo keeps milliseconds, so the hash is taken over what is stored.
+	e.At = time.Now().UTC().Truncate(time.Millisecond)
+	e.Hash = auditHash(e)
+	return s.trailRepo.Append(ctx, e)
+}
+
+func (s *auditService) Query(ctx context.Context, q *model.AuditQuery, limit int64) ([]model.AuditEvent, error) {
+	if q.From != nil && q.To != nil && !q.From.Before(*q.To) {
+		return nil, ErrInvalidAuditQuery
+	}
+	if limit <= 0 || limit > maxAuditPage {
+		limit = maxAuditPage
+	}
+	return s.trailRepo.Find(ctx, q, limit)
+}
+
+// Verify cannot notice events removed from the end of the trail. In
+// production: the latest hash is published somewhere the service cannot
+// write, and checked against the tip.
+func (s *auditService) Verify(ctx context.Context, fromSeq, limit int64) (*model.AuditVerification, error) {
+	if fromSeq < 1 {
+		fromSeq = 1
+	}
+	if limit <= 0 || limit > maxAuditVerify {
+		limit = maxAuditVerify
+	}
+	v := &model.AuditVerification{FromSeq: fromSeq, Valid: true}
+
+	// The event before fromSeq anchors the first link checked.
+	prevHash := ""
+	if fromSeq > 1 {
+		anchor, err := s.trailRepo.FindFrom(ctx, fromSeq-1, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(anchor) == 0 || anchor[0].Seq != fromSeq-1 {
+			return brokenChain(v, fromSeq-1, "event missing"), nil
+		}
+		prevHash = anchor[0].Hash
+	}
+
+	events, err := s.trailRepo.FindFrom(ctx, fromSeq, limit)
+	if err != nil {
+		return nil, err
+	}
+	want := fromSeq
+	for i := range events {
+		e := &events[i]
+		switch {
+		case e.Seq != want:
+			return brokenChain(v, want, "event missing"), nil
+		case e.PrevHash != prevHash:
+			return brokenChain(v, e.Seq, "previous hash mismatch"), nil
+		case auditHash(e) != e.Hash:
+			return brokenChain(v, e.Seq, "hash mismatch"), nil
+		case e.Actor.IP != "" && e.Actor.IPDigest != "" && secure.Fingerprint(e.Actor.IP) != e.Actor.IPDigest:
+			return brokenChain(v, e.Seq, "ip digest mismatch"), nil
+		}
+		v.Checked++
+		v.ToSeq = e.Seq
+		want, prevHash = e.Seq+1, e.Hash
+	}
+	return v, nil
+}
+
+func brokenChain(v *model.AuditVerification, seq int64, reason string) *model.AuditVerification {
+	v.Valid, v.BrokenAt, v.Reason = false, seq, reason
+	return v
+}
+
+// auditHash is the SHA-256 of the event's content and PrevHash. Where the
+// actor has an IPDigest it stands in for the IP, which retention clears.
+func auditHash(e *model.AuditEvent) string {
+	actor := e.Actor
+	if actor.IPDigest != "" {
+		actor.IP = ""
+	}
+	b, _ := json.Marshal(struct {
+		Seq        int64
+		PrevHash   string
+		At         string
+		Actor      model.AuditActor
+		Action     string
+		EntityType string
+		EntityID   string
+		Changes    map[string]model.AuditChange
+	}{e.Seq, e.PrevHash, e.At.UTC().Format(time.RFC3339Nano), actor, e.Action, e.EntityType, e.EntityID, e.Changes})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// auditDiff compares the JSON form of before and after field by field.
+func auditDiff(before, after interface{}) map[string]model.AuditChange {
+	from, to := auditFields(before), auditFields(after)
+	changes := make(map[string]model.AuditChange)
+	for k, v := range to {
+		if !bytes.Equal(from[k], v) {
+			changes[k] = model.AuditChange{From: from[k], To: v}
+		}
+	}
+	for k, v := range from {
+		if _, ok := to[k]; !ok {
+			changes[k] = model.AuditChange{From: v}
+		}
+	}
+	return changes
+}
+
+func auditFields(v interface{}) map[string]json.RawMessage {
+	if v == nil || reflect.ValueOf(v).Kind() == reflect.Pointer && reflect.ValueOf(v).IsNil() {
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	b, err := json.Marshal(v)
+	if err == nil {
+		_ = json.Unmarshal(b, &fields)
+	}
+	// Encrypted fields are kept as fingerprints, or the trail would hold
+	// their plaintext.
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return fields
+	}
+	for i := 0; i < rv.NumField(); i++ {
+		sf := rv.Type().Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		value, ok := rv.Field(i).Interface().(secure.Sensitive)
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if _, present := fields[name]; ok && present {
+			fields[name], _ = json.Marshal(value.Fingerprint())
+		}
+	}
+	return fields
+}
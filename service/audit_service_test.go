@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/repository"
+	"github.com/banking-superapp/upi-service/secure"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// fakeTrail serves FindFrom from memory; nothing else is used by Verify.
+type fakeTrail struct {
+	repository.AuditTrailRepo
+	events []model.AuditEvent
+}
+
+func (f *fakeTrail) FindFrom(_ context.Context, seq, limit int64) ([]model.AuditEvent, error) {
+	var out []model.AuditEvent
+	for _, e := range f.events {
+		if e.Seq >= seq && int64(len(out)) < limit {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func auditChain(n int) []model.AuditEvent {
+	events := make([]model.AuditEvent, n)
+	prev := ""
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range events {
+		e := &events[i]
+		e.Seq, e.PrevHash, e.At = int64(i+1), prev, at.Add(time.Duration(i)*time.Second)
+		e.Actor = model.AuditActor{Type: "user", ID: "u1", IP: "203.0.113.7", IPDigest: secure.Fingerprint("203.0.113.7")}
+		e.Action, e.EntityType, e.EntityID = "vpa.update", "vpa", "asha@okbank"
+		e.Changes = map[string]model.AuditChange{"status": {From: json.RawMessage(`"active"`), To: json.RawMessage(`"inactive"`)}}
+		e.Hash = auditHash(e)
+		prev = e.Hash
+	}
+	return events
+}
+
+func TestAuditVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(events []model.AuditEvent) []model.AuditEvent
+		from   int64
+		limit  int64
+		want   model.AuditVerification
+	}{
+		{
+			name: "intact",
+			want: model.AuditVerification{FromSeq: 1, ToSeq: 5, Checked: 5, Valid: true},
+		},
+		{
+			name: "from the middle",
+			from: 3,
+			want: model.AuditVerification{FromSeq: 3, ToSeq: 5, Checked: 3, Valid: true},
+		},
+		{
+			name:  "limited",
+			limit: 2,
+			want:  model.AuditVerification{FromSeq: 1, ToSeq: 2, Checked: 2, Valid: true},
+		},
+		{
+			name: "IP cleared by retention",
+			tamper: func(events []model.AuditEvent) []model.AuditEvent {
+				events[2].Actor.IP = ""
+				return events
+			},
+			want: model.AuditVerification{FromSeq: 1, ToSeq: 5, Checked: 5, Valid: true},
+		},
+		{
+			name: "content changed",
+			tamper: func(events []model.AuditEvent) []model.AuditEvent {
+				events[2].Action = "vpa.delete"
+				return events
+			},
+			want: model.AuditVerification{FromSeq: 1, ToSeq: 2, Checked: 2, BrokenAt: 3, Reason: "hash mismatch"},
+		},
+		{
+			name: "content changed and rehashed",
+			tamper: func(events []model.AuditEvent) []model.AuditEvent {
+				events[2].EntityID = "ravi@okbank"
+				events[2].Hash = auditHash(&events[2])
+				return events
+			},
+			want: model.AuditVerification{FromSeq: 1, ToSeq: 3, Checked: 3, BrokenAt: 4, Reason: "previous hash mismatch"},
+		},
+		{
+			name: "event removed",
+			tamper: func(events []model.AuditEvent) []model.AuditEvent {
+				return append(events[:2], events[3:]...)
+			},
+			want: model.AuditVerification{FromSeq: 1, ToSeq: 2, Checked: 2, BrokenAt: 3, Reason: "event missing"},
+		},
+		{
+			name: "anchor removed",
+			tamper: func(events []model.AuditEvent) []model.AuditEvent {
+				return append(events[:1], events[2:]...)
+			},
+			from: 3,
+			want: model.AuditVerification{FromSeq: 3, BrokenAt: 2, Reason: "event missing"},
+		},
+		{
+			name: "IP swapped",
+			tamper: func(events []model.AuditEvent) []model.AuditEvent {
+				events[1].Actor.IP = "198.51.100.1"
+				return events
+			},
+			want: model.AuditVerification{FromSeq: 1, ToSeq: 1, Checked: 1, BrokenAt: 2, Reason: "ip digest mismatch"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := auditChain(5)
+			if tt.tamper != nil {
+				events = tt.tamper(events)
+			}
+			svc := NewAuditService(&fakeTrail{events: events})
+			got, err := svc.Verify(context.Background(), tt.from, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *got != tt.want {
+				t.Errorf("Verify = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuditHashCovers(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(e *model.AuditEvent)
+		changes bool
+	}{
+		{name: "seq", change: func(e *model.AuditEvent) { e.Seq++ }, changes: true},
+		{name: "previous hash", change: func(e *model.AuditEvent) { e.PrevHash = "00" }, changes: true},
+		{name: "time", change: func(e *model.AuditEvent) { e.At = e.At.Add(time.Millisecond) }, changes: true},
+		{name: "actor", change: func(e *model.AuditEvent) { e.Actor.ID = "u2" }, changes: true},
+		{name: "IP digest", change: func(e *model.AuditEvent) { e.Actor.IPDigest = secure.Fingerprint("198.51.100.1") }, changes: true},
+		{name: "action", change: func(e *model.AuditEvent) { e.Action = "vpa.delete" }, changes: true},
+		{name: "entity", change: func(e *model.AuditEvent) { e.EntityType, e.EntityID = "mandate", "m1" }, changes: true},
+		{name: "changes", change: func(e *model.AuditEvent) { e.Changes = nil }, changes: true},
+		{name: "IP without digest", change: func(e *model.AuditEvent) { e.Actor.IPDigest, e.Actor.IP = "", "198.51.100.1" }, changes: true},
+		{name: "IP with digest", change: func(e *model.AuditEvent) { e.Actor.IP = "" }},
+		{name: "zone", change: func(e *model.AuditEvent) { e.At = e.At.In(ist) }},
+		{name: "stored ID and hash", change: func(e *model.AuditEvent) { e.ID, e.Hash = bson.NewObjectID(), "" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := auditChain(1)[0]
+			tt.change(&e)
+			if changed := auditHash(&e) != auditChain(1)[0].Hash; changed != tt.changes {
+				t.Errorf("hash changed = %v, want %v", changed, tt.changes)
+			}
+		})
+	}
+}
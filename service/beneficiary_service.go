@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/repository"
+	"github.com/banking-superapp/upi-service/secure"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	ErrBeneficiaryNotFound = errors.New("beneficiary not found")
+	ErrBeneficiaryExists   = errors.New("beneficiary already saved")
+	ErrBeneficiaryMismatch = errors.New("to_vpa does not match the beneficiary")
+	ErrInvalidNickname     = errors.New("nickname must be 1 to 40 characters")
+)
+
+const (
+	maxNicknameLen     = 40
+	defaultSuggestions = 10
+	maxSuggestions     = 30
+)
+
+type BeneficiaryService interface {
+	CreateBeneficiary(ctx context.Context, userID string, req *model.CreateBeneficiaryRequest) (*model.Beneficiary, error)
+	GetBeneficiaries(ctx context.Context, userID string, favoritesOnly bool) ([]model.Beneficiary, error)
+	UpdateBeneficiary(ctx context.Context, userID, beneficiaryID string, req *model.UpdateBeneficiaryRequest) (*model.Beneficiary, error)
+	DeleteBeneficiary(ctx context.Context, userID, beneficiaryID string) error
+	// SuggestPayees returns recently paid VPAs, saved or not.
+	SuggestPayees(ctx context.Context, userID string, limit int64) ([]model.PayeeSuggestion, error)
+}
+
+type beneficiaryService struct {
+	vpaRepo         repository.VPARepo
+	txnRepo         repository.UPITransactionRepo
+	beneficiaryRepo repository.BeneficiaryRepo
+	upi             UPIService
+}
+
+func NewBeneficiaryService(vr repository.VPARepo, tr repository.UPITransactionRepo, br repository.BeneficiaryRepo, upi UPIService) BeneficiaryService {
+	return &beneficiaryService{vpaRepo: vr, txnRepo: tr, beneficiaryRepo: br, upi: upi}
+}
+
+func (s *beneficiaryService) CreateBeneficiary(ctx context.Context, userID string, req *model.CreateBeneficiaryRequest) (*model.Beneficiary, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	vpa := strings.ToLower(strings.TrimSpace(req.VPA))
+	if !vpaPattern.MatchString(vpa) {
+		return nil, ErrVPANotFound
+	}
+	nickname := strings.TrimSpace(req.Nickname)
+	if nickname == "" || len([]rune(nickname)) > maxNicknameLen {
+		return nil, ErrInvalidNickname
+	}
+
+	validated, err := s.upi.ValidateVPA(ctx, vpa)
+	if err != nil {
+		return nil, err
+	}
+	if !validated.Valid {
+		return nil, ErrVPANotFound
+	}
+
+	b := &model.Beneficiary{
+		UserID:       oid,
+		VPA:          vpa,
+		Nickname:     secure.String(nickname),
+		VerifiedName: secure.String(validated.Name),
+		IsFavorite:   req.IsFavorite,
+	}
+	if err := s.beneficiaryRepo.Create(ctx, b); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrBeneficiaryExists
+		}
+		return nil, err
+	}
+	return b, nil
+}
+
+func (s *beneficiaryService) GetBeneficiaries(ctx context.Context, userID string, favoritesOnly bool) ([]model.Beneficiary, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	return s.beneficiaryRepo.FindByUserID(ctx, oid, favoritesOnly)
+}
+
+func (s *beneficiaryService) UpdateBeneficiary(ctx context.Context, userID, beneficiaryID string, req *model.UpdateBeneficiaryRequest) (*model.Beneficiary, error) {
+	oid, bid, err := beneficiaryIDs(userID, beneficiaryID)
+	if err != nil {
+		return nil, err
+	}
+	set := bson.M{}
+	if req.Nickname != nil {
+		nickname := strings.TrimSpace(*req.Nickname)
+		if nickname == "" || len([]rune(nickname)) > maxNicknameLen {
+			return nil, ErrInvalidNickname
+		}
+		set["nickname"] = secure.String(nickname)
+	}
+	if req.IsFavorite != nil {
+		set["is_favorite"] = *req.IsFavorite
+	}
+	b, err := s.beneficiaryRepo.Update(ctx, oid, bid, set)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrBeneficiaryNotFound
+		}
+		return nil, err
+	}
+	return b, nil
+}
+
+func (s *beneficiaryService) DeleteBeneficiary(ctx context.Context, userID, beneficiaryID string) error {
+	oid, bid, err := beneficiaryIDs(userID, beneficiaryID)
+	if err != nil {
+		return err
+	}
+	err = s.beneficiaryRepo.Delete(ctx, oid, bid)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrBeneficiaryNotFound
+	}
+	return err
+}
+
+func (s *beneficiaryService) SuggestPayees(ctx context.Context, userID string, limit int64) ([]model.PayeeSuggestion, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	if limit < 1 || limit > maxSuggestions {
+		limit = defaultSuggestions
+	}
+
+	vpas, err := s.vpaRepo.FindByUserID(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+	addresses := make([]string, 0, len(vpas))
+	for _, v := range vpas {
+		addresses = append(addresses, v.Address)
+	}
+	suggestions, err := s.txnRepo.RecentPayees(ctx, oid, addresses, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	saved, err := s.beneficiaryRepo.FindByUserID(ctx, oid, false)
+	if err != nil {
+		return nil, err
+	}
+	byVPA := make(map[string]*model.Beneficiary, len(saved))
+	for i := range saved {
+		byVPA[saved[i].VPA] = &saved[i]
+	}
+	for i := range suggestions {
+		if b, ok := byVPA[suggestions[i].VPA]; ok {
+			suggestions[i].BeneficiaryID = &b.ID
+			suggestions[i].Nickname = string(b.Nickname)
+		}
+	}
+	return suggestions, nil
+}
+
+// resolveBeneficiary points a payment request at its beneficiary's VPA.
+func resolveBeneficiary(ctx context.Context, repo repository.BeneficiaryRepo, userID bson.ObjectID, req *model.UPIPayRequest) error {
+	bid, err := bson.ObjectIDFromHex(req.BeneficiaryID)
+	if err != nil {
+		return ErrBeneficiaryNotFound
+	}
+	b, err := repo.FindByID(ctx, userID, bid)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrBeneficiaryNotFound
+		}
+		return err
+	}
+	if req.ToVPA != "" && strings.ToLower(strings.TrimSpace(req.ToVPA)) != b.VPA {
+		return ErrBeneficiaryMismatch
+	}
+	req.ToVPA = b.VPA
+	return nil
+}
+
+func beneficiaryIDs(userID, beneficiaryID string) (bson.ObjectID, bson.ObjectID, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return oid, oid, ErrUnauthorized
+	}
+	bid, err := bson.ObjectIDFromHex(beneficiaryID)
+	if err != nil {
+		return oid, bid, ErrBeneficiaryNotFound
+	}
+	return oid, bid, nil
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/repository"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	ErrBudgetNotFound = errors.New("budget not found")
+	ErrInvalidBudget  = errors.New("budget needs a known category and a positive limit")
+	ErrInvalidMonth   = errors.New("month must be in YYYY-MM format")
+)
+
+// budgetThresholds are the utilization percentages that trigger an alert.
+var budgetThresholds = []int{50, 80, 100}
+
+type BudgetService interface {
+	SetBudget(ctx context.Context, userID string, req *model.SetBudgetRequest) (*model.Budget, error)
+	DeleteBudget(ctx context.Context, userID, category string) error
+	// GetUtilization reports spend against every budget for an IST month
+	// (YYYY-MM), defaulting to the current one.
+	GetUtilization(ctx context.Context, userID, month string) ([]model.BudgetUtilization, error)
+	// Evaluate checks the payer's budget for a successful payment's category
+	// and sends any newly crossed threshold alert.
+	Evaluate(ctx context.Context, txn *model.UPITransaction) error
+}
+
+type budgetService struct {
+	vpaRepo    repository.VPARepo
+	txnRepo    repository.UPITransactionRepo
+	budgetRepo repository.BudgetRepo
+	notifier   Notifier
+}
+
+func NewBudgetService(vr repository.VPARepo, tr repository.UPITransactionRepo, br repository.BudgetRepo, n Notifier) BudgetService {
+	return &budgetService{vpaRepo: vr, txnRepo: tr, budgetRepo: br, notifier: n}
+}
+
+func (s *budgetService) SetBudget(ctx context.Context, userID string, req *model.SetBudgetRequest) (*model.Budget, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	if !validCategory(req.Category) || req.Limit <= 0 || req.Limit != roundPaise(req.Limit) {
+		return nil, ErrInvalidBudget
+	}
+	b := &model.Budget{UserID: oid, Category: req.Category, Limit: req.Limit}
+	if err := s.budgetRepo.Upsert(ctx, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (s *budgetService) DeleteBudget(ctx context.Context, userID, category string) error {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return ErrUnauthorized
+	}
+	err = s.budgetRepo.Delete(ctx, oid, category)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrBudgetNotFound
+	}
+	return err
+}
+
+func (s *budgetService) GetUtilization(ctx context.Context, userID, month string) ([]model.BudgetUtilization, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	start := time.Now()
+	if month != "" {
+		if start, err = time.ParseInLocation("2006-01", month, ist); err != nil {
+			return nil, ErrInvalidMonth
+		}
+	}
+	start, end := istMonth(start)
+
+	budgets, err := s.budgetRepo.FindByUserID(ctx, oid)
+	if err != nil || len(budgets) == 0 {
+		return nil, err
+	}
+	spent, err := s.spend(ctx, oid, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]model.BudgetUtilization, 0, len(budgets))
+	for _, b := range budgets {
+		out = append(out, utilization(b, start.Format("2006-01"), spent[b.Category]))
+	}
+	return out, nil
+}
+
+func (s *budgetService) Evaluate(ctx context.Context, txn *model.UPITransaction) error {
+	if txn.Status != "success" || txn.Category == "" {
+		return nil
+	}
+	budget, err := s.budgetRepo.Find(ctx, txn.UserID, txn.Category)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+		return err
+	}
+
+	start, end := istMonth(txn.TransactionDate)
+	spent, err := s.spend(ctx, txn.UserID, start, end)
+	if err != nil {
+		return err
+	}
+	u := utilization(*budget, start.Format("2006-01"), spent[budget.Category])
+
+	// Claim every crossed threshold but only announce the highest, so a
+	// single large payment does not produce a burst of alerts.
+	crossed := 0
+	for _, t := range budgetThresholds {
+		if u.Percent < float64(t) {
+			break
+		}
+		claimed, err := s.budgetRepo.ClaimAlert(ctx, budget.ID, u.Month, t)
+		if err != nil {
+			return err
+		}
+		if claimed {
+			crossed = t
+		}
+	}
+	if crossed == 0 {
+		return nil
+	}
+
+	body := fmt.Sprintf("You have used %d%% of your Rs %.2f %s budget this month (Rs %.2f spent).", crossed, u.Limit, u.Category, u.Spent)
+	if crossed >= 100 {
+		body = fmt.Sprintf("You have exceeded your Rs %.2f %s budget this month (Rs %.2f spent).", u.Limit, u.Category, u.Spent)
+	}
+	return s.notifier.Notify(ctx, &model.Notification{
+		Recipient: txn.FromVPA,
+		Type:      "budget_alert",
+		Title:     "Budget alert",
+		Body:      body,
+		Data: map[string]string{
+			"category":  u.Category,
+			"month":     u.Month,
+			"threshold": strconv.Itoa(crossed),
+			"spent":     strconv.FormatFloat(u.Spent, 'f', 2, 64),
+			"limit":     strconv.FormatFloat(u.Limit, 'f', 2, 64),
+		},
+	})
+}
+
+// spend returns the user's outgoing spend per category in [start, end).
+func (s *budgetService) spend(ctx context.Context, userID bson.ObjectID, start, end time.Time) (map[string]float64, error) {
+	vpas, err := s.vpaRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	addresses := make([]string, 0, len(vpas))
+	for _, v := range vpas {
+		addresses = append(addresses, v.Address)
+	}
+	rows, err := s.txnRepo.SpendByCategory(ctx, userID, addresses, start, end)
+	if err != nil {
+		return nil, err
+	}
+	spent := make(map[string]float64, len(rows))
+	for _, r := range rows {
+		spent[r.Category] += r.Amount
+	}
+	return spent, nil
+}
+
+func utilization(b model.Budget, month string, spent float64) model.BudgetUtilization {
+	spent = roundPaise(spent)
+	return model.BudgetUtilization{
+		Category:  b.Category,
+		Month:     month,
+		Limit:     b.Limit,
+		Spent:     spent,
+		Remaining: roundPaise(max(b.Limit-spent, 0)),
+		Percent:   math.Round(spent/b.Limit*10000) / 100,
+	}
+}
+
+// evaluateBudgets runs budget checks off the payment path.
+func evaluateBudgets(ctx context.Context, budgets BudgetService, txn model.UPITransaction) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	if err := budgets.Evaluate(ctx, &txn); err != nil {
+		slog.ErrorContext(ctx, "budget evaluation failed", "error", err)
+	}
+}
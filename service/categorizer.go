@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/repository"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// Categories is the fixed set of spend categories shown on the insights
+// screen.
+var Categories = []string{
+	"food", "groceries", "shopping", "travel", "fuel", "bills",
+	"rent", "health", "entertainment", "education", "transfers", "others",
+}
+
+// mccCategories maps ISO 18245 merchant category codes to categories.
+var mccCategories = map[string]string{
+	"5411": "groceries", "5422": "groceries", "5499": "groceries",
+	"5812": "food", "5813": "food", "5814": "food",
+	"5311": "shopping", "5399": "shopping", "5651": "shopping", "5691": "shopping", "5732": "shopping", "5942": "shopping",
+	"4111": "travel", "4121": "travel", "4131": "travel", "4511": "travel", "4722": "travel", "7011": "travel",
+	"5541": "fuel", "5542": "fuel", "5983": "fuel",
+	"4814": "bills", "4899": "bills", "4900": "bills",
+	"5912": "health", "8011": "health", "8062": "health", "8099": "health",
+	"7832": "entertainment", "7841": "entertainment", "7922": "entertainment", "7996": "entertainment",
+	"8211": "education", "8220": "education", "8299": "education",
+}
+
+// keywordRule matches any of words in a merchant's VPA handle or the note.
+type keywordRule struct {
+	category string
+	words    []string
+}
+
+var (
+	payeeRules = []keywordRule{
+		{"food", []string{"swiggy", "zomato", "dominos", "eatsure"}},
+		{"groceries", []string{"bigbasket", "blinkit", "zepto", "dmart", "jiomart"}},
+		{"shopping", []string{"amazon", "flipkart", "myntra", "ajio", "meesho", "nykaa"}},
+		{"travel", []string{"irctc", "uber", "olacabs", "rapido", "makemytrip", "goibibo", "redbus"}},
+		{"fuel", []string{"iocl", "hpcl", "bpcl", "indianoil"}},
+		{"bills", []string{"airtel", "jio", "vodafone", "bescom", "tatapower", "bbps"}},
+		{"entertainment", []string{"bookmyshow", "netflix", "hotstar", "spotify"}},
+		{"health", []string{"pharmeasy", "apollo", "1mg", "practo"}},
+	}
+	noteRules = []keywordRule{
+		{"rent", []string{"rent", "landlord"}},
+		{"food", []string{"food", "dinner", "lunch", "breakfast", "restaurant", "cafe"}},
+		{"groceries", []string{"grocery", "groceries", "vegetables", "milk"}},
+		{"bills", []string{"bill", "recharge", "electricity", "broadband", "wifi"}},
+		{"fuel", []string{"fuel", "petrol", "diesel"}},
+		{"travel", []string{"cab", "taxi", "flight", "train", "bus", "ticket", "hotel"}},
+		{"health", []string{"medicine", "doctor", "hospital", "pharmacy"}},
+		{"education", []string{"fees", "tuition", "school", "college", "course"}},
+		{"entertainment", []string{"movie", "concert", "subscription"}},
+	}
+)
+
+// Categorizer tags outgoing payments. Rules are tried in order: the payer's
+// override for the payee, the merchant's MCC, known merchant handles, note
+// keywords; anything else is a transfer (P2P) or others (P2M).
+type Categorizer struct {
+	overrides repository.CategoryOverrideRepo
+}
+
+func NewCategorizer(or repository.CategoryOverrideRepo) *Categorizer {
+	return &Categorizer{overrides: or}
+}
+
+func (c *Categorizer) Categorize(ctx context.Context, txn *model.UPITransaction) (string, error) {
+	o, err := c.overrides.Find(ctx, txn.UserID, txn.ToVPA)
+	if err == nil {
+		return o.Category, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return "", err
+	}
+
+	if category, ok := mccCategories[txn.MCC]; ok {
+		return category, nil
+	}
+	// Merchant handles vary ("swiggy.food", "zomatoorder"), so they are
+	// matched by substring; a person's handle is not, or anyone named Jio or
+	// Apollo would read as a bill or a pharmacy.
+	if txn.PaymentType == "P2M" {
+		handle, _, _ := strings.Cut(txn.ToVPA, "@")
+		if category := matchKeywords(payeeRules, strings.ToLower(handle), false); category != "" {
+			return category, nil
+		}
+	}
+	if category := matchKeywords(noteRules, strings.ToLower(string(txn.Note)), true); category != "" {
+		return category, nil
+	}
+	if txn.PaymentType == "P2M" {
+		return "others", nil
+	}
+	return "transfers", nil
+}
+
+// matchKeywords returns the first rule with a word found in s. With wholeWord
+// set, words must appear as separate tokens of s.
+func matchKeywords(rules []keywordRule, s string, wholeWord bool) string {
+	tokens := map[string]bool{}
+	if wholeWord {
+		for _, t := range strings.FieldsFunc(s, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+		}) {
+			tokens[t] = true
+		}
+	}
+	for _, rule := range rules {
+		for _, w := range rule.words {
+			if wholeWord && tokens[w] || !wholeWord && strings.Contains(s, w) {
+				return rule.category
+			}
+		}
+	}
+	return ""
+}
+
+func validCategory(category string) bool {
+	for _, c := range Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
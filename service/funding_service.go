@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/repository"
+	"github.com/banking-superapp/upi-service/secure"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	ErrFundingSourceNotFound = errors.New("funding source not found")
+	ErrFundingSourceExists   = errors.New("funding source already linked")
+	ErrInvalidFundingSource  = errors.New("funding source needs a known type, an account and, for credit, a positive limit")
+	ErrFundingNotEligible    = errors.New("funding source cannot be used for this payment")
+	ErrCreditLimitExceeded   = errors.New("amount exceeds the available credit")
+	ErrCreditOutstanding     = errors.New("outstanding credit must be repaid before unlinking")
+	ErrInvalidRepayment      = errors.New("repayment must be a positive amount in paise, no more than is outstanding")
+)
+
+// fundingRule is what a funding source type may pay for.
+type fundingRule struct {
+	p2p, p2m bool
+	credit   bool // draws against a credit limit
+}
+
+var fundingRules = map[string]fundingRule{
+	"savings":     {p2p: true, p2m: true},
+	"credit_line": {p2p: true, p2m: true, credit: true},
+	"credit_card": {p2m: true, credit: true}, // RuPay credit cards pay merchants only
+}
+
+// FundingService manages the accounts a user can pay from in addition to the
+// savings account behind their VPA.
+type FundingService interface {
+	Link(ctx context.Context, userID string, req *model.LinkFundingSourceRequest) (*model.FundingSource, error)
+	List(ctx context.Context, userID string) ([]model.FundingSource, error)
+	// Unlink refuses while credit drawn on the source is outstanding.
+	Unlink(ctx context.Context, userID, id string) error
+	// Repay pays down a credit source from the savings account behind the
+	// user's VPA.
+	Repay(ctx context.Context, userID, id string, req *model.RepayCreditRequest) (*model.FundingSource, error)
+
+	// Reserve checks that the source may fund the classified txn, draws
+	// credit sources and tags txn with the source. Release undoes it for a
+	// payment that was never recorded.
+	Reserve(ctx context.Context, userID bson.ObjectID, sourceID string, txn *model.UPITransaction) error
+	Release(ctx context.Context, txn *model.UPITransaction)
+}
+
+type fundingService struct {
+	sourceRepo repository.FundingSourceRepo
+	vpaRepo    repository.VPARepo
+	ledgerRepo repository.LedgerRepo
+	pins       PINVerifier
+}
+
+func NewFundingService(fr repository.FundingSourceRepo, vr repository.VPARepo, lr repository.LedgerRepo, pins PINVerifier) FundingService {
+	return &fundingService{sourceRepo: fr, vpaRepo: vr, ledgerRepo: lr, pins: pins}
+}
+
+func (s *fundingService) Link(ctx context.Context, userID string, req *model.LinkFundingSourceRequest) (*model.FundingSource, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	rule, ok := fundingRules[req.Type]
+	accountID := strings.TrimSpace(req.AccountID)
+	if !ok || accountID == "" {
+		return nil, ErrInvalidFundingSource
+	}
+	if rule.credit != (req.CreditLimit > 0) || req.CreditLimit != roundPaise(req.CreditLimit) {
+		return nil, ErrInvalidFundingSource
+	}
+
+	// In production: discover the account and its sanctioned limit with the issuer.
+	f := &model.FundingSource{
+		UserID:      oid,
+		Type:        req.Type,
+		AccountID:   secure.Indexed(accountID),
+		Issuer:      strings.TrimSpace(req.Issuer),
+		CreditLimit: req.CreditLimit,
+	}
+	if err := s.sourceRepo.Create(ctx, f); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrFundingSourceExists
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+func (s *fundingService) List(ctx context.Context, userID string) ([]model.FundingSource, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	return s.sourceRepo.FindByUserID(ctx, oid)
+}
+
+func (s *fundingService) Unlink(ctx context.Context, userID, id string) error {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return ErrUnauthorized
+	}
+	sid, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrFundingSourceNotFound
+	}
+	if err := s.sourceRepo.Unlink(ctx, oid, sid); err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+		if source, err := s.sourceRepo.FindActive(ctx, oid, sid); err == nil && source.Outstanding > 0 {
+			return ErrCreditOutstanding
+		}
+		return ErrFundingSourceNotFound
+	}
+	return nil
+}
+
+func (s *fundingService) Repay(ctx context.Context, userID, id string, req *model.RepayCreditRequest) (*model.FundingSource, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	sid, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrFundingSourceNotFound
+	}
+	if req.Amount <= 0 || req.Amount != roundPaise(req.Amount) {
+		return nil, ErrInvalidRepayment
+	}
+	// Sources unlinked before unlinking required repayment may still owe.
+	source, err := s.sourceRepo.FindByID(ctx, oid, sid)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrFundingSourceNotFound
+		}
+		return nil, err
+	}
+	if !fundingRules[source.Type].credit || req.Amount > source.Outstanding {
+		return nil, ErrInvalidRepayment
+	}
+	vpas, err := s.vpaRepo.FindByUserID(ctx, oid)
+	if err != nil || len(vpas) == 0 {
+		return nil, errors.New("no VPA found for user")
+	}
+	if err := s.pins.Verify(ctx, userID, req.PINCredential); err != nil {
+		return nil, err
+	}
+
+	// In production: the repayment is a debit through the UPI switch to the
+	// issuer before the outstanding is reduced.
+	source, err = s.sourceRepo.Repay(ctx, oid, sid, req.Amount)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrInvalidRepayment // a concurrent repayment got there first
+		}
+		return nil, err
+	}
+	vpa := defaultVPA(vpas).Address
+	txnID := fmt.Sprintf("CRP%d", time.Now().UnixNano())
+	now := time.Now()
+	note := secure.String("Credit repayment")
+	entries := []model.LedgerEntry{
+		{UserID: oid, VPA: vpa, TxnID: txnID, Direction: "debit", Amount: req.Amount, Counterparty: secure.String(source.Issuer), Note: note, PostedAt: now},
+		{UserID: oid, VPA: vpa, Account: source.Type + ":" + source.ID.Hex(), TxnID: txnID, Direction: "credit", Amount: req.Amount, Counterparty: secure.String(vpa), Note: note, PostedAt: now},
+	}
+	if err := s.ledgerRepo.Post(ctx, entries); err != nil {
+		slog.ErrorContext(ctx, "credit repayment ledger posting failed", "txn_id", txnID, "error", err)
+	}
+	return source, nil
+}
+
+func (s *fundingService) Reserve(ctx context.Context, userID bson.ObjectID, sourceID string, txn *model.UPITransaction) error {
+	sid, err := bson.ObjectIDFromHex(sourceID)
+	if err != nil {
+		return ErrFundingSourceNotFound
+	}
+	source, err := s.sourceRepo.FindActive(ctx, userID, sid)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrFundingSourceNotFound
+		}
+		return err
+	}
+
+	rule := fundingRules[source.Type]
+	if txn.PaymentType == "P2P" && !rule.p2p || txn.PaymentType == "P2M" && !rule.p2m {
+		return ErrFundingNotEligible
+	}
+	if rule.credit {
+		// In production: the issuer authorises the draw.
+		if err := s.sourceRepo.Draw(ctx, source.ID, txn.Amount); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return ErrCreditLimitExceeded
+			}
+			return err
+		}
+	}
+	txn.FundingSourceID = &source.ID
+	txn.FundingType = source.Type
+	return nil
+}
+
+func (s *fundingService) Release(ctx context.Context, txn *model.UPITransaction) {
+	if txn.FundingSourceID == nil || !fundingRules[txn.FundingType].credit {
+		return
+	}
+	if err := s.sourceRepo.Release(ctx, *txn.FundingSourceID, txn.Amount); err != nil {
+		slog.ErrorContext(ctx, "releasing credit failed", "amount", txn.Amount, "error", err)
+	}
+}
+
+// fundingAccount names the ledger account a payment is drawn from: empty for
+// the VPA's account, lite for UPI Lite, otherwise type:id of the source.
+func fundingAccount(txn *model.UPITransaction) string {
+	if txn.FundingSourceID != nil {
+		return txn.FundingType + ":" + txn.FundingSourceID.Hex()
+	}
+	return txn.Channel
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/repository"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	ErrInvalidCategory  = errors.New("unknown category")
+	ErrOverrideNotFound = errors.New("category override not found")
+	ErrInvalidRange     = errors.New("from must be before to and at most two years earlier")
+	ErrInvalidInterval  = errors.New("interval must be day, week or month")
+)
+
+const (
+	defaultInsightMonths = 6
+	maxInsightRange      = 2 * 366 * 24 * time.Hour
+	defaultTopPayees     = 10
+	maxTopPayees         = 50
+)
+
+// cashFlowPeriods maps an interval to its $dateToString bucket format.
+var cashFlowPeriods = map[string]string{
+	"day":   "%Y-%m-%d",
+	"week":  "%G-W%V",
+	"month": "%Y-%m",
+}
+
+type InsightsService interface {
+	SpendByCategory(ctx context.Context, userID string, from, to *time.Time) ([]model.CategorySpend, error)
+	TopPayees(ctx context.Context, userID string, from, to *time.Time, limit int64) ([]model.PayeeSpend, error)
+	CashFlow(ctx context.Context, userID string, from, to *time.Time, interval string) ([]model.CashFlowPoint, error)
+	GetOverrides(ctx context.Context, userID string) ([]model.CategoryOverride, error)
+	SetOverride(ctx context.Context, userID string, req *model.SetCategoryOverrideRequest) (*model.CategoryOverride, error)
+	DeleteOverride(ctx context.Context, userID, payeeVPA string) error
+}
+
+type insightsService struct {
+	vpaRepo      repository.VPARepo
+	txnRepo      repository.UPITransactionRepo
+	overrideRepo repository.CategoryOverrideRepo
+}
+
+func NewInsightsService(vr repository.VPARepo, tr repository.UPITransactionRepo, or repository.CategoryOverrideRepo) InsightsService {
+	return &insightsService{vpaRepo: vr, txnRepo: tr, overrideRepo: or}
+}
+
+func (s *insightsService) SpendByCategory(ctx context.Context, userID string, from, to *time.Time) ([]model.CategorySpend, error) {
+	oid, vpas, start, end, err := s.scope(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	spend, err := s.txnRepo.SpendByCategory(ctx, oid, vpas, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for i := range spend {
+		spend[i].Amount = roundPaise(spend[i].Amount)
+	}
+	return spend, nil
+}
+
+func (s *insightsService) TopPayees(ctx context.Context, userID string, from, to *time.Time, limit int64) ([]model.PayeeSpend, error) {
+	if limit < 1 || limit > maxTopPayees {
+		limit = defaultTopPayees
+	}
+	oid, vpas, start, end, err := s.scope(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	payees, err := s.txnRepo.TopPayees(ctx, oid, vpas, start, end, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range payees {
+		payees[i].Amount = roundPaise(payees[i].Amount)
+	}
+	return payees, nil
+}
+
+func (s *insightsService) CashFlow(ctx context.Context, userID string, from, to *time.Time, interval string) ([]model.CashFlowPoint, error) {
+	if interval == "" {
+		interval = "month"
+	}
+	period, ok := cashFlowPeriods[interval]
+	if !ok {
+		return nil, ErrInvalidInterval
+	}
+	oid, vpas, start, end, err := s.scope(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	points, err := s.txnRepo.CashFlow(ctx, oid, vpas, start, end, period)
+	if err != nil {
+		return nil, err
+	}
+	for i := range points {
+		points[i].Inflow = roundPaise(points[i].Inflow)
+		points[i].Outflow = roundPaise(points[i].Outflow)
+		points[i].Net = roundPaise(points[i].Inflow - points[i].Outflow)
+	}
+	return points, nil
+}
+
+func (s *insightsService) GetOverrides(ctx context.Context, userID string) ([]model.CategoryOverride, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	return s.overrideRepo.FindByUserID(ctx, oid)
+}
+
+func (s *insightsService) SetOverride(ctx context.Context, userID string, req *model.SetCategoryOverrideRequest) (*model.CategoryOverride, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	payee := strings.ToLower(strings.TrimSpace(req.PayeeVPA))
+	if !vpaPattern.MatchString(payee) {
+		return nil, ErrVPANotFound
+	}
+	if !validCategory(req.Category) {
+		return nil, ErrInvalidCategory
+	}
+
+	o := &model.CategoryOverride{UserID: oid, PayeeVPA: payee, Category: req.Category}
+	if err := s.overrideRepo.Upsert(ctx, o); err != nil {
+		return nil, err
+	}
+	if req.ApplyToPast {
+		if _, err := s.txnRepo.SetCategory(ctx, oid, payee, req.Category); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+// DeleteOverride stops pinning future payments; past payments keep their
+// category.
+func (s *insightsService) DeleteOverride(ctx context.Context, userID, payeeVPA string) error {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return ErrUnauthorized
+	}
+	err = s.overrideRepo.Delete(ctx, oid, strings.ToLower(strings.TrimSpace(payeeVPA)))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrOverrideNotFound
+	}
+	return err
+}
+
+// scope resolves the user, their VPAs and the [start, end) range, which
+// defaults to the current IST month and the five before it.
+func (s *insightsService) scope(ctx context.Context, userID string, from, to *time.Time) (bson.ObjectID, []string, time.Time, time.Time, error) {
+	var start, end time.Time
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return oid, nil, start, end, ErrUnauthorized
+	}
+
+	end = time.Now()
+	if to != nil {
+		end = *to
+	}
+	if from != nil {
+		start = *from
+	} else {
+		e := end.In(ist)
+		start = time.Date(e.Year(), e.Month()-defaultInsightMonths+1, 1, 0, 0, 0, 0, ist)
+	}
+	if !start.Before(end) || end.Sub(start) > maxInsightRange {
+		return oid, nil, start, end, ErrInvalidRange
+	}
+
+	vpas, err := s.vpaRepo.FindByUserID(ctx, oid)
+	if err != nil {
+		return oid, nil, start, end, err
+	}
+	addresses := make([]string, 0, len(vpas))
+	for _, v := range vpas {
+		addresses = append(addresses, v.Address)
+	}
+	return oid, addresses, start, end, nil
+}
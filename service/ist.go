@@ -0,0 +1,36 @@
+package service
+
+import "time"
+
+// ist is India Standard Time, the zone settlement days and schedules are
+// expressed in regardless of where the service runs.
+var ist = time.FixedZone("IST", 5*60*60+30*60)
+
+const dateLayout = "2006-01-02"
+
+// istDay returns the [start, end) bounds of the IST calendar day of t.
+func istDay(t time.Time) (time.Time, time.Time) {
+	t = t.In(ist)
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, ist)
+	return start, start.AddDate(0, 0, 1)
+}
+
+// istMonth returns the [start, end) bounds of the IST calendar month of t.
+func istMonth(t time.Time) (time.Time, time.Time) {
+	t = t.In(ist)
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, ist)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// ParseDate reads a YYYY-MM-DD date as midnight IST.
+func ParseDate(v string) (time.Time, error) {
+	return time.ParseInLocation(dateLayout, v, ist)
+}
+
+// ParseDateTime accepts an RFC 3339 timestamp or a date as read by ParseDate.
+func ParseDateTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return ParseDate(v)
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/repository"
+	"github.com/banking-superapp/upi-service/secure"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	ErrLiteNotEnabled      = errors.New("UPI Lite is not enabled")
+	ErrLiteEnabled         = errors.New("UPI Lite is already enabled")
+	ErrLiteBalanceCap      = errors.New("top-up would exceed the UPI Lite balance cap")
+	ErrLiteInsufficient    = errors.New("insufficient UPI Lite balance")
+	ErrLiteTxnLimit        = errors.New("amount exceeds the UPI Lite per transaction limit")
+	ErrInvalidLiteTopUp    = errors.New("top-up amount must be positive with at most 2 decimals")
+	ErrInvalidLiteAutoRule = errors.New("auto top-up needs a threshold below the cap and a positive amount")
+)
+
+// NPCI UPI Lite caps.
+const (
+	liteTxnLimit      = 1000
+	liteBalanceCap    = 5000
+	liteAccount       = "lite"
+	liteAutoTopUpAuth = 365 * 24 * time.Hour
+)
+
+// LiteService manages UPI Lite wallets. Debits are authorised on the device
+// without a UPI PIN; top-ups move money from the linked bank account and
+// need one.
+type LiteService interface {
+	Enable(ctx context.Context, userID string) (*model.LiteWallet, error)
+	GetWallet(ctx context.Context, userID string) (*model.LiteWallet, error)
+	TopUp(ctx context.Context, userID string, req *model.LiteTopUpRequest) (*model.LiteWallet, error)
+	SetAutoTopUp(ctx context.Context, userID string, req *model.LiteAutoTopUpRequest) (*model.LiteWallet, error)
+	DisableAutoTopUp(ctx context.Context, userID string) (*model.LiteWallet, error)
+	// Reconcile compares every wallet with its ledger postings.
+	Reconcile(ctx context.Context) ([]model.LiteMismatch, error)
+	// CheckBalances logs the mismatches Reconcile finds.
+	CheckBalances(ctx context.Context) error
+
+	// Debit and Refund back Lite payments made through UPIService.Pay.
+	Debit(ctx context.Context, userID bson.ObjectID, amount float64) error
+	Refund(ctx context.Context, userID bson.ObjectID, amount float64) error
+	// AutoTopUp applies the user's rule after a debit.
+	AutoTopUp(ctx context.Context, userID bson.ObjectID) error
+}
+
+type liteService struct {
+	vpaRepo    repository.VPARepo
+	walletRepo repository.LiteWalletRepo
+	ledgerRepo repository.LedgerRepo
+	pins       PINVerifier
+	preauth    *PreAuthorizer
+}
+
+func NewLiteService(vr repository.VPARepo, wr repository.LiteWalletRepo, lr repository.LedgerRepo, pins PINVerifier, preauth *PreAuthorizer) LiteService {
+	return &liteService{vpaRepo: vr, walletRepo: wr, ledgerRepo: lr, pins: pins, preauth: preauth}
+}
+
+func (s *liteService) Enable(ctx context.Context, userID string) (*model.LiteWallet, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	vpas, err := s.vpaRepo.FindByUserID(ctx, oid)
+	if err != nil || len(vpas) == 0 {
+		return nil, errors.New("no VPA found for user")
+	}
+	w := &model.LiteWallet{UserID: oid, VPA: defaultVPA(vpas).Address}
+	if err := s.walletRepo.Create(ctx, w); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrLiteEnabled
+		}
+		return nil, err
+	}
+	return w, nil
+}
+
+func (s *liteService) GetWallet(ctx context.Context, userID string) (*model.LiteWallet, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	return s.wallet(ctx, oid)
+}
+
+func (s *liteService) TopUp(ctx context.Context, userID string, req *model.LiteTopUpRequest) (*model.LiteWallet, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	if req.Amount <= 0 || req.Amount != roundPaise(req.Amount) {
+		return nil, ErrInvalidLiteTopUp
+	}
+	if _, err := s.wallet(ctx, oid); err != nil {
+		return nil, err
+	}
+	if err := s.pins.Verify(ctx, userID, req.PINCredential); err != nil {
+		return nil, err
+	}
+	return s.topUp(ctx, oid, req.Amount, "UPI Lite top-up")
+}
+
+func (s *liteService) SetAutoTopUp(ctx context.Context, userID string, req *model.LiteAutoTopUpRequest) (*model.LiteWallet, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	if req.Amount <= 0 || req.Amount != roundPaise(req.Amount) || req.Threshold < 0 ||
+		req.Threshold >= liteBalanceCap || req.Amount > liteBalanceCap {
+		return nil, ErrInvalidLiteAutoRule
+	}
+	w, err := s.wallet(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.pins.Verify(ctx, userID, req.PINCredential); err != nil {
+		return nil, err
+	}
+
+	rule := &model.LiteAutoTopUp{
+		Threshold:    req.Threshold,
+		Amount:       req.Amount,
+		PreAuthUntil: time.Now().Add(liteAutoTopUpAuth),
+	}
+	rule.PreAuthToken, err = s.preauth.Issue(PreAuthClaims{
+		Subject:   w.ID.Hex(),
+		UserID:    userID,
+		ToVPA:     w.VPA,
+		MaxAmount: toPaise(req.Amount),
+		ExpiresAt: rule.PreAuthUntil.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.walletRepo.SetAutoTopUp(ctx, oid, rule)
+}
+
+func (s *liteService) DisableAutoTopUp(ctx context.Context, userID string) (*model.LiteWallet, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	w, err := s.walletRepo.SetAutoTopUp(ctx, oid, nil)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrLiteNotEnabled
+	}
+	return w, err
+}
+
+func (s *liteService) Debit(ctx context.Context, userID bson.ObjectID, amount float64) error {
+	if amount > liteTxnLimit {
+		return ErrLiteTxnLimit
+	}
+	if _, err := s.walletRepo.Debit(ctx, userID, amount); err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+		if _, err := s.wallet(ctx, userID); err != nil {
+			return err
+		}
+		return ErrLiteInsufficient
+	}
+	return nil
+}
+
+// Refund returns a debit whose payment was not recorded. The cap does not
+// apply since the money was in the wallet moments ago.
+func (s *liteService) Refund(ctx context.Context, userID bson.ObjectID, amount float64) error {
+	_, err := s.walletRepo.Credit(ctx, userID, amount, liteBalanceCap+amount)
+	return err
+}
+
+func (s *liteService) AutoTopUp(ctx context.Context, userID bson.ObjectID) error {
+	w, err := s.wallet(ctx, userID)
+	if err != nil || w.AutoTopUp == nil || w.Balance >= w.AutoTopUp.Threshold {
+		return err
+	}
+	amount := min(w.AutoTopUp.Amount, roundPaise(liteBalanceCap-w.Balance))
+	if amount <= 0 {
+		return nil
+	}
+	claims := PreAuthClaims{Subject: w.ID.Hex(), UserID: userID.Hex(), ToVPA: w.VPA}
+	if err := s.preauth.Verify(w.AutoTopUp.PreAuthToken, claims, toPaise(amount)); err != nil {
+		return err
+	}
+	_, err = s.topUp(ctx, userID, amount, "UPI Lite auto top-up")
+	if errors.Is(err, ErrLiteBalanceCap) {
+		return nil // a concurrent top-up got there first
+	}
+	return err
+}
+
+func (s *liteService) Reconcile(ctx context.Context) ([]model.LiteMismatch, error) {
+	wallets, err := s.walletRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var mismatches []model.LiteMismatch
+	for _, w := range wallets {
+		ledger, err := s.ledgerRepo.AccountBalance(ctx, w.UserID, liteAccount)
+		if err != nil {
+			return nil, err
+		}
+		if diff := roundPaise(w.Balance - ledger); diff != 0 {
+			mismatches = append(mismatches, model.LiteMismatch{
+				UserID:        w.UserID,
+				WalletBalance: roundPaise(w.Balance),
+				LedgerBalance: roundPaise(ledger),
+				Difference:    diff,
+			})
+		}
+	}
+	return mismatches, nil
+}
+
+func (s *liteService) CheckBalances(ctx context.Context) error {
+	mismatches, err := s.Reconcile(ctx)
+	if err != nil {
+		return err
+	}
+	for _, m := range mismatches {
+		slog.WarnContext(ctx, "lite wallet disagrees with ledger", "user_id", m.UserID.Hex(),
+			"wallet_balance", m.WalletBalance, "ledger_balance", m.LedgerBalance)
+	}
+	return nil
+}
+
+// topUp moves amount from the bank account into the wallet and posts both
+// legs to the ledger. In production: the bank leg is a debit through the
+// UPI switch before the wallet is credited.
+func (s *liteService) topUp(ctx context.Context, userID bson.ObjectID, amount float64, note string) (*model.LiteWallet, error) {
+	w, err := s.walletRepo.Credit(ctx, userID, amount, liteBalanceCap)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrLiteBalanceCap
+		}
+		return nil, err
+	}
+	txnID := fmt.Sprintf("LTU%d", time.Now().UnixNano())
+	now := time.Now()
+	entries := []model.LedgerEntry{
+		{UserID: userID, VPA: w.VPA, TxnID: txnID, Direction: "debit", Amount: amount, Counterparty: secure.String(w.VPA), Note: secure.String(note), PostedAt: now},
+		{UserID: userID, VPA: w.VPA, Account: liteAccount, TxnID: txnID, Direction: "credit", Amount: amount, Counterparty: secure.String(w.VPA), Note: secure.String(note), PostedAt: now},
+	}
+	if err := s.ledgerRepo.Post(ctx, entries); err != nil {
+		slog.ErrorContext(ctx, "lite top-up ledger posting failed", "txn_id", txnID, "error", err)
+	}
+	return w, nil
+}
+
+func (s *liteService) wallet(ctx context.Context, userID bson.ObjectID) (*model.LiteWallet, error) {
+	w, err := s.walletRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrLiteNotEnabled
+		}
+		return nil, err
+	}
+	return w, nil
+}
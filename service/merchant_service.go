@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/repository"
+	"github.com/banking-superapp/upi-service/secure"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	ErrMerchantNotFound = errors.New("merchant not found")
+	ErrInvalidMerchant  = errors.New("legal_name, 4 digit mcc, settlement_account and handle are required")
+	ErrLimitExceeded    = errors.New("transaction limit exceeded")
+	ErrInvalidDate      = errors.New("date must be in YYYY-MM-DD format")
+)
+
+// NPCI per-transaction and daily caps. Merchant specific limits set at
+// verification override the verified tier defaults; zero means uncapped.
+const (
+	p2pTxnLimit                  = 100000
+	unverifiedMerchantTxnLimit   = 2000
+	unverifiedMerchantDailyLimit = 20000
+	verifiedMerchantTxnLimit     = 200000
+)
+
+var (
+	mccPattern    = regexp.MustCompile(`^\d{4}$`)
+	handlePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9.\-]{2,48}$`)
+)
+
+type MerchantService interface {
+	OnboardMerchant(ctx context.Context, userID string, req *model.OnboardMerchantRequest) (*model.Merchant, error)
+	GetMerchants(ctx context.Context, userID string) ([]model.Merchant, error)
+	VerifyMerchant(ctx context.Context, merchantID string, req *model.VerifyMerchantRequest) (*model.Merchant, error)
+	GenerateSettlement(ctx context.Context, userID, merchantID, date string) (*model.MerchantSettlement, error)
+	GetSettlements(ctx context.Context, userID, merchantID string) ([]model.MerchantSettlement, error)
+}
+
+type merchantService struct {
+	vpaRepo        repository.VPARepo
+	txnRepo        repository.UPITransactionRepo
+	merchantRepo   repository.MerchantRepo
+	settlementRepo repository.SettlementRepo
+	audit          AuditService
+}
+
+func NewMerchantService(vr repository.VPARepo, tr repository.UPITransactionRepo, mr repository.MerchantRepo, sr repository.SettlementRepo, as AuditService) MerchantService {
+	return &merchantService{vpaRepo: vr, txnRepo: tr, merchantRepo: mr, settlementRepo: sr, audit: as}
+}
+
+func (s *merchantService) OnboardMerchant(ctx context.Context, userID string, req *model.OnboardMerchantRequest) (*model.Merchant, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+
+	handle := strings.ToLower(strings.TrimSpace(req.Handle))
+	if strings.TrimSpace(req.LegalName) == "" || !mccPattern.MatchString(req.MCC) ||
+		req.SettlementAccount == "" || !handlePattern.MatchString(handle) {
+		return nil, ErrInvalidMerchant
+	}
+
+	vpa := &model.VPA{
+		UserID:    oid,
+		Address:   handle + bankSuffix,
+		AccountID: secure.Indexed(req.SettlementAccount),
+		IsActive:  true,
+	}
+	if err := s.vpaRepo.Create(ctx, vpa); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrVPAExists
+		}
+		return nil, err
+	}
+	s.audit.Record(ctx, "vpa.create", "vpa", vpa.Address, nil, vpa)
+
+	merchant := &model.Merchant{
+		UserID:            oid,
+		LegalName:         secure.String(strings.TrimSpace(req.LegalName)),
+		MCC:               req.MCC,
+		SettlementAccount: secure.Indexed(req.SettlementAccount),
+		VPA:               vpa.Address,
+		IsActive:          true,
+	}
+	if err := s.merchantRepo.Create(ctx, merchant); err != nil {
+		if s.vpaRepo.Deactivate(ctx, vpa.Address) == nil {
+			after := *vpa
+			after.IsActive = false
+			s.audit.Record(ctx, "vpa.deactivate", "vpa", vpa.Address, vpa, &after)
+		}
+		return nil, err
+	}
+	return merchant, nil
+}
+
+func (s *merchantService) GetMerchants(ctx context.Context, userID string) ([]model.Merchant, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	return s.merchantRepo.FindByUserID(ctx, oid)
+}
+
+func (s *merchantService) VerifyMerchant(ctx context.Context, merchantID string, req *model.VerifyMerchantRequest) (*model.Merchant, error) {
+	mid, err := bson.ObjectIDFromHex(merchantID)
+	if err != nil {
+		return nil, ErrMerchantNotFound
+	}
+	if req.TxnLimit < 0 || req.DailyLimit < 0 || req.MDRBps < 0 || req.MDRBps > 10000 {
+		return nil, ErrInvalidMerchant
+	}
+	merchant, err := s.merchantRepo.Verify(ctx, mid, req)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrMerchantNotFound
+		}
+		return nil, err
+	}
+	return merchant, nil
+}
+
+// GenerateSettlement computes and stores the merchant's settlement summary for
+// an IST day, defaulting to yesterday. Regenerating a day overwrites the
+// previous summary.
+func (s *merchantService) GenerateSettlement(ctx context.Context, userID, merchantID, date string) (*model.MerchantSettlement, error) {
+	merchant, err := s.ownedMerchant(ctx, userID, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if date == "" {
+		date = time.Now().In(ist).AddDate(0, 0, -1).Format(dateLayout)
+	}
+
+	day, err := time.ParseInLocation(dateLayout, date, ist)
+	if err != nil {
+		return nil, ErrInvalidDate
+	}
+	from, to := istDay(day)
+
+	settlement, err := s.txnRepo.MerchantTotals(ctx, merchant.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	settlement.VPA = merchant.VPA
+	settlement.SettlementAccount = merchant.SettlementAccount
+	settlement.Date = date
+	settlement.GrossAmount = roundPaise(settlement.GrossAmount)
+	settlement.MDRFee = roundPaise(settlement.MDRFee)
+	settlement.NetAmount = roundPaise(settlement.GrossAmount - settlement.MDRFee)
+
+	if err := s.settlementRepo.Upsert(ctx, settlement); err != nil {
+		return nil, err
+	}
+	return settlement, nil
+}
+
+func (s *merchantService) GetSettlements(ctx context.Context, userID, merchantID string) ([]model.MerchantSettlement, error) {
+	merchant, err := s.ownedMerchant(ctx, userID, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	return s.settlementRepo.FindByMerchant(ctx, merchant.ID, 90)
+}
+
+func (s *merchantService) ownedMerchant(ctx context.Context, userID, merchantID string) (*model.Merchant, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	mid, err := bson.ObjectIDFromHex(merchantID)
+	if err != nil {
+		return nil, ErrMerchantNotFound
+	}
+	merchant, err := s.merchantRepo.FindByID(ctx, mid)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrMerchantNotFound
+		}
+		return nil, err
+	}
+	if merchant.UserID != oid {
+		return nil, ErrMerchantNotFound
+	}
+	return merchant, nil
+}
+
+// classifyPayment tags txn as P2P or P2M based on whether the payee is one of
+// our merchants, enforces the applicable limits and computes the MDR fee. A
+// P2M payment is reserved against the merchant's volume for the day; callers
+// undo that with releaseMerchantVolume if the payment does not go through.
+func classifyPayment(ctx context.Context, mr repository.MerchantRepo, txn *model.UPITransaction) (*model.Merchant, error) {
+	merchant, err := mr.FindByVPA(ctx, txn.ToVPA)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			txn.PaymentType = "P2P"
+			if txn.Amount > p2pTxnLimit {
+				return nil, ErrLimitExceeded
+			}
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	txnLimit, dailyLimit := float64(unverifiedMerchantTxnLimit), float64(unverifiedMerchantDailyLimit)
+	if merchant.Verified {
+		txnLimit, dailyLimit = verifiedMerchantTxnLimit, 0
+		if merchant.TxnLimit > 0 {
+			txnLimit = merchant.TxnLimit
+		}
+		if merchant.DailyLimit > 0 {
+			dailyLimit = merchant.DailyLimit
+		}
+	}
+	if txn.Amount > txnLimit {
+		return nil, ErrLimitExceeded
+	}
+	date := txn.TransactionDate.In(ist).Format(dateLayout)
+	if err := mr.ReserveDaily(ctx, merchant.ID, date, txn.Amount, dailyLimit); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrLimitExceeded
+		}
+		return nil, err
+	}
+
+	txn.PaymentType = "P2M"
+	txn.MerchantID = &merchant.ID
+	txn.MCC = merchant.MCC
+	txn.MDRFee = roundPaise(txn.Amount * float64(merchant.MDRBps) / 10000)
+	return merchant, nil
+}
+
+// releaseMerchantVolume gives back what classifyPayment reserved for a P2M
+// payment that was not made or failed.
+func releaseMerchantVolume(ctx context.Context, mr repository.MerchantRepo, txn *model.UPITransaction) {
+	if txn.MerchantID == nil {
+		return
+	}
+	date := txn.TransactionDate.In(ist).Format(dateLayout)
+	if err := mr.ReleaseDaily(ctx, *txn.MerchantID, date, txn.Amount); err != nil {
+		slog.ErrorContext(ctx, "releasing merchant daily volume failed", "amount", txn.Amount, "error", err)
+	}
+}
+
+func roundPaise(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
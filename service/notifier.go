@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/banking-superapp/upi-service/model"
+)
+
+// Notifier delivers notifications to users. In production this fans out to
+// the notification service; logNotifier is used until that is wired in.
+type Notifier interface {
+	Notify(ctx context.Context, n *model.Notification) error
+}
+
+type logNotifier struct{}
+
+func NewLogNotifier() Notifier { return logNotifier{} }
+
+func (logNotifier) Notify(ctx context.Context, n *model.Notification) error {
+	slog.InfoContext(ctx, "notify", "recipient", n.Recipient, "type", n.Type, "title", n.Title, "body", n.Body)
+	return nil
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/repository"
+	"github.com/banking-superapp/upi-service/secure"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	ErrTxnNotFound       = errors.New("transaction not found")
+	ErrTxnNotPending     = errors.New("transaction is not pending")
+	ErrInvalidResolution = errors.New("resolution must be success or failed")
+	ErrMandateNotFound   = errors.New("mandate not found")
+	ErrMandateNotActive  = errors.New("mandate is not active or paused")
+)
+
+// OpsService backs operator tooling. It trusts its caller to have checked
+// who is asking; changes land in the audit trail under the context's actor.
+type OpsService interface {
+	// FindTransaction looks ref up as a txn ID, then as an RRN.
+	FindTransaction(ctx context.Context, ref string) (*model.UPITransaction, []model.LedgerEntry, error)
+	// ResolveTransaction settles a pending transaction. Failing one reverses
+	// its ledger postings and returns the money to where it came from.
+	ResolveTransaction(ctx context.Context, txnID, status, reason string) (*model.UPITransaction, error)
+	DeactivateVPA(ctx context.Context, address string) error
+	// FindVPAsByAccount finds VPAs by their encrypted account ID.
+	FindVPAsByAccount(ctx context.Context, accountID string) ([]model.VPA, error)
+	RevokeMandate(ctx context.Context, mandateID string) (*model.Mandate, error)
+	ReconcileLite(ctx context.Context) ([]model.LiteMismatch, error)
+	ExportTransactions(ctx context.Context, userID string, from, to time.Time) ([]model.UPITransaction, error)
+	ExportLedger(ctx context.Context, userID string, from, to time.Time) ([]model.LedgerEntry, error)
+}
+
+type opsService struct {
+	vpaRepo      repository.VPARepo
+	txnRepo      repository.UPITransactionRepo
+	mandateRepo  repository.MandateRepo
+	merchantRepo repository.MerchantRepo
+	ledgerRepo   repository.LedgerRepo
+	lite         LiteService
+	funding      FundingService
+	audit        AuditService
+}
+
+func NewOpsService(vr repository.VPARepo, tr repository.UPITransactionRepo, mr repository.MandateRepo, mer repository.MerchantRepo, lr repository.LedgerRepo, ls LiteService, fs FundingService, as AuditService) OpsService {
+	return &opsService{vpaRepo: vr, txnRepo: tr, mandateRepo: mr, merchantRepo: mer, ledgerRepo: lr, lite: ls, funding: fs, audit: as}
+}
+
+func (s *opsService) FindTransaction(ctx context.Context, ref string) (*model.UPITransaction, []model.LedgerEntry, error) {
+	txn, err := s.txnRepo.FindByTxnID(ctx, ref)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		txn, err = s.txnRepo.FindByRRN(ctx, ref)
+	}
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil, ErrTxnNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	entries, err := s.ledgerRepo.FindByTxnID(ctx, txn.TxnID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return txn, entries, nil
+}
+
+func (s *opsService) ResolveTransaction(ctx context.Context, txnID, status, reason string) (*model.UPITransaction, error) {
+	if status != "success" && status != "failed" {
+		return nil, ErrInvalidResolution
+	}
+	before, err := s.txnRepo.FindByTxnID(ctx, txnID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrTxnNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	txn, err := s.txnRepo.Resolve(ctx, txnID, status, reason)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrTxnNotPending
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, "txn.resolve", "transaction", txnID, before, txn)
+	if status == "failed" {
+		if err := s.reverse(ctx, txn); err != nil {
+			return txn, err
+		}
+	}
+	return txn, nil
+}
+
+// reverse undoes what Pay did for a transaction that did not go through.
+func (s *opsService) reverse(ctx context.Context, txn *model.UPITransaction) error {
+	entries, err := s.ledgerRepo.FindByTxnID(ctx, txn.TxnID)
+	if err != nil {
+		return err
+	}
+	reversals := make([]model.LedgerEntry, 0, len(entries))
+	for _, e := range entries {
+		e.ID = bson.ObjectID{}
+		e.TxnID = "REV" + txn.TxnID
+		e.Direction = map[string]string{"debit": "credit", "credit": "debit"}[e.Direction]
+		e.Note = secure.String("Reversal of " + txn.TxnID)
+		e.PostedAt = time.Now()
+		reversals = append(reversals, e)
+	}
+	if len(reversals) > 0 {
+		if err := s.ledgerRepo.Post(ctx, reversals); err != nil {
+			return err
+		}
+	}
+	if txn.Channel == liteAccount {
+		if err := s.lite.Refund(ctx, txn.UserID, txn.Amount); err != nil {
+			return err
+		}
+	}
+	s.funding.Release(ctx, txn)
+	releaseMerchantVolume(ctx, s.merchantRepo, txn)
+	slog.InfoContext(ctx, "transaction reversed", "txn_id", txn.TxnID, "entries", len(reversals))
+	return nil
+}
+
+func (s *opsService) DeactivateVPA(ctx context.Context, address string) error {
+	before, err := s.vpaRepo.FindByAddress(ctx, address)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrVPANotFound
+		}
+		return err
+	}
+	if err := s.vpaRepo.Deactivate(ctx, address); err != nil {
+		return err
+	}
+	after := *before
+	after.IsActive = false
+	s.audit.Record(ctx, "vpa.deactivate", "vpa", address, before, &after)
+	return nil
+}
+
+func (s *opsService) FindVPAsByAccount(ctx context.Context, accountID string) ([]model.VPA, error) {
+	return s.vpaRepo.FindByAccountID(ctx, strings.TrimSpace(accountID))
+}
+
+func (s *opsService) RevokeMandate(ctx context.Context, mandateID string) (*model.Mandate, error) {
+	before, err := s.mandateRepo.FindByMandateID(ctx, mandateID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrMandateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	m, err := s.mandateRepo.Transition(ctx, mandateID, []string{"active", "paused"}, "revoked")
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrMandateNotActive
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, "mandate.revoke", "mandate", mandateID, before, m)
+	return m, nil
+}
+
+func (s *opsService) ReconcileLite(ctx context.Context) ([]model.LiteMismatch, error) {
+	return s.lite.Reconcile(ctx)
+}
+
+func (s *opsService) ExportTransactions(ctx context.Context, userID string, from, to time.Time) ([]model.UPITransaction, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	vpas, err := s.vpaRepo.FindByUserID(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+	addresses := make([]string, len(vpas))
+	for i, v := range vpas {
+		addresses[i] = v.Address
+	}
+	return s.txnRepo.FindByUserID(ctx, oid, addresses, &model.TxnFilter{From: &from, To: &to}, nil, 0, 0)
+}
+
+func (s *opsService) ExportLedger(ctx context.Context, userID string, from, to time.Time) ([]model.LedgerEntry, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	return s.ledgerRepo.FindRange(ctx, oid, from, to)
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/repository"
+	"github.com/banking-superapp/upi-service/secure"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	ErrOrderNotFound  = errors.New("merchant order not found")
+	ErrOrderExists    = errors.New("merchant order already exists")
+	ErrOrderExpired   = errors.New("merchant order has expired")
+	ErrOrderPaid      = errors.New("merchant order is already paid")
+	ErrAmountMismatch = errors.New("amount does not match the order amount")
+	ErrInvalidOrder   = errors.New("order_id is required and callback_url must be an https URL on a public host")
+)
+
+const (
+	defaultOrderExpiry = 15 * time.Minute
+	maxOrderExpiry     = 24 * time.Hour
+)
+
+type OrderService interface {
+	CreateOrder(ctx context.Context, userID string, req *model.CreateOrderRequest) (*model.OrderQRResponse, error)
+	GetOrder(ctx context.Context, userID, orderID string) (*model.MerchantOrder, error)
+	// CallbackKey returns the HMAC key the merchant checks the
+	// X-Callback-Signature of its order callbacks with.
+	CallbackKey(ctx context.Context, userID string) (*model.CallbackKeyResponse, error)
+}
+
+type orderService struct {
+	vpaRepo      repository.VPARepo
+	orderRepo    repository.MerchantOrderRepo
+	merchantRepo repository.MerchantRepo
+	signer       *QRSigner
+	webhooks     WebhookSender
+}
+
+func NewOrderService(vr repository.VPARepo, or repository.MerchantOrderRepo, mr repository.MerchantRepo, signer *QRSigner, wh WebhookSender) OrderService {
+	return &orderService{vpaRepo: vr, orderRepo: or, merchantRepo: mr, signer: signer, webhooks: wh}
+}
+
+func (s *orderService) CreateOrder(ctx context.Context, userID string, req *model.CreateOrderRequest) (*model.OrderQRResponse, error) {
+	if req.Amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	if req.OrderID == "" || !validCallbackURL(req.CallbackURL) {
+		return nil, ErrInvalidOrder
+	}
+
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+
+	vpas, err := s.vpaRepo.FindByUserID(ctx, oid)
+	if err != nil || len(vpas) == 0 {
+		return nil, errors.New("no VPA found for user")
+	}
+	payee := defaultVPA(vpas).Address
+	if req.VPA != "" {
+		payee = ""
+		for _, v := range vpas {
+			if strings.EqualFold(v.Address, req.VPA) {
+				payee = v.Address
+				break
+			}
+		}
+		if payee == "" {
+			return nil, ErrVPANotFound
+		}
+	}
+
+	expiresIn := defaultOrderExpiry
+	if req.ExpiresIn > 0 {
+		expiresIn = min(time.Duration(req.ExpiresIn)*time.Second, maxOrderExpiry)
+	}
+
+	order := &model.MerchantOrder{
+		UserID:      oid,
+		OrderID:     req.OrderID,
+		TxnRef:      generateTxnRef(),
+		PayeeVPA:    payee,
+		Amount:      req.Amount,
+		Note:        secure.String(req.Note),
+		CallbackURL: req.CallbackURL,
+		Status:      "created",
+		ExpiresAt:   time.Now().Add(expiresIn),
+	}
+	if err := s.orderRepo.Create(ctx, order); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrOrderExists
+		}
+		return nil, err
+	}
+
+	intent := &UPIIntent{
+		PayeeVPA:     payee,
+		PayeeName:    verifiedName,
+		MerchantCode: merchantCode(ctx, s.merchantRepo, payee),
+		TxnRef:       order.TxnRef,
+		Note:         string(order.Note),
+		Amount:       order.Amount,
+	}
+	if s.signer.CanSign() {
+		if intent.Sign, err = s.signer.Sign(intent.String()); err != nil {
+			return nil, err
+		}
+	}
+	qr, err := renderQR(intent)
+	if err != nil {
+		return nil, err
+	}
+	return &model.OrderQRResponse{Order: order, QR: qr}, nil
+}
+
+func (s *orderService) GetOrder(ctx context.Context, userID, orderID string) (*model.MerchantOrder, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	order, err := s.orderRepo.FindByOrderID(ctx, oid, orderID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+	if order.Status == "created" && time.Now().After(order.ExpiresAt) {
+		order.Status = "expired"
+	}
+	return order, nil
+}
+
+// claimOrder marks the order referenced by a payment as paid. It returns a nil
+// order when txnRef does not belong to an order of ours and the payee is
+// another bank's VPA, in which case the reference is only passed through.
+func claimOrder(ctx context.Context, repo repository.MerchantOrderRepo, req *model.UPIPayRequest, txnID string) (*model.MerchantOrder, error) {
+	order, err := repo.FindByTxnRef(ctx, req.TxnRef)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			if strings.HasSuffix(strings.ToLower(req.ToVPA), bankSuffix) {
+				return nil, ErrOrderNotFound
+			}
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if !strings.EqualFold(order.PayeeVPA, req.ToVPA) {
+		return nil, ErrOrderNotFound
+	}
+	if toPaise(order.Amount) != toPaise(req.Amount) {
+		return nil, ErrAmountMismatch
+	}
+	if order.Status == "paid" {
+		return nil, ErrOrderPaid
+	}
+	if time.Now().After(order.ExpiresAt) {
+		return nil, ErrOrderExpired
+	}
+
+	claimed, err := repo.MarkPaid(ctx, req.TxnRef, txnID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// Lost a race with a concurrent payment of the same order.
+			return nil, ErrOrderPaid
+		}
+		return nil, err
+	}
+	return claimed, nil
+}
+
+func (s *orderService) CallbackKey(ctx context.Context, userID string) (*model.CallbackKeyResponse, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	return &model.CallbackKeyResponse{Algorithm: "HMAC-SHA256", Key: s.webhooks.SigningKey(oid.Hex())}, nil
+}
+
+func validCallbackURL(raw string) bool {
+	if raw == "" {
+		return true
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" || strings.EqualFold(u.Hostname(), "localhost") {
+		return false
+	}
+	// Names are checked again when the callback connects, after resolution.
+	if ip, err := netip.ParseAddr(u.Hostname()); err == nil {
+		return publicAddr(ip)
+	}
+	return true
+}
+
+func toPaise(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func generateTxnRef() string {
+	return strings.ToUpper(strings.ReplaceAll(uuid.NewString(), "-", ""))
+}
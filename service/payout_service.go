@@ -0,0 +1,420 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/banking-superapp/upi-service/logging"
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/repository"
+	"github.com/banking-superapp/upi-service/secure"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	ErrNotBusinessAccount = errors.New("bulk payouts require a verified merchant account")
+	ErrBatchNotFound      = errors.New("payout batch not found")
+	ErrInvalidCSV         = errors.New("CSV must have vpa,amount,note columns")
+)
+
+const (
+	maxPayoutRows     = 1000
+	stalePayoutRowAge = 10 * time.Minute
+	payoutLease       = 2 * time.Minute
+)
+
+var vpaPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9.\-_]{1,255}@[a-z][a-z0-9]{1,63}$`)
+
+// PayoutValidationError lists every invalid row of a rejected batch.
+type PayoutValidationError struct {
+	Rows []model.PayoutRowError
+}
+
+func (e *PayoutValidationError) Error() string {
+	return fmt.Sprintf("%d invalid payout rows", len(e.Rows))
+}
+
+type PayoutService interface {
+	CreateBatch(ctx context.Context, userID string, req *model.CreatePayoutBatchRequest) (*model.PayoutBatch, error)
+	GetBatch(ctx context.Context, userID, batchID string) (*model.PayoutBatch, error)
+	GetRows(ctx context.Context, userID, batchID, status string) ([]model.PayoutRow, error)
+	ExportResults(ctx context.Context, userID, batchID string) ([]byte, error)
+	// ResumeBatches picks up processing batches whose executor's lease has
+	// lapsed. It runs at start up and periodically, so batches interrupted
+	// on another replica still finish.
+	ResumeBatches(ctx context.Context) error
+}
+
+type payoutService struct {
+	payoutRepo   repository.PayoutRepo
+	merchantRepo repository.MerchantRepo
+	txnRepo      repository.UPITransactionRepo
+	upi          UPIService
+	owner        string        // names this process in batch leases
+	slots        chan struct{} // payments in flight across all batches
+
+	mu      sync.Mutex
+	running map[bson.ObjectID]bool
+}
+
+func NewPayoutService(pr repository.PayoutRepo, mr repository.MerchantRepo, tr repository.UPITransactionRepo, upi UPIService, concurrency int, owner string) PayoutService {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &payoutService{
+		payoutRepo:   pr,
+		merchantRepo: mr,
+		txnRepo:      tr,
+		upi:          upi,
+		owner:        owner,
+		slots:        make(chan struct{}, concurrency),
+		running:      make(map[bson.ObjectID]bool),
+	}
+}
+
+func (s *payoutService) CreateBatch(ctx context.Context, userID string, req *model.CreatePayoutBatchRequest) (*model.PayoutBatch, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	if err := s.requireBusiness(ctx, oid); err != nil {
+		return nil, err
+	}
+
+	rows, err := validatePayoutRows(req.Rows)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &model.PayoutBatch{
+		UserID:    oid,
+		Reference: req.Reference,
+		Status:    "processing",
+		TotalRows: int64(len(rows)),
+	}
+	for _, r := range rows {
+		batch.TotalAmount += r.Amount
+	}
+	batch.TotalAmount = roundPaise(batch.TotalAmount)
+
+	if err := s.payoutRepo.CreateBatch(ctx, batch, rows); err != nil {
+		return nil, err
+	}
+	go s.execute(*batch)
+	return batch, nil
+}
+
+func (s *payoutService) GetBatch(ctx context.Context, userID, batchID string) (*model.PayoutBatch, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	bid, err := bson.ObjectIDFromHex(batchID)
+	if err != nil {
+		return nil, ErrBatchNotFound
+	}
+	batch, err := s.payoutRepo.FindBatch(ctx, oid, bid)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrBatchNotFound
+		}
+		return nil, err
+	}
+	return batch, nil
+}
+
+func (s *payoutService) GetRows(ctx context.Context, userID, batchID, status string) ([]model.PayoutRow, error) {
+	batch, err := s.GetBatch(ctx, userID, batchID)
+	if err != nil {
+		return nil, err
+	}
+	return s.payoutRepo.FindRows(ctx, batch.ID, status)
+}
+
+func (s *payoutService) ExportResults(ctx context.Context, userID, batchID string) ([]byte, error) {
+	rows, err := s.GetRows(ctx, userID, batchID, "")
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"row", "vpa", "amount", "note", "status", "txn_id", "failure_reason"})
+	for _, r := range rows {
+		w.Write([]string{
+			strconv.FormatInt(r.Row, 10),
+			r.VPA,
+			strconv.FormatFloat(r.Amount, 'f', 2, 64),
+			string(r.Note),
+			r.Status,
+			r.TxnID,
+			r.FailureReason,
+		})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func (s *payoutService) ResumeBatches(ctx context.Context) error {
+	batches, err := s.payoutRepo.FindBatchesByStatus(ctx, "processing")
+	if err != nil {
+		return err
+	}
+	for _, b := range batches {
+		if b.LeaseOwner != s.owner && b.LeaseUntil != nil && b.LeaseUntil.After(time.Now()) {
+			continue // executing on another replica
+		}
+		go s.execute(b)
+	}
+	return nil
+}
+
+// execute pays the pending rows of a batch through UPIService.Pay while
+// holding its lease. Rows already claimed when it stops, because it lost the
+// lease or the process ended, are settled by the next execution.
+func (s *payoutService) execute(batch model.PayoutBatch) {
+	if !s.start(batch.ID) {
+		return
+	}
+	defer s.finish(batch.ID)
+
+	ctx := logging.With(context.Background(), "batch_id", batch.ID.Hex())
+	claimed, err := s.payoutRepo.ClaimBatch(ctx, batch.ID, s.owner, time.Now().Add(payoutLease))
+	if err != nil || !claimed {
+		if err != nil {
+			slog.ErrorContext(ctx, "claiming payout batch failed", "error", err)
+		}
+		return
+	}
+	defer func() {
+		if err := s.payoutRepo.ReleaseBatch(ctx, batch.ID, s.owner); err != nil {
+			slog.ErrorContext(ctx, "releasing payout batch failed", "error", err)
+		}
+	}()
+	leased, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go s.renewLease(leased, cancel, batch.ID)
+
+	if err := s.settleStuckRows(ctx, batch.ID); err != nil {
+		slog.ErrorContext(ctx, "settling interrupted payout rows failed", "error", err)
+		return
+	}
+	rows, err := s.payoutRepo.FindRows(ctx, batch.ID, "pending")
+	if err != nil {
+		slog.ErrorContext(ctx, "loading payout rows failed", "error", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+dispatch:
+	for i := range rows {
+		row := &rows[i]
+		select {
+		case s.slots <- struct{}{}:
+		case <-leased.Done():
+			break dispatch
+		}
+		wg.Add(1)
+		// Payments already started finish even if the lease is lost, so
+		// their outcome is recorded.
+		go func() {
+			defer func() { <-s.slots; wg.Done() }()
+			s.payRow(ctx, batch, row)
+		}()
+	}
+	wg.Wait()
+
+	if _, err := s.payoutRepo.CompleteBatch(ctx, batch.ID); err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		slog.ErrorContext(ctx, "completing payout batch failed", "error", err)
+	}
+}
+
+// start reports false when this process is already executing the batch.
+func (s *payoutService) start(batchID bson.ObjectID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running[batchID] {
+		return false
+	}
+	s.running[batchID] = true
+	return true
+}
+
+func (s *payoutService) finish(batchID bson.ObjectID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.running, batchID)
+}
+
+// renewLease keeps the batch leased until ctx ends, and cancels it if the
+// lease cannot be kept so no further rows are started.
+func (s *payoutService) renewLease(ctx context.Context, cancel context.CancelFunc, batchID bson.ObjectID) {
+	ticker := time.NewTicker(payoutLease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			claimed, err := s.payoutRepo.ClaimBatch(ctx, batchID, s.owner, time.Now().Add(payoutLease))
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil || !claimed {
+				slog.ErrorContext(ctx, "payout batch lease lost", "claimed", claimed, "error", err)
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+// settleStuckRows gives rows left processing by an earlier execution the
+// outcome of the transaction recorded when they were claimed. Recently
+// claimed rows and rows whose payment is still pending are left for a later
+// pass.
+func (s *payoutService) settleStuckRows(ctx context.Context, batchID bson.ObjectID) error {
+	stuck, err := s.payoutRepo.FindRows(ctx, batchID, "processing")
+	if err != nil {
+		return err
+	}
+	for i := range stuck {
+		row := &stuck[i]
+		if time.Since(row.UpdatedAt) < stalePayoutRowAge {
+			continue
+		}
+		if row.TxnID == "" {
+			// Claimed before rows recorded their transaction ID.
+			row.Status, row.FailureReason = "failed", "interrupted; check transaction history before retrying"
+		} else {
+			txn, err := s.txnRepo.FindByTxnID(ctx, row.TxnID)
+			switch {
+			case errors.Is(err, mongo.ErrNoDocuments):
+				row.Status, row.TxnID, row.FailureReason = "failed", "", "interrupted before payment; safe to retry"
+			case err != nil:
+				return err
+			case txn.Status == "pending":
+				continue
+			case txn.Status == "success":
+				row.Status = "success"
+			default:
+				row.Status, row.FailureReason = "failed", "payment "+txn.Status+": "+txn.FailureReason
+			}
+		}
+		if err := s.payoutRepo.RecordRowResult(ctx, batchID, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *payoutService) payRow(ctx context.Context, batch model.PayoutBatch, row *model.PayoutRow) {
+	txnID := generateTxnID()
+	claimed, err := s.payoutRepo.MarkRowProcessing(ctx, row.ID, txnID)
+	if err != nil || !claimed {
+		return
+	}
+
+	txn, err := s.upi.Pay(withTxnID(ctx, txnID), batch.UserID.Hex(), &model.UPIPayRequest{
+		ToVPA:  row.VPA,
+		Amount: row.Amount,
+		Note:   string(row.Note),
+	})
+	if err != nil {
+		// The error may have come after the payment was stored.
+		if paid, findErr := s.txnRepo.FindByTxnID(ctx, txnID); findErr == nil {
+			txn, err = paid, nil
+		}
+	}
+	if err != nil {
+		row.Status = "failed"
+		row.FailureReason = err.Error()
+	} else {
+		row.Status = "success"
+		row.TxnID = txn.TxnID
+	}
+	if err := s.payoutRepo.RecordRowResult(ctx, batch.ID, row); err != nil {
+		slog.ErrorContext(ctx, "recording payout row failed", "row", row.Row, "error", err)
+	}
+}
+
+func (s *payoutService) requireBusiness(ctx context.Context, userID bson.ObjectID) error {
+	merchants, err := s.merchantRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, m := range merchants {
+		if m.Verified && m.IsActive {
+			return nil
+		}
+	}
+	return ErrNotBusinessAccount
+}
+
+func validatePayoutRows(in []model.PayoutRowInput) ([]model.PayoutRow, error) {
+	if len(in) == 0 || len(in) > maxPayoutRows {
+		return nil, &PayoutValidationError{Rows: []model.PayoutRowError{
+			{Error: fmt.Sprintf("batch must have between 1 and %d rows", maxPayoutRows)},
+		}}
+	}
+
+	var invalid []model.PayoutRowError
+	rows := make([]model.PayoutRow, 0, len(in))
+	for i, r := range in {
+		n := int64(i + 1)
+		vpa := strings.ToLower(strings.TrimSpace(r.VPA))
+		switch {
+		case !vpaPattern.MatchString(vpa):
+			invalid = append(invalid, model.PayoutRowError{Row: n, Error: "invalid vpa"})
+		case r.Amount <= 0 || r.Amount != roundPaise(r.Amount):
+			invalid = append(invalid, model.PayoutRowError{Row: n, Error: "amount must be positive with at most 2 decimals"})
+		case r.Amount > p2pTxnLimit:
+			invalid = append(invalid, model.PayoutRowError{Row: n, Error: ErrLimitExceeded.Error()})
+		default:
+			rows = append(rows, model.PayoutRow{Row: n, VPA: vpa, Amount: r.Amount, Note: secure.String(r.Note), Status: "pending"})
+		}
+	}
+	if len(invalid) > 0 {
+		return nil, &PayoutValidationError{Rows: invalid}
+	}
+	return rows, nil
+}
+
+// ParsePayoutCSV reads vpa,amount,note rows. A leading header row is skipped.
+func ParsePayoutCSV(r io.Reader) ([]model.PayoutRowInput, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, ErrInvalidCSV
+	}
+	if len(records) > 0 && len(records[0]) > 1 && strings.EqualFold(strings.TrimSpace(records[0][1]), "amount") {
+		records = records[1:]
+	}
+
+	rows := make([]model.PayoutRowInput, 0, len(records))
+	for _, rec := range records {
+		if len(rec) < 2 || len(rec) > 3 {
+			return nil, ErrInvalidCSV
+		}
+		// Unparsable amounts are kept as zero so validation reports the row.
+		amount, _ := strconv.ParseFloat(strings.TrimSpace(rec[1]), 64)
+		row := model.PayoutRowInput{VPA: rec[0], Amount: amount}
+		if len(rec) == 3 {
+			row.Note = rec[2]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
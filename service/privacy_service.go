@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/repository"
+	"github.com/banking-superapp/upi-service/secure"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	ErrErasureNotFound    = errors.New("erasure request not found")
+	ErrErasurePendingTxns = errors.New("pending transactions must settle before erasure")
+	ErrErasureLiteBalance = errors.New("UPI Lite balance must be spent before erasure")
+	ErrErasureCreditOwed  = errors.New("outstanding credit must be repaid before erasure")
+)
+
+var erasureBlockerErrors = map[string]error{
+	"pending_transactions": ErrErasurePendingTxns,
+	"lite_balance":         ErrErasureLiteBalance,
+	"outstanding_credit":   ErrErasureCreditOwed,
+}
+
+// erasureRetained tells the user what an erasure kept, and why.
+var erasureRetained = []string{
+	"transactions: IDs, RRNs, amounts, VPAs, statuses and dates, for the record keeping period set by RBI and PMLA",
+	"ledger entries: transaction IDs, accounts, amounts and dates, for the same period; counterparties are cleared, as the transactions keep the VPAs",
+	"mandates, collects, schedules, splits and merchant orders: amounts, VPAs, statuses and dates, as evidence for the payments they made",
+	"VPAs: deactivated and kept, so retained transactions stay traceable and the addresses are not reissued",
+	"funding sources and UPI Lite wallet: unlinked, kept for reconciliation",
+	"merchant profile, settlements and payout batches: business records",
+	"audit trail: kept intact; sensitive values in it are fingerprints and IP addresses are cleared by retention",
+}
+
+const (
+	staleErasureAge     = 15 * time.Minute
+	maxRetentionRunPage = 100
+)
+
+// PrivacyService handles data principal erasure requests under the DPDP Act
+// and the retention policies for personal data.
+type PrivacyService interface {
+	// RequestErasure queues erasure of the user's personal data, or returns
+	// the request already open. It refuses while money is in flight or owed.
+	RequestErasure(ctx context.Context, userID string) (*model.ErasureRequest, error)
+	GetErasure(ctx context.Context, userID, id string) (*model.ErasureRequest, error)
+	// ProcessQueued carries out queued erasures until none are left.
+	ProcessQueued(ctx context.Context) error
+	// ApplyRetention runs the retention policies and records what they
+	// changed, or with dryRun what they would change.
+	ApplyRetention(ctx context.Context, dryRun bool) (*model.RetentionRun, error)
+	RetentionRuns(ctx context.Context, limit int64) ([]model.RetentionRun, error)
+}
+
+type privacyService struct {
+	privacyRepo repository.PrivacyRepo
+	audit       AuditService
+	periods     model.RetentionPeriods
+}
+
+func NewPrivacyService(pr repository.PrivacyRepo, as AuditService, periods model.RetentionPeriods) PrivacyService {
+	return &privacyService{privacyRepo: pr, audit: as, periods: periods}
+}
+
+func (s *privacyService) RequestErasure(ctx context.Context, userID string) (*model.ErasureRequest, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	if open, err := s.privacyRepo.FindOpenErasure(ctx, oid); err == nil {
+		return open, nil
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+	if err := s.blocked(ctx, oid); err != nil {
+		return nil, err
+	}
+
+	req := &model.ErasureRequest{
+		UserID:      oid,
+		RequestedBy: AuditActorFrom(ctx),
+		Status:      "queued",
+	}
+	// The request outlives the user's other records, so it keeps no IP.
+	req.RequestedBy.IP, req.RequestedBy.IPDigest = "", ""
+	if err := s.privacyRepo.CreateErasure(ctx, req); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, "erasure.request", "erasure", req.ID.Hex(), nil, req)
+	return req, nil
+}
+
+func (s *privacyService) blocked(ctx context.Context, userID bson.ObjectID) error {
+	reason, err := s.privacyRepo.ErasureBlocker(ctx, userID)
+	if err != nil || reason == "" {
+		return err
+	}
+	return erasureBlockerErrors[reason]
+}
+
+func (s *privacyService) GetErasure(ctx context.Context, userID, id string) (*model.ErasureRequest, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	rid, err := bson.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrErasureNotFound
+	}
+	req, err := s.privacyRepo.FindErasure(ctx, oid, rid)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrErasureNotFound
+	}
+	return req, err
+}
+
+func (s *privacyService) ProcessQueued(ctx context.Context) error {
+	for ctx.Err() == nil {
+		req, err := s.privacyRepo.ClaimErasure(ctx, time.Now().Add(-staleErasureAge))
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return nil
+			}
+			return err
+		}
+		if err := s.erase(ctx, req); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// erase carries out a request the caller has moved to processing. Money that
+// moved since the request was made fails it; the user can ask again once it
+// settles.
+func (s *privacyService) erase(ctx context.Context, req *model.ErasureRequest) error {
+	reason, err := s.privacyRepo.ErasureBlocker(ctx, req.UserID)
+	if err != nil {
+		return err
+	}
+	if reason != "" {
+		return s.fail(ctx, req, erasureBlockerErrors[reason])
+	}
+
+	// The pseudonym is derived rather than stored, so a retry after a crash
+	// moves the remaining records to the same one and nothing here links it
+	// back to the user.
+	var pseudonym bson.ObjectID
+	copy(pseudonym[:], secure.Pseudonym(req.UserID.Hex()))
+	report, err := s.privacyRepo.Erase(ctx, req.UserID, pseudonym)
+	if err != nil {
+		return s.fail(ctx, req, err)
+	}
+	if err := s.privacyRepo.CompleteErasure(ctx, req.ID, report, erasureRetained); err != nil {
+		return err
+	}
+	before := *req
+	req.Status, req.Report, req.Retained = "completed", report, erasureRetained
+	s.audit.Record(ctx, "erasure.complete", "erasure", req.ID.Hex(), &before, req)
+	return nil
+}
+
+func (s *privacyService) fail(ctx context.Context, req *model.ErasureRequest, cause error) error {
+	if err := s.privacyRepo.FailErasure(ctx, req.ID, cause.Error()); err != nil {
+		return err
+	}
+	before := *req
+	req.Status, req.FailureReason = "failed", cause.Error()
+	s.audit.Record(ctx, "erasure.fail", "erasure", req.ID.Hex(), &before, req)
+	return nil
+}
+
+func (s *privacyService) ApplyRetention(ctx context.Context, dryRun bool) (*model.RetentionRun, error) {
+	run := &model.RetentionRun{DryRun: dryRun, StartedAt: time.Now()}
+	actions, err := s.privacyRepo.ApplyRetention(ctx, s.periods, run.StartedAt, dryRun)
+	run.Actions, run.FinishedAt = actions, time.Now()
+	if err != nil {
+		run.Error = err.Error()
+	}
+	// A partial run is still recorded, so the report shows what changed.
+	if recErr := s.privacyRepo.RecordRetentionRun(context.WithoutCancel(ctx), run); recErr != nil {
+		return run, errors.Join(err, recErr)
+	}
+	return run, err
+}
+
+func (s *privacyService) RetentionRuns(ctx context.Context, limit int64) ([]model.RetentionRun, error) {
+	if limit <= 0 || limit > maxRetentionRunPage {
+		limit = maxRetentionRunPage
+	}
+	return s.privacyRepo.FindRetentionRuns(ctx, limit)
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const upiIntentPrefix = "upi://pay?"
+
+// UPIIntent is the parameter set of a upi://pay deep link as defined by the
+// NPCI linking specification.
+type UPIIntent struct {
+	PayeeVPA     string  // pa
+	PayeeName    string  // pn
+	MerchantCode string  // mc
+	TxnRef       string  // tr
+	Note         string  // tn
+	Amount       float64 // am, zero when the payer enters the amount
+	Sign         string  // sign, base64 signature over the rest of the URI
+}
+
+// String renders the intent URI without the signature.
+func (i *UPIIntent) String() string {
+	params := [][2]string{{"pa", i.PayeeVPA}, {"pn", i.PayeeName}}
+	if i.MerchantCode != "" {
+		params = append(params, [2]string{"mc", i.MerchantCode})
+	}
+	if i.TxnRef != "" {
+		params = append(params, [2]string{"tr", i.TxnRef})
+	}
+	if i.Note != "" {
+		params = append(params, [2]string{"tn", i.Note})
+	}
+	if i.Amount > 0 {
+		params = append(params, [2]string{"am", strconv.FormatFloat(i.Amount, 'f', 2, 64)})
+	}
+	params = append(params, [2]string{"cu", "INR"})
+
+	parts := make([]string, 0, len(params))
+	for _, p := range params {
+		parts = append(parts, p[0]+"="+escapeParam(p[1]))
+	}
+	return upiIntentPrefix + strings.Join(parts, "&")
+}
+
+// SignedString renders the intent URI with the sign parameter appended.
+func (i *UPIIntent) SignedString() string {
+	s := i.String()
+	if i.Sign == "" {
+		return s
+	}
+	return s + "&sign=" + url.QueryEscape(i.Sign)
+}
+
+// ParseUPIIntent decodes a upi://pay URI. The returned string is the URI
+// with the sign parameter removed, which is the payload the signature covers.
+func ParseUPIIntent(raw string) (*UPIIntent, string, error) {
+	raw = strings.TrimSpace(raw)
+	if !strings.HasPrefix(strings.ToLower(raw), upiIntentPrefix) {
+		return nil, "", ErrInvalidQR
+	}
+	query := raw[len(upiIntentPrefix):]
+
+	var unsigned []string
+	intent := &UPIIntent{}
+	for _, part := range strings.Split(query, "&") {
+		if part == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(part, "=")
+		decoded, err := url.QueryUnescape(value)
+		if err != nil {
+			return nil, "", ErrInvalidQR
+		}
+		switch strings.ToLower(key) {
+		case "pa":
+			intent.PayeeVPA = strings.ToLower(decoded)
+		case "pn":
+			intent.PayeeName = decoded
+		case "mc":
+			intent.MerchantCode = decoded
+		case "tr":
+			intent.TxnRef = decoded
+		case "tn":
+			intent.Note = decoded
+		case "am":
+			amount, err := strconv.ParseFloat(decoded, 64)
+			if err != nil || amount < 0 || math.IsNaN(amount) || math.IsInf(amount, 0) {
+				return nil, "", ErrInvalidQR
+			}
+			intent.Amount = amount
+		case "cu":
+			if decoded != "" && decoded != "INR" {
+				return nil, "", ErrInvalidQR
+			}
+		case "sign":
+			intent.Sign = decoded
+			continue
+		}
+		unsigned = append(unsigned, part)
+	}
+	if intent.PayeeVPA == "" || !strings.Contains(intent.PayeeVPA, "@") {
+		return nil, "", ErrInvalidQR
+	}
+	return intent, raw[:len(upiIntentPrefix)] + strings.Join(unsigned, "&"), nil
+}
+
+// escapeParam percent-encodes s the way UPI apps expect: spaces as %20 and
+// the @ of a VPA left as is.
+func escapeParam(s string) string {
+	return strings.NewReplacer("+", "%20", "%40", "@").Replace(url.QueryEscape(s))
+}
+
+// QRSigner signs the QRs we issue and verifies signed QRs from us or from
+// trusted issuers. A nil *QRSigner signs nothing and verifies nothing.
+type QRSigner struct {
+	key     *ecdsa.PrivateKey
+	trusted []*ecdsa.PublicKey
+}
+
+// LoadQRSigner reads the signing key and trusted public keys from PEM files.
+// An empty keyFile yields a signer that can only verify.
+func LoadQRSigner(keyFile string, trustedFiles []string) (*QRSigner, error) {
+	s := &QRSigner{}
+	if keyFile != "" {
+		key, err := readECPrivateKey(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("qr signing key: %w", err)
+		}
+		s.key = key
+		s.trusted = append(s.trusted, &key.PublicKey)
+	}
+	for _, f := range trustedFiles {
+		pub, err := readECPublicKey(f)
+		if err != nil {
+			return nil, fmt.Errorf("qr trusted key %s: %w", f, err)
+		}
+		s.trusted = append(s.trusted, pub)
+	}
+	return s, nil
+}
+
+// CanSign reports whether a signing key is configured.
+func (s *QRSigner) CanSign() bool {
+	return s != nil && s.key != nil
+}
+
+// Sign returns the base64 encoded ECDSA signature of payload.
+func (s *QRSigner) Sign(payload string) (string, error) {
+	if !s.CanSign() {
+		return "", errors.New("qr signing key not configured")
+	}
+	digest := sha256.Sum256([]byte(payload))
+	sig, err := ecdsa.SignASN1(rand.Reader, s.key, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// Verify reports whether sig is a valid signature of payload by any trusted key.
+func (s *QRSigner) Verify(payload, sig string) bool {
+	if s == nil {
+		return false
+	}
+	raw, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	digest := sha256.Sum256([]byte(payload))
+	for _, pub := range s.trusted {
+		if ecdsa.VerifyASN1(pub, digest[:], raw) {
+			return true
+		}
+	}
+	return false
+}
+
+func readECPrivateKey(path string) (*ecdsa.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("not an ECDSA private key")
+	}
+	return key, nil
+}
+
+func readECPublicKey(path string) (*ecdsa.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := parsed.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("not an ECDSA public key")
+	}
+	return pub, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	return block, nil
+}
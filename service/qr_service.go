@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/repository"
+	"github.com/skip2/go-qrcode"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+var (
+	ErrInvalidQR          = errors.New("invalid UPI QR payload")
+	ErrInvalidQRSignature = errors.New("QR signature verification failed")
+)
+
+const qrImageSize = 256
+
+type QRService interface {
+	GenerateQR(ctx context.Context, userID string, req *model.GenerateQRRequest) (*model.QRCode, error)
+	ParseQR(ctx context.Context, payload string) (*model.ParsedQR, error)
+}
+
+type qrService struct {
+	vpaRepo      repository.VPARepo
+	merchantRepo repository.MerchantRepo
+	signer       *QRSigner
+}
+
+func NewQRService(vr repository.VPARepo, mr repository.MerchantRepo, signer *QRSigner) QRService {
+	return &qrService{vpaRepo: vr, merchantRepo: mr, signer: signer}
+}
+
+func (s *qrService) GenerateQR(ctx context.Context, userID string, req *model.GenerateQRRequest) (*model.QRCode, error) {
+	if req.Amount < 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+
+	vpas, err := s.vpaRepo.FindByUserID(ctx, oid)
+	if err != nil || len(vpas) == 0 {
+		return nil, errors.New("no VPA found for user")
+	}
+
+	payee := defaultVPA(vpas).Address
+	if req.VPA != "" {
+		payee = ""
+		for _, v := range vpas {
+			if strings.EqualFold(v.Address, req.VPA) {
+				payee = v.Address
+				break
+			}
+		}
+		if payee == "" {
+			return nil, ErrVPANotFound
+		}
+	}
+
+	name := req.Name
+	if name == "" {
+		name = verifiedName
+	}
+	intent := &UPIIntent{
+		PayeeVPA:     payee,
+		PayeeName:    name,
+		MerchantCode: merchantCode(ctx, s.merchantRepo, payee),
+		Amount:       req.Amount,
+		Note:         req.Note,
+	}
+	return renderQR(intent)
+}
+
+func (s *qrService) ParseQR(ctx context.Context, payload string) (*model.ParsedQR, error) {
+	intent, unsigned, err := ParseUPIIntent(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := &model.ParsedQR{
+		PayeeVPA:     intent.PayeeVPA,
+		PayeeName:    intent.PayeeName,
+		MerchantCode: intent.MerchantCode,
+		TxnRef:       intent.TxnRef,
+		Signed:       intent.Sign != "",
+		PayRequest: model.UPIPayRequest{
+			ToVPA:  intent.PayeeVPA,
+			Amount: intent.Amount,
+			Note:   intent.Note,
+			TxnRef: intent.TxnRef,
+		},
+	}
+	if parsed.Signed {
+		if !s.signer.Verify(unsigned, intent.Sign) {
+			return nil, ErrInvalidQRSignature
+		}
+		parsed.Verified = true
+	}
+	return parsed, nil
+}
+
+// merchantCode returns the MCC to advertise in a QR for vpa, or "" when vpa
+// is not a merchant handle.
+func merchantCode(ctx context.Context, mr repository.MerchantRepo, vpa string) string {
+	merchant, err := mr.FindByVPA(ctx, vpa)
+	if err != nil {
+		return ""
+	}
+	return merchant.MCC
+}
+
+// renderQR encodes the intent URI, including any signature, as a PNG.
+func renderQR(intent *UPIIntent) (*model.QRCode, error) {
+	uri := intent.SignedString()
+	png, err := qrcode.Encode(uri, qrcode.Medium, qrImageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &model.QRCode{Intent: uri, PNG: png}, nil
+}
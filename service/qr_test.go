@@ -0,0 +1,82 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseUPIIntent(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		want     UPIIntent
+		unsigned string
+		err      error
+	}{
+		{
+			name:     "merchant intent",
+			raw:      "upi://pay?pa=shop@okbank&pn=Corner%20Shop&mc=5411&tr=ORD1&tn=milk&am=42.50&cu=INR",
+			want:     UPIIntent{PayeeVPA: "shop@okbank", PayeeName: "Corner Shop", MerchantCode: "5411", TxnRef: "ORD1", Note: "milk", Amount: 42.5},
+			unsigned: "upi://pay?pa=shop@okbank&pn=Corner%20Shop&mc=5411&tr=ORD1&tn=milk&am=42.50&cu=INR",
+		},
+		{
+			name:     "sign is left out of the signed payload",
+			raw:      "upi://pay?pa=shop@okbank&sign=c2ln&am=10",
+			want:     UPIIntent{PayeeVPA: "shop@okbank", Amount: 10, Sign: "c2ln"},
+			unsigned: "upi://pay?pa=shop@okbank&am=10",
+		},
+		{
+			name:     "scheme and keys are case insensitive",
+			raw:      "  UPI://PAY?PA=Asha@OKBank&PN=Asha  ",
+			want:     UPIIntent{PayeeVPA: "asha@okbank", PayeeName: "Asha"},
+			unsigned: "UPI://PAY?PA=Asha@OKBank&PN=Asha",
+		},
+		{
+			name:     "empty parameters are skipped",
+			raw:      "upi://pay?&pa=asha@okbank&&cu=",
+			want:     UPIIntent{PayeeVPA: "asha@okbank"},
+			unsigned: "upi://pay?pa=asha@okbank&cu=",
+		},
+		{name: "other scheme", raw: "https://pay?pa=asha@okbank", err: ErrInvalidQR},
+		{name: "upi mandate", raw: "upi://mandate?pa=asha@okbank", err: ErrInvalidQR},
+		{name: "no payee", raw: "upi://pay?pn=Asha&am=1", err: ErrInvalidQR},
+		{name: "payee without handle", raw: "upi://pay?pa=asha", err: ErrInvalidQR},
+		{name: "bad escape", raw: "upi://pay?pa=asha@okbank&tn=%zz", err: ErrInvalidQR},
+		{name: "amount not a number", raw: "upi://pay?pa=asha@okbank&am=ten", err: ErrInvalidQR},
+		{name: "negative amount", raw: "upi://pay?pa=asha@okbank&am=-1", err: ErrInvalidQR},
+		{name: "NaN amount", raw: "upi://pay?pa=asha@okbank&am=NaN", err: ErrInvalidQR},
+		{name: "infinite amount", raw: "upi://pay?pa=asha@okbank&am=Inf", err: ErrInvalidQR},
+		{name: "foreign currency", raw: "upi://pay?pa=asha@okbank&am=1&cu=USD", err: ErrInvalidQR},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, unsigned, err := ParseUPIIntent(tt.raw)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			if *got != tt.want {
+				t.Errorf("intent = %+v, want %+v", *got, tt.want)
+			}
+			if unsigned != tt.unsigned {
+				t.Errorf("unsigned = %q, want %q", unsigned, tt.unsigned)
+			}
+		})
+	}
+}
+
+func TestUPIIntentStringRoundTrip(t *testing.T) {
+	in := UPIIntent{PayeeVPA: "shop@okbank", PayeeName: "Corner Shop & Co", MerchantCode: "5411", TxnRef: "ORD 1", Note: "50% off", Amount: 99.9}
+	got, unsigned, err := ParseUPIIntent(in.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != in {
+		t.Errorf("intent = %+v, want %+v", *got, in)
+	}
+	if unsigned != in.String() {
+		t.Errorf("unsigned = %q, want %q", unsigned, in.String())
+	}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrDraining = errors.New("shutting down")
+
+const readinessCheckTimeout = 2 * time.Second
+
+// Readiness backs /readyz. Every registered check must pass for the service
+// to take traffic; Drain makes it report not ready regardless so load
+// balancers stop routing to it before the server shuts down.
+type Readiness struct {
+	mu       sync.RWMutex
+	checks   map[string]func(context.Context) error
+	draining atomic.Bool
+}
+
+func NewReadiness() *Readiness {
+	return &Readiness{checks: map[string]func(context.Context) error{}}
+}
+
+// Add registers a named check. Checks run concurrently, each with a short
+// timeout.
+func (r *Readiness) Add(name string, check func(context.Context) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[name] = check
+}
+
+func (r *Readiness) Drain() {
+	r.draining.Store(true)
+}
+
+// Check runs every check and returns the result of each, keyed by name, as
+// "ok" or the failure.
+func (r *Readiness) Check(ctx context.Context) (bool, map[string]string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	results := make(map[string]string, len(r.checks)+1)
+	ready := !r.draining.Load()
+	if !ready {
+		results["shutdown"] = ErrDraining.Error()
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range r.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, readinessCheckTimeout)
+			defer cancel()
+			err := check(ctx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				results[name] = err.Error()
+				ready = false
+			} else {
+				results[name] = "ok"
+			}
+		}()
+	}
+	wg.Wait()
+	return ready, results
+}
+
+// Heartbeats records when each background worker last ran so readiness can
+// spot a worker that has stalled.
+type Heartbeats struct {
+	mu      sync.Mutex
+	workers map[string]heartbeat
+}
+
+type heartbeat struct {
+	interval time.Duration
+	last     time.Time
+}
+
+func NewHeartbeats() *Heartbeats {
+	return &Heartbeats{workers: map[string]heartbeat{}}
+}
+
+func (h *Heartbeats) Beat(name string, interval time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.workers[name] = heartbeat{interval: interval, last: time.Now()}
+}
+
+// Check fails if any worker has missed two runs. A run that is still in
+// progress counts as missed, so the allowance includes a minute of slack.
+func (h *Heartbeats) Check(context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var stale []string
+	for name, hb := range h.workers {
+		if age := time.Since(hb.last); age > 2*hb.interval+time.Minute {
+			stale = append(stale, fmt.Sprintf("%s (last ran %s ago)", name, age.Round(time.Second)))
+		}
+	}
+	if len(stale) > 0 {
+		sort.Strings(stale)
+		return fmt.Errorf("stalled workers: %s", strings.Join(stale, ", "))
+	}
+	return nil
+}
+
+// HTTPCheck passes when a GET to url answers with a 2xx status.
+func HTTPCheck(url string) func(context.Context) error {
+	client := &http.Client{}
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("%s returned %d", url, resp.StatusCode)
+		}
+		return nil
+	}
+}
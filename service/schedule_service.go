@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/banking-superapp/upi-service/metrics"
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/repository"
+	"github.com/banking-superapp/upi-service/secure"
+	"github.com/robfig/cron/v3"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	ErrScheduleNotFound = errors.New("scheduled payment not found")
+	ErrInvalidSchedule  = errors.New("provide either a future run_at or a valid daily-or-slower rule")
+	ErrScheduleState    = errors.New("scheduled payment cannot change from its current status")
+)
+
+const (
+	recurringPreAuthValidity = 365 * 24 * time.Hour
+	oneTimePreAuthGrace      = 24 * time.Hour
+	minRecurrenceGap         = 24 * time.Hour
+	ruleCheckSpan            = 4 * 366 * 24 * time.Hour // a leap cycle, so rules on 29 February are covered
+	dueScheduleBatch         = 100
+)
+
+type ScheduleService interface {
+	CreateSchedule(ctx context.Context, userID string, req *model.CreateScheduleRequest) (*model.ScheduledPayment, error)
+	GetSchedules(ctx context.Context, userID string) ([]model.ScheduledPayment, error)
+	GetRuns(ctx context.Context, userID, scheduleID string) ([]model.ScheduledPaymentRun, error)
+	Pause(ctx context.Context, userID, scheduleID string) (*model.ScheduledPayment, error)
+	Resume(ctx context.Context, userID, scheduleID string) (*model.ScheduledPayment, error)
+	SkipNext(ctx context.Context, userID, scheduleID string) (*model.ScheduledPayment, error)
+	Cancel(ctx context.Context, userID, scheduleID string) (*model.ScheduledPayment, error)
+	// ExecuteDue runs every occurrence that has come due.
+	ExecuteDue(ctx context.Context) error
+}
+
+type scheduleService struct {
+	scheduleRepo repository.ScheduleRepo
+	upi          UPIService
+	pins         PINVerifier
+	preauth      *PreAuthorizer
+}
+
+func NewScheduleService(sr repository.ScheduleRepo, upi UPIService, pins PINVerifier, preauth *PreAuthorizer) ScheduleService {
+	return &scheduleService{scheduleRepo: sr, upi: upi, pins: pins, preauth: preauth}
+}
+
+func (s *scheduleService) CreateSchedule(ctx context.Context, userID string, req *model.CreateScheduleRequest) (*model.ScheduledPayment, error) {
+	if req.Amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	toVPA := strings.ToLower(strings.TrimSpace(req.ToVPA))
+	if !vpaPattern.MatchString(toVPA) {
+		return nil, ErrVPANotFound
+	}
+
+	now := time.Now()
+	sp := &model.ScheduledPayment{
+		ID:      bson.NewObjectID(),
+		UserID:  oid,
+		ToVPA:   toVPA,
+		Amount:  req.Amount,
+		Note:    secure.String(req.Note),
+		EndDate: req.EndDate,
+		Status:  "active",
+	}
+	switch {
+	case req.RunAt != nil && req.Rule == "":
+		if !req.RunAt.After(now) {
+			return nil, ErrInvalidSchedule
+		}
+		sp.Kind = "one_time"
+		sp.NextRunAt = req.RunAt
+		sp.PreAuthUntil = req.RunAt.Add(oneTimePreAuthGrace)
+	case req.RunAt == nil && req.Rule != "":
+		schedule, err := parseRule(req.Rule)
+		if err != nil {
+			return nil, err
+		}
+		next := schedule.Next(now)
+		if next.IsZero() || (req.EndDate != nil && next.After(*req.EndDate)) {
+			return nil, ErrInvalidSchedule
+		}
+		sp.Kind = "recurring"
+		sp.Rule = req.Rule
+		sp.NextRunAt = &next
+		sp.PreAuthUntil = now.Add(recurringPreAuthValidity)
+		if req.EndDate != nil && req.EndDate.Before(sp.PreAuthUntil) {
+			sp.PreAuthUntil = *req.EndDate
+		}
+	default:
+		return nil, ErrInvalidSchedule
+	}
+
+	if err := s.pins.Verify(ctx, userID, req.PINCredential); err != nil {
+		return nil, err
+	}
+	sp.PreAuthToken, err = s.preauth.Issue(PreAuthClaims{
+		Subject:   sp.ID.Hex(),
+		UserID:    userID,
+		ToVPA:     sp.ToVPA,
+		MaxAmount: toPaise(sp.Amount),
+		ExpiresAt: sp.PreAuthUntil.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.scheduleRepo.Create(ctx, sp); err != nil {
+		return nil, err
+	}
+	return sp, nil
+}
+
+func (s *scheduleService) GetSchedules(ctx context.Context, userID string) ([]model.ScheduledPayment, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	return s.scheduleRepo.FindByUserID(ctx, oid)
+}
+
+func (s *scheduleService) GetRuns(ctx context.Context, userID, scheduleID string) ([]model.ScheduledPaymentRun, error) {
+	oid, sid, err := scheduleIDs(userID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.scheduleRepo.FindByID(ctx, oid, sid); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrScheduleNotFound
+		}
+		return nil, err
+	}
+	return s.scheduleRepo.FindRuns(ctx, sid, 100)
+}
+
+func (s *scheduleService) Pause(ctx context.Context, userID, scheduleID string) (*model.ScheduledPayment, error) {
+	return s.update(ctx, userID, scheduleID, []string{"active"}, bson.M{"status": "paused"})
+}
+
+// Resume reactivates a paused schedule. Occurrences missed while paused are
+// not made up; a recurring schedule continues from its next future occurrence.
+func (s *scheduleService) Resume(ctx context.Context, userID, scheduleID string) (*model.ScheduledPayment, error) {
+	oid, sid, err := scheduleIDs(userID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	sp, err := s.scheduleRepo.FindByID(ctx, oid, sid)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrScheduleNotFound
+		}
+		return nil, err
+	}
+
+	set := bson.M{"status": "active"}
+	if sp.Kind == "recurring" && sp.NextRunAt != nil && sp.NextRunAt.Before(time.Now()) {
+		schedule, err := parseRule(sp.Rule)
+		if err != nil {
+			return nil, err
+		}
+		set["next_run_at"] = schedule.Next(time.Now())
+	}
+	return s.update(ctx, userID, scheduleID, []string{"paused"}, set)
+}
+
+func (s *scheduleService) SkipNext(ctx context.Context, userID, scheduleID string) (*model.ScheduledPayment, error) {
+	return s.update(ctx, userID, scheduleID, []string{"active", "paused"}, bson.M{"skip_next": true})
+}
+
+func (s *scheduleService) Cancel(ctx context.Context, userID, scheduleID string) (*model.ScheduledPayment, error) {
+	return s.update(ctx, userID, scheduleID, []string{"active", "paused"}, bson.M{"status": "cancelled"})
+}
+
+func (s *scheduleService) update(ctx context.Context, userID, scheduleID string, from []string, set bson.M) (*model.ScheduledPayment, error) {
+	oid, sid, err := scheduleIDs(userID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	sp, err := s.scheduleRepo.Update(ctx, oid, sid, from, set)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			if _, findErr := s.scheduleRepo.FindByID(ctx, oid, sid); findErr == nil {
+				return nil, ErrScheduleState
+			}
+			return nil, ErrScheduleNotFound
+		}
+		return nil, err
+	}
+	return sp, nil
+}
+
+func (s *scheduleService) ExecuteDue(ctx context.Context) error {
+	due, err := s.scheduleRepo.FindDue(ctx, time.Now(), dueScheduleBatch)
+	if err != nil {
+		return err
+	}
+	// One schedule failing must not hold up the others due in the batch.
+	for i := range due {
+		if err := s.execute(ctx, &due[i]); err != nil {
+			slog.ErrorContext(ctx, "executing scheduled payment failed", "schedule_id", due[i].ID.Hex(), "error", err)
+		}
+	}
+	return nil
+}
+
+// execute claims one due occurrence, moves the schedule on to the next one
+// and then pays it (or records the skip).
+func (s *scheduleService) execute(ctx context.Context, sp *model.ScheduledPayment) error {
+	runAt := *sp.NextRunAt
+
+	var next *time.Time
+	status := "completed"
+	if sp.Kind == "recurring" {
+		schedule, err := parseRule(sp.Rule)
+		if err != nil {
+			// Stored under older validation; it can never run, so it is
+			// cancelled rather than retried on every tick.
+			return s.cancelInvalid(ctx, sp, runAt)
+		}
+		// Occurrences missed while the worker was down collapse into this run.
+		n := schedule.Next(time.Now())
+		if sp.EndDate == nil || !n.After(*sp.EndDate) {
+			next, status = &n, "active"
+		}
+	}
+
+	// A skip requested after FindDue read the schedule fails the claim; the
+	// next tick sees it and skips this occurrence instead of paying it.
+	claimed, err := s.scheduleRepo.Advance(ctx, sp.ID, runAt, sp.SkipNext, next, status)
+	if err != nil || !claimed {
+		return err
+	}
+
+	run := &model.ScheduledPaymentRun{ScheduleID: sp.ID, UserID: sp.UserID, ScheduledFor: runAt}
+	switch {
+	case sp.SkipNext:
+		run.Status = "skipped"
+	default:
+		claims := PreAuthClaims{Subject: sp.ID.Hex(), UserID: sp.UserID.Hex(), ToVPA: sp.ToVPA}
+		if err := s.preauth.Verify(sp.PreAuthToken, claims, toPaise(sp.Amount)); err != nil {
+			run.Status, run.FailureReason = "failed", err.Error()
+			break
+		}
+		txn, err := s.upi.Pay(ctx, sp.UserID.Hex(), &model.UPIPayRequest{ToVPA: sp.ToVPA, Amount: sp.Amount, Note: string(sp.Note)})
+		if err != nil {
+			run.Status, run.FailureReason = "failed", err.Error()
+			break
+		}
+		run.Status, run.TxnID = "success", txn.TxnID
+	}
+	metrics.ScheduledPaymentExecutions.WithLabelValues(sp.Kind, run.Status).Inc()
+	if err := s.scheduleRepo.CreateRun(ctx, run); err != nil {
+		slog.ErrorContext(ctx, "recording schedule run failed", "schedule_id", sp.ID.Hex(), "error", err)
+	}
+	return nil
+}
+
+func (s *scheduleService) cancelInvalid(ctx context.Context, sp *model.ScheduledPayment, runAt time.Time) error {
+	claimed, err := s.scheduleRepo.Advance(ctx, sp.ID, runAt, sp.SkipNext, nil, "cancelled")
+	if err != nil || !claimed {
+		return err
+	}
+	run := &model.ScheduledPaymentRun{
+		ScheduleID:    sp.ID,
+		UserID:        sp.UserID,
+		ScheduledFor:  runAt,
+		Status:        "failed",
+		FailureReason: ErrInvalidSchedule.Error(),
+	}
+	return s.scheduleRepo.CreateRun(ctx, run)
+}
+
+// parseRule parses a standard 5 field cron expression (or descriptor such as
+// @monthly) in IST and rejects rules that fire more than once a day anywhere
+// in the next ruleCheckSpan, not just at their next firing.
+func parseRule(rule string) (cron.Schedule, error) {
+	schedule, err := cron.ParseStandard(rule)
+	if err != nil {
+		return nil, ErrInvalidSchedule
+	}
+	spec, ok := schedule.(*cron.SpecSchedule)
+	if !ok {
+		return nil, ErrInvalidSchedule
+	}
+	spec.Location = ist
+
+	now := time.Now()
+	prev := spec.Next(now)
+	if prev.IsZero() {
+		return nil, ErrInvalidSchedule
+	}
+	for end := now.Add(ruleCheckSpan); prev.Before(end); {
+		next := spec.Next(prev)
+		if next.IsZero() {
+			break
+		}
+		if next.Sub(prev) < minRecurrenceGap {
+			return nil, ErrInvalidSchedule
+		}
+		prev = next
+	}
+	return spec, nil
+}
+
+func scheduleIDs(userID, scheduleID string) (bson.ObjectID, bson.ObjectID, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return oid, oid, ErrUnauthorized
+	}
+	sid, err := bson.ObjectIDFromHex(scheduleID)
+	if err != nil {
+		return oid, sid, ErrScheduleNotFound
+	}
+	return oid, sid, nil
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		rule string
+		err  error
+	}{
+		{rule: "0 9 * * *"},
+		{rule: "30 23 * * 1-5"},
+		{rule: "0 10 1 * *"},
+		{rule: "0 8 29 2 *"},
+		{rule: "@monthly"},
+		{rule: "@yearly"},
+		{rule: "*/5 * * * *", err: ErrInvalidSchedule},
+		{rule: "0 9,21 * * *", err: ErrInvalidSchedule},
+		{rule: "0 * * * *", err: ErrInvalidSchedule},
+		{rule: "@hourly", err: ErrInvalidSchedule},
+		{rule: "@every 24h", err: ErrInvalidSchedule},
+		{rule: "0 9 30 2 *", err: ErrInvalidSchedule},
+		{rule: "0 0 9 * * *", err: ErrInvalidSchedule},
+		{rule: "every day", err: ErrInvalidSchedule},
+		{rule: "", err: ErrInvalidSchedule},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			if _, err := parseRule(tt.rule); !errors.Is(err, tt.err) {
+				t.Errorf("parseRule(%q) = %v, want %v", tt.rule, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestParseRuleRunsInIST(t *testing.T) {
+	schedule, err := parseRule("0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	want := time.Date(2026, 3, 2, 9, 0, 0, 0, ist)
+	if got := schedule.Next(from); !got.Equal(want) {
+		t.Errorf("Next(%v) = %v, want %v", from, got, want)
+	}
+}
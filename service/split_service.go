@@ -0,0 +1,361 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/banking-superapp/upi-service/metrics"
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/repository"
+	"github.com/banking-superapp/upi-service/secure"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	ErrSplitNotFound = errors.New("split not found")
+	ErrInvalidSplit  = errors.New("invalid split: check mode, participants and shares")
+)
+
+const (
+	maxSplitParticipants = 20
+	defaultSplitExpiry   = 7 * 24 * time.Hour
+	maxSplitExpiry       = 30 * 24 * time.Hour
+	manualReminderGap    = time.Hour
+	autoReminderGap      = 24 * time.Hour
+	maxAutoReminders     = 3
+)
+
+type SplitService interface {
+	CreateSplit(ctx context.Context, userID string, req *model.CreateSplitRequest) (*model.Split, error)
+	GetSplits(ctx context.Context, userID string) ([]model.Split, error)
+	GetSplit(ctx context.Context, userID, splitID string) (*model.Split, error)
+	Remind(ctx context.Context, userID, splitID string) (*model.Split, error)
+	// ProcessOpenSplits expires overdue splits and sends automatic reminders.
+	ProcessOpenSplits(ctx context.Context) error
+}
+
+type splitService struct {
+	vpaRepo     repository.VPARepo
+	collectRepo repository.CollectRepo
+	splitRepo   repository.SplitRepo
+	notifier    Notifier
+}
+
+func NewSplitService(vr repository.VPARepo, cr repository.CollectRepo, sr repository.SplitRepo, n Notifier) SplitService {
+	return &splitService{vpaRepo: vr, collectRepo: cr, splitRepo: sr, notifier: n}
+}
+
+func (s *splitService) CreateSplit(ctx context.Context, userID string, req *model.CreateSplitRequest) (*model.Split, error) {
+	if req.TotalAmount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+
+	vpas, err := s.vpaRepo.FindByUserID(ctx, oid)
+	if err != nil || len(vpas) == 0 {
+		return nil, errors.New("no VPA found for user")
+	}
+	own := make(map[string]bool, len(vpas))
+	for _, v := range vpas {
+		own[v.Address] = true
+	}
+
+	if len(req.Participants) == 0 || len(req.Participants) > maxSplitParticipants {
+		return nil, ErrInvalidSplit
+	}
+	seen := make(map[string]bool, len(req.Participants))
+	for i := range req.Participants {
+		vpa := strings.ToLower(strings.TrimSpace(req.Participants[i].VPA))
+		if !vpaPattern.MatchString(vpa) || seen[vpa] || own[vpa] {
+			return nil, ErrInvalidSplit
+		}
+		seen[vpa] = true
+		req.Participants[i].VPA = vpa
+	}
+
+	self, shares, err := splitShares(req)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresIn := defaultSplitExpiry
+	if req.ExpiresInHrs > 0 {
+		expiresIn = min(time.Duration(req.ExpiresInHrs)*time.Hour, maxSplitExpiry)
+	}
+
+	split := &model.Split{
+		ID:          bson.NewObjectID(),
+		UserID:      oid,
+		ToVPA:       defaultVPA(vpas).Address,
+		TotalAmount: req.TotalAmount,
+		SelfShare:   self,
+		Note:        secure.String(req.Note),
+		Mode:        req.Mode,
+		Status:      "open",
+		ExpiresAt:   time.Now().Add(expiresIn),
+	}
+	for i, p := range req.Participants {
+		split.Participants = append(split.Participants, model.SplitParticipant{
+			VPA:       p.VPA,
+			Amount:    shares[i],
+			CollectID: bson.NewObjectID(),
+			Status:    "pending",
+		})
+	}
+	if err := s.splitRepo.Create(ctx, split); err != nil {
+		return nil, err
+	}
+
+	for i, p := range split.Participants {
+		cr := &model.CollectRequest{
+			ID:        p.CollectID,
+			UserID:    oid,
+			FromVPA:   p.VPA,
+			ToVPA:     split.ToVPA,
+			Amount:    p.Amount,
+			Note:      split.Note,
+			Status:    "pending",
+			SplitID:   &split.ID,
+			ExpiresAt: split.ExpiresAt,
+		}
+		if err := s.collectRepo.Create(ctx, cr); err != nil {
+			// The split is withdrawn, so the collects already sent must not
+			// stay payable.
+			cleanup := context.WithoutCancel(ctx)
+			for _, sent := range split.Participants[:i] {
+				_ = s.collectRepo.Transition(cleanup, sent.CollectID, "pending", "expired", "")
+			}
+			_ = s.splitRepo.SetStatus(cleanup, split.ID, "closed")
+			return nil, err
+		}
+		metrics.Collects.WithLabelValues("created").Inc()
+	}
+	return split, nil
+}
+
+func (s *splitService) GetSplits(ctx context.Context, userID string) ([]model.Split, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	return s.splitRepo.FindByUserID(ctx, oid)
+}
+
+func (s *splitService) GetSplit(ctx context.Context, userID, splitID string) (*model.Split, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	sid, err := bson.ObjectIDFromHex(splitID)
+	if err != nil {
+		return nil, ErrSplitNotFound
+	}
+	split, err := s.splitRepo.FindByID(ctx, sid)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrSplitNotFound
+		}
+		return nil, err
+	}
+	if split.UserID != oid {
+		return nil, ErrSplitNotFound
+	}
+	return split, nil
+}
+
+// Remind nudges every pending participant not reminded in the last hour.
+func (s *splitService) Remind(ctx context.Context, userID, splitID string) (*model.Split, error) {
+	split, err := s.GetSplit(ctx, userID, splitID)
+	if err != nil {
+		return nil, err
+	}
+	if split.Status != "open" {
+		return split, nil
+	}
+	if err := s.remind(ctx, split, manualReminderGap, 0); err != nil {
+		return nil, err
+	}
+	return s.splitRepo.FindByID(ctx, split.ID)
+}
+
+func (s *splitService) ProcessOpenSplits(ctx context.Context) error {
+	splits, err := s.splitRepo.FindOpen(ctx)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for i := range splits {
+		split := &splits[i]
+		if now.After(split.ExpiresAt) {
+			if err := s.expire(ctx, split); err != nil {
+				return err
+			}
+			continue
+		}
+		if now.Sub(split.CreatedAt) < autoReminderGap {
+			continue
+		}
+		if err := s.remind(ctx, split, autoReminderGap, maxAutoReminders); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// remind notifies pending participants last reminded more than gap ago. A
+// non-zero limit caps the number of reminders a participant receives.
+func (s *splitService) remind(ctx context.Context, split *model.Split, gap time.Duration, limit int) error {
+	for _, p := range split.Participants {
+		if p.Status != "pending" || (limit > 0 && p.RemindersSent >= limit) {
+			continue
+		}
+		if p.LastRemindedAt != nil && time.Since(*p.LastRemindedAt) < gap {
+			continue
+		}
+		n := &model.Notification{
+			Recipient: p.VPA,
+			Type:      "split_reminder",
+			Title:     "Payment reminder",
+			Body:      fmt.Sprintf("%s is waiting for your share of Rs %.2f for %q", split.ToVPA, p.Amount, split.Note),
+			Data: map[string]string{
+				"split_id":   split.ID.Hex(),
+				"collect_id": p.CollectID.Hex(),
+				"amount":     strconv.FormatFloat(p.Amount, 'f', 2, 64),
+			},
+		}
+		if err := s.notifier.Notify(ctx, n); err != nil {
+			return err
+		}
+		if err := s.splitRepo.MarkReminded(ctx, split.ID, p.CollectID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *splitService) expire(ctx context.Context, split *model.Split) error {
+	for _, p := range split.Participants {
+		if p.Status != "pending" {
+			continue
+		}
+		if err := s.collectRepo.Transition(ctx, p.CollectID, "pending", "expired", ""); err == nil {
+			metrics.Collects.WithLabelValues("expired").Inc()
+		}
+		updated, err := s.splitRepo.UpdateParticipant(ctx, split.ID, p.CollectID, "expired", "")
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+		if updated != nil {
+			split = updated
+		}
+	}
+	return closeSplitIfDone(ctx, s.splitRepo, split)
+}
+
+// settleSplitParticipant records the outcome of a split's collect request and
+// closes the split once nobody is left pending.
+func settleSplitParticipant(ctx context.Context, repo repository.SplitRepo, cr *model.CollectRequest, status string) error {
+	split, err := repo.UpdateParticipant(ctx, *cr.SplitID, cr.ID, status, cr.TxnID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+		return err
+	}
+	return closeSplitIfDone(ctx, repo, split)
+}
+
+func closeSplitIfDone(ctx context.Context, repo repository.SplitRepo, split *model.Split) error {
+	allPaid := true
+	for _, p := range split.Participants {
+		switch p.Status {
+		case "pending":
+			return nil
+		case "paid":
+		default:
+			allPaid = false
+		}
+	}
+	if allPaid {
+		return repo.SetStatus(ctx, split.ID, "settled")
+	}
+	return repo.SetStatus(ctx, split.ID, "closed")
+}
+
+// splitShares returns the creator's own share and each participant's share,
+// all in rupees rounded to paise. Rounding leftovers go to the first shares.
+func splitShares(req *model.CreateSplitRequest) (float64, []float64, error) {
+	total := toPaise(req.TotalAmount)
+	n := int64(len(req.Participants))
+	shares := make([]int64, n)
+	var self int64
+
+	switch req.Mode {
+	case "equal", "":
+		req.Mode = "equal"
+		parts := n
+		if req.IncludeSelf {
+			parts++
+		}
+		base, rem := total/parts, total%parts
+		for i := range shares {
+			shares[i] = base
+			if int64(i) < rem {
+				shares[i]++
+			}
+		}
+		if req.IncludeSelf {
+			self = base
+		}
+	case "custom":
+		var sum int64
+		for i, p := range req.Participants {
+			shares[i] = toPaise(p.Amount)
+			sum += shares[i]
+		}
+		self = total - sum
+	case "percentage":
+		var bps, sum int64
+		for i, p := range req.Participants {
+			share := int64(math.Round(p.Percentage * 100))
+			if share <= 0 {
+				return 0, nil, ErrInvalidSplit
+			}
+			bps += share
+			shares[i] = total * share / 10000
+			sum += shares[i]
+		}
+		if bps > 10000 || (!req.IncludeSelf && bps != 10000) {
+			return 0, nil, ErrInvalidSplit
+		}
+		self = total - sum
+		if !req.IncludeSelf {
+			shares[0] += self
+			self = 0
+		}
+	default:
+		return 0, nil, ErrInvalidSplit
+	}
+
+	if self < 0 || (!req.IncludeSelf && self != 0) || (req.IncludeSelf && self == 0 && req.Mode != "equal") {
+		return 0, nil, ErrInvalidSplit
+	}
+	out := make([]float64, n)
+	for i, p := range shares {
+		if p <= 0 {
+			return 0, nil, ErrInvalidSplit
+		}
+		out[i] = float64(p) / 100
+	}
+	return float64(self) / 100, out, nil
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/banking-superapp/upi-service/model"
+)
+
+func TestSplitShares(t *testing.T) {
+	amounts := func(a ...float64) []model.SplitShareInput {
+		out := make([]model.SplitShareInput, len(a))
+		for i := range a {
+			out[i].Amount = a[i]
+		}
+		return out
+	}
+	percentages := func(p ...float64) []model.SplitShareInput {
+		out := make([]model.SplitShareInput, len(p))
+		for i := range p {
+			out[i].Percentage = p[i]
+		}
+		return out
+	}
+
+	tests := []struct {
+		name   string
+		req    model.CreateSplitRequest
+		self   float64
+		shares []float64
+		err    error
+	}{
+		{
+			name:   "equal defaults the mode",
+			req:    model.CreateSplitRequest{TotalAmount: 90, Participants: amounts(0, 0, 0)},
+			shares: []float64{30, 30, 30},
+		},
+		{
+			name:   "equal gives leftover paise to the first shares",
+			req:    model.CreateSplitRequest{TotalAmount: 100, Mode: "equal", Participants: amounts(0, 0, 0)},
+			shares: []float64{33.34, 33.33, 33.33},
+		},
+		{
+			name:   "equal including self",
+			req:    model.CreateSplitRequest{TotalAmount: 100, Mode: "equal", IncludeSelf: true, Participants: amounts(0, 0)},
+			self:   33.33,
+			shares: []float64{33.34, 33.33},
+		},
+		{
+			name: "equal too small to share",
+			req:  model.CreateSplitRequest{TotalAmount: 0.02, Mode: "equal", Participants: amounts(0, 0, 0)},
+			err:  ErrInvalidSplit,
+		},
+		{
+			name:   "custom covering the total",
+			req:    model.CreateSplitRequest{TotalAmount: 100, Mode: "custom", Participants: amounts(40, 60)},
+			shares: []float64{40, 60},
+		},
+		{
+			name:   "custom leaving the rest to self",
+			req:    model.CreateSplitRequest{TotalAmount: 100, Mode: "custom", IncludeSelf: true, Participants: amounts(40.25)},
+			self:   59.75,
+			shares: []float64{40.25},
+		},
+		{
+			name: "custom short of the total",
+			req:  model.CreateSplitRequest{TotalAmount: 100, Mode: "custom", Participants: amounts(40, 50)},
+			err:  ErrInvalidSplit,
+		},
+		{
+			name: "custom over the total",
+			req:  model.CreateSplitRequest{TotalAmount: 100, Mode: "custom", IncludeSelf: true, Participants: amounts(40, 70)},
+			err:  ErrInvalidSplit,
+		},
+		{
+			name: "custom leaving nothing for self",
+			req:  model.CreateSplitRequest{TotalAmount: 100, Mode: "custom", IncludeSelf: true, Participants: amounts(40, 60)},
+			err:  ErrInvalidSplit,
+		},
+		{
+			name: "custom zero share",
+			req:  model.CreateSplitRequest{TotalAmount: 100, Mode: "custom", Participants: amounts(100, 0)},
+			err:  ErrInvalidSplit,
+		},
+		{
+			name:   "percentage gives rounding to the first share",
+			req:    model.CreateSplitRequest{TotalAmount: 10, Mode: "percentage", Participants: percentages(33.33, 33.33, 33.34)},
+			shares: []float64{3.34, 3.33, 3.33},
+		},
+		{
+			name:   "percentage including self",
+			req:    model.CreateSplitRequest{TotalAmount: 100, Mode: "percentage", IncludeSelf: true, Participants: percentages(25, 25)},
+			self:   50,
+			shares: []float64{25, 25},
+		},
+		{
+			name: "percentage under 100 without self",
+			req:  model.CreateSplitRequest{TotalAmount: 100, Mode: "percentage", Participants: percentages(50, 40)},
+			err:  ErrInvalidSplit,
+		},
+		{
+			name: "percentage over 100",
+			req:  model.CreateSplitRequest{TotalAmount: 100, Mode: "percentage", IncludeSelf: true, Participants: percentages(50, 60)},
+			err:  ErrInvalidSplit,
+		},
+		{
+			name: "percentage leaving nothing for self",
+			req:  model.CreateSplitRequest{TotalAmount: 100, Mode: "percentage", IncludeSelf: true, Participants: percentages(50, 50)},
+			err:  ErrInvalidSplit,
+		},
+		{
+			name: "percentage zero share",
+			req:  model.CreateSplitRequest{TotalAmount: 100, Mode: "percentage", Participants: percentages(100, 0)},
+			err:  ErrInvalidSplit,
+		},
+		{
+			name: "unknown mode",
+			req:  model.CreateSplitRequest{TotalAmount: 100, Mode: "weighted", Participants: amounts(0)},
+			err:  ErrInvalidSplit,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			self, shares, err := splitShares(&tt.req)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+			if self != tt.self || !slices.Equal(shares, tt.shares) {
+				t.Errorf("splitShares = %v, %v, want %v, %v", self, shares, tt.self, tt.shares)
+			}
+			if tt.req.Mode == "" {
+				t.Error("mode was not defaulted")
+			}
+		})
+	}
+}
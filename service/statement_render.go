@@ -0,0 +1,183 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/jung-kurt/gofpdf"
+)
+
+func renderStatementJSON(st *model.Statement) ([]byte, error) {
+	return json.MarshalIndent(st, "", "  ")
+}
+
+// renderStatementCSV writes a summary block followed by one block per VPA,
+// separated by blank lines.
+func renderStatementCSV(st *model.Statement) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"statement_from", st.From})
+	w.Write([]string{"statement_to", st.To})
+	w.Write([]string{"opening_balance", money(st.OpeningBalance)})
+	w.Write([]string{"total_debits", money(st.TotalDebits)})
+	w.Write([]string{"total_credits", money(st.TotalCredits)})
+	w.Write([]string{"closing_balance", money(st.ClosingBalance)})
+	for _, sec := range st.Sections {
+		w.Write(nil)
+		w.Write([]string{"vpa", sec.VPA, sec.Account})
+		w.Write([]string{"date", "txn_id", "counterparty", "note", "debit", "credit"})
+		for _, e := range sec.Entries {
+			debit, credit := entryColumns(e)
+			w.Write([]string{e.PostedAt.In(ist).Format("2006-01-02 15:04:05"), e.TxnID, string(e.Counterparty), string(e.Note), debit, credit})
+		}
+		w.Write([]string{"total", "", "", "", money(sec.TotalDebits), money(sec.TotalCredits)})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+var statementColumns = []struct {
+	title string
+	width float64
+	align string
+}{
+	{"Date", 32, "L"},
+	{"Txn ID", 38, "L"},
+	{"Counterparty", 45, "L"},
+	{"Note", 35, "L"},
+	{"Debit", 20, "R"},
+	{"Credit", 20, "R"},
+}
+
+// renderStatementPDF lays the statement out on A4 pages, repeating the
+// column headings on every page a section's table runs onto.
+func renderStatementPDF(st *model.Statement) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetMargins(10, 10, 10)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AliasNbPages("")
+
+	inTable := false
+	pdf.SetHeaderFunc(func() {
+		pdf.SetFont("Helvetica", "B", 13)
+		pdf.CellFormat(0, 7, "UPI Account Statement", "", 1, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.CellFormat(0, 5, fmt.Sprintf("Period %s to %s (IST)", st.From, st.To), "", 1, "L", false, 0, "")
+		pdf.Ln(3)
+		if inTable {
+			tableHeader(pdf)
+		}
+	})
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.CellFormat(0, 5, "Generated "+st.GeneratedAt.Format("2006-01-02 15:04 MST"), "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 5, fmt.Sprintf("Page %d of {nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "", 10)
+	for _, row := range [][2]string{
+		{"Opening balance", money(st.OpeningBalance)},
+		{"Total debits", money(st.TotalDebits)},
+		{"Total credits", money(st.TotalCredits)},
+		{"Closing balance", money(st.ClosingBalance)},
+	} {
+		pdf.CellFormat(50, 6, row[0], "", 0, "L", false, 0, "")
+		pdf.CellFormat(40, 6, "Rs "+row[1], "", 1, "R", false, 0, "")
+	}
+
+	if len(st.Sections) == 0 {
+		pdf.Ln(6)
+		pdf.CellFormat(0, 6, "No transactions in this period.", "", 1, "L", false, 0, "")
+	}
+	for _, sec := range st.Sections {
+		pdf.Ln(6)
+		pdf.SetFont("Helvetica", "B", 11)
+		pdf.CellFormat(0, 7, tr(sectionTitle(sec)), "", 1, "L", false, 0, "")
+		tableHeader(pdf)
+		inTable = true
+		pdf.SetFont("Helvetica", "", 8)
+		for _, e := range sec.Entries {
+			debit, credit := entryColumns(e)
+			cells := []string{e.PostedAt.In(ist).Format("2006-01-02 15:04"), e.TxnID, tr(string(e.Counterparty)), tr(string(e.Note)), debit, credit}
+			for i, col := range statementColumns {
+				pdf.CellFormat(col.width, 5, fitCell(pdf, cells[i], col.width), "B", 0, col.align, false, 0, "")
+			}
+			pdf.Ln(-1)
+		}
+		inTable = false
+		pdf.SetFont("Helvetica", "B", 8)
+		pdf.CellFormat(150, 6, "Total for "+tr(sectionTitle(sec)), "", 0, "L", false, 0, "")
+		pdf.CellFormat(20, 6, money(sec.TotalDebits), "", 0, "R", false, 0, "")
+		pdf.CellFormat(20, 6, money(sec.TotalCredits), "", 1, "R", false, 0, "")
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var accountLabels = map[string]string{
+	liteAccount:   "UPI Lite",
+	"savings":     "savings account",
+	"credit_line": "credit line",
+	"credit_card": "RuPay credit card",
+}
+
+func sectionTitle(sec model.StatementSection) string {
+	if sec.Account == "" {
+		return sec.VPA
+	}
+	kind, id, _ := strings.Cut(sec.Account, ":")
+	label, ok := accountLabels[kind]
+	if !ok {
+		label = kind
+	}
+	if len(id) > 4 {
+		label += " .." + id[len(id)-4:]
+	}
+	return sec.VPA + " (" + label + ")"
+}
+
+func tableHeader(pdf *gofpdf.Fpdf) {
+	pdf.SetFont("Helvetica", "B", 8)
+	pdf.SetFillColor(230, 230, 230)
+	for _, col := range statementColumns {
+		pdf.CellFormat(col.width, 6, col.title, "", 0, col.align, true, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont("Helvetica", "", 8)
+}
+
+// fitCell shortens s with an ellipsis until it fits a column of width w. s is
+// already translated to the single byte font encoding, so trimming bytes is
+// safe.
+func fitCell(pdf *gofpdf.Fpdf, s string, w float64) string {
+	if pdf.GetStringWidth(s) <= w-2 {
+		return s
+	}
+	for len(s) > 0 && pdf.GetStringWidth(s+"...") > w-2 {
+		s = s[:len(s)-1]
+	}
+	return s + "..."
+}
+
+func entryColumns(e model.LedgerEntry) (debit, credit string) {
+	if e.Direction == "credit" {
+		return "", money(e.Amount)
+	}
+	return money(e.Amount), ""
+}
+
+func money(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/repository"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	ErrStatementNotFound = errors.New("statement not found")
+	ErrInvalidStatement  = errors.New("from and to must be YYYY-MM-DD at most 366 days apart, format csv, pdf or json")
+	ErrStatementNotReady = errors.New("statement is not ready")
+)
+
+const (
+	maxStatementDays = 366
+	// Ranges with more entries than this are generated in the background.
+	syncStatementEntries = 500
+	statementRetention   = 7 * 24 * time.Hour
+	staleStatementAge    = 10 * time.Minute
+	// Content is stored inline, so it must leave room in the 16MB document.
+	maxStatementSize = 15 << 20
+)
+
+type StatementService interface {
+	// RequestStatement generates small statements immediately and queues
+	// larger ones; the returned job reports which.
+	RequestStatement(ctx context.Context, userID string, req *model.CreateStatementRequest) (*model.StatementJob, error)
+	GetJob(ctx context.Context, userID, jobID string) (*model.StatementJob, error)
+	// Download returns a ready job including its content.
+	Download(ctx context.Context, userID, jobID string) (*model.StatementJob, error)
+	// ProcessQueued generates queued statements until none are left.
+	ProcessQueued(ctx context.Context) error
+}
+
+type statementService struct {
+	ledgerRepo    repository.LedgerRepo
+	statementRepo repository.StatementRepo
+}
+
+func NewStatementService(lr repository.LedgerRepo, sr repository.StatementRepo) StatementService {
+	return &statementService{ledgerRepo: lr, statementRepo: sr}
+}
+
+func (s *statementService) RequestStatement(ctx context.Context, userID string, req *model.CreateStatementRequest) (*model.StatementJob, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	from, to, err := statementRange(req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+	switch req.Format {
+	case "csv", "pdf", "json":
+	default:
+		return nil, ErrInvalidStatement
+	}
+
+	count, err := s.ledgerRepo.CountRange(ctx, oid, from, to)
+	if err != nil {
+		return nil, err
+	}
+	job := &model.StatementJob{
+		UserID:    oid,
+		From:      req.From,
+		To:        req.To,
+		Format:    req.Format,
+		Status:    "queued",
+		Entries:   count,
+		ExpiresAt: time.Now().Add(statementRetention),
+	}
+	if count <= syncStatementEntries {
+		job.Status = "processing"
+	}
+	if err := s.statementRepo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+	if job.Status == "queued" {
+		return job, nil
+	}
+	return s.generate(ctx, job)
+}
+
+func (s *statementService) GetJob(ctx context.Context, userID, jobID string) (*model.StatementJob, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	jid, err := bson.ObjectIDFromHex(jobID)
+	if err != nil {
+		return nil, ErrStatementNotFound
+	}
+	job, err := s.statementRepo.FindByID(ctx, oid, jid)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrStatementNotFound
+		}
+		return nil, err
+	}
+	return job, nil
+}
+
+func (s *statementService) Download(ctx context.Context, userID, jobID string) (*model.StatementJob, error) {
+	job, err := s.GetJob(ctx, userID, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != "ready" {
+		return nil, ErrStatementNotReady
+	}
+	return job, nil
+}
+
+func (s *statementService) ProcessQueued(ctx context.Context) error {
+	for ctx.Err() == nil {
+		job, err := s.statementRepo.Claim(ctx, time.Now().Add(-staleStatementAge))
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return nil
+			}
+			return err
+		}
+		if _, err := s.generate(ctx, job); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// generate renders a job the caller has moved to processing and stores the
+// result. Any failure fails the job, so it is not claimed again forever; only
+// an error recording the failure is returned.
+func (s *statementService) generate(ctx context.Context, job *model.StatementJob) (*model.StatementJob, error) {
+	content, err := s.render(ctx, job)
+	if err != nil {
+		slog.ErrorContext(ctx, "statement generation failed", "statement_id", job.ID.Hex(), "error", err)
+		return s.statementRepo.Fail(ctx, job.ID, "statement generation failed")
+	}
+	if len(content) > maxStatementSize {
+		return s.statementRepo.Fail(ctx, job.ID, "statement too large; request a shorter period")
+	}
+	done, err := s.statementRepo.Complete(ctx, job.ID, content)
+	if err != nil {
+		slog.ErrorContext(ctx, "storing statement failed", "statement_id", job.ID.Hex(), "size", len(content), "error", err)
+		return s.statementRepo.Fail(ctx, job.ID, "storing statement failed")
+	}
+	return done, nil
+}
+
+func (s *statementService) render(ctx context.Context, job *model.StatementJob) ([]byte, error) {
+	st, err := s.build(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+	switch job.Format {
+	case "csv":
+		return renderStatementCSV(st)
+	case "pdf":
+		return renderStatementPDF(st)
+	default:
+		return renderStatementJSON(st)
+	}
+}
+
+// build assembles the statement from the ledger. Balances cover only
+// postings recorded by this service.
+func (s *statementService) build(ctx context.Context, job *model.StatementJob) (*model.Statement, error) {
+	from, to, err := statementRange(job.From, job.To)
+	if err != nil {
+		return nil, err
+	}
+	opening, err := s.ledgerRepo.Balance(ctx, job.UserID, from)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.ledgerRepo.FindRange(ctx, job.UserID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	st := &model.Statement{
+		From:           job.From,
+		To:             job.To,
+		GeneratedAt:    time.Now().In(ist),
+		OpeningBalance: roundPaise(opening),
+	}
+	type sectionKey struct{ vpa, account string }
+	sections := map[sectionKey]*model.StatementSection{}
+	for _, e := range entries {
+		key := sectionKey{e.VPA, e.Account}
+		sec, ok := sections[key]
+		if !ok {
+			sec = &model.StatementSection{VPA: e.VPA, Account: e.Account}
+			sections[key] = sec
+		}
+		sec.Entries = append(sec.Entries, e)
+		if e.Direction == "credit" {
+			sec.TotalCredits += e.Amount
+		} else {
+			sec.TotalDebits += e.Amount
+		}
+	}
+	for _, sec := range sections {
+		sec.TotalDebits = roundPaise(sec.TotalDebits)
+		sec.TotalCredits = roundPaise(sec.TotalCredits)
+		st.TotalDebits += sec.TotalDebits
+		st.TotalCredits += sec.TotalCredits
+		st.Sections = append(st.Sections, *sec)
+	}
+	sort.Slice(st.Sections, func(i, j int) bool {
+		a, b := st.Sections[i], st.Sections[j]
+		return a.VPA < b.VPA || a.VPA == b.VPA && a.Account < b.Account
+	})
+	st.TotalDebits = roundPaise(st.TotalDebits)
+	st.TotalCredits = roundPaise(st.TotalCredits)
+	st.ClosingBalance = roundPaise(st.OpeningBalance + st.TotalCredits - st.TotalDebits)
+	return st, nil
+}
+
+// statementRange converts inclusive IST dates to [from, to) instants.
+func statementRange(fromDate, toDate string) (time.Time, time.Time, error) {
+	from, err := time.ParseInLocation(dateLayout, fromDate, ist)
+	if err != nil {
+		return from, from, ErrInvalidStatement
+	}
+	last, err := time.ParseInLocation(dateLayout, toDate, ist)
+	if err != nil || last.Before(from) {
+		return from, from, ErrInvalidStatement
+	}
+	to := last.AddDate(0, 0, 1)
+	if to.After(from.AddDate(0, 0, maxStatementDays)) {
+		return from, from, ErrInvalidStatement
+	}
+	return from, to, nil
+}
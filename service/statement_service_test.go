@@ -0,0 +1,39 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStatementRange(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, ist) }
+	tests := []struct {
+		name     string
+		from, to string
+		wantFrom time.Time
+		wantTo   time.Time
+		err      error
+	}{
+		{name: "one day", from: "2026-01-01", to: "2026-01-01", wantFrom: day(2026, 1, 1), wantTo: day(2026, 1, 2)},
+		{name: "month", from: "2026-02-01", to: "2026-02-28", wantFrom: day(2026, 2, 1), wantTo: day(2026, 3, 1)},
+		{name: "leap year", from: "2024-01-01", to: "2024-12-31", wantFrom: day(2024, 1, 1), wantTo: day(2025, 1, 1)},
+		{name: "366 days", from: "2025-01-01", to: "2026-01-01", wantFrom: day(2025, 1, 1), wantTo: day(2026, 1, 2)},
+		{name: "367 days", from: "2025-01-01", to: "2026-01-02", err: ErrInvalidStatement},
+		{name: "to before from", from: "2026-01-02", to: "2026-01-01", err: ErrInvalidStatement},
+		{name: "bad from", from: "01/01/2026", to: "2026-01-01", err: ErrInvalidStatement},
+		{name: "bad to", from: "2026-01-01", to: "2026-02-30", err: ErrInvalidStatement},
+		{name: "missing", err: ErrInvalidStatement},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := statementRange(tt.from, tt.to)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && (!from.Equal(tt.wantFrom) || !to.Equal(tt.wantTo)) {
+				t.Errorf("range = [%v, %v), want [%v, %v)", from, to, tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/banking-superapp/upi-service/model"
+	"github.com/banking-superapp/upi-service/repository"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var (
+	ErrInvalidUPINumber  = errors.New("UPI number must be a 10 digit mobile number or an 8 to 9 digit numeric ID")
+	ErrUPINumberNotFound = errors.New("UPI number not found")
+	ErrUPINumberTaken    = errors.New("UPI number is already mapped")
+	ErrVPAHasUPINumber   = errors.New("VPA already has a UPI number of this kind")
+	ErrMobileNotBound    = errors.New("mobile number must be the one bound to this device")
+)
+
+var (
+	mobileNumberPattern = regexp.MustCompile(`^[6-9]\d{9}$`)
+	numericIDPattern    = regexp.MustCompile(`^[1-9]\d{7,8}$`)
+)
+
+type UPINumberService interface {
+	// Register maps a number to one of the user's VPAs. A mobile number must
+	// be boundMobile, the SIM verified number the calling device is bound to.
+	Register(ctx context.Context, userID, boundMobile string, req *model.RegisterUPINumberRequest) (*model.UPINumber, error)
+	GetNumbers(ctx context.Context, userID string) ([]model.UPINumber, error)
+	// Port moves a number to another of the user's VPAs.
+	Port(ctx context.Context, userID, number string, req *model.PortUPINumberRequest) (*model.UPINumber, error)
+	Deregister(ctx context.Context, userID, number string) (*model.UPINumber, error)
+}
+
+type upiNumberService struct {
+	vpaRepo    repository.VPARepo
+	numberRepo repository.UPINumberRepo
+}
+
+func NewUPINumberService(vr repository.VPARepo, nr repository.UPINumberRepo) UPINumberService {
+	return &upiNumberService{vpaRepo: vr, numberRepo: nr}
+}
+
+// Register maps a number to one of the user's VPAs. Otherwise anyone could
+// map someone else's mobile number and receive their payments. In
+// production: the mapping is registered with the NPCI mapper.
+func (s *upiNumberService) Register(ctx context.Context, userID, boundMobile string, req *model.RegisterUPINumberRequest) (*model.UPINumber, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	kind := upiNumberKind(req.Number)
+	if kind == "" {
+		return nil, ErrInvalidUPINumber
+	}
+	if kind == "mobile" && req.Number != boundMobile {
+		return nil, ErrMobileNotBound
+	}
+	vpa, err := s.ownVPA(ctx, oid, req.VPA)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.numberRepo.FindActive(ctx, req.Number); err == nil {
+		return nil, ErrUPINumberTaken
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	n := &model.UPINumber{UserID: oid, Number: req.Number, Kind: kind, VPA: vpa, Status: "active"}
+	if err := s.numberRepo.Create(ctx, n); err != nil {
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, err
+		}
+		// Either index may have been hit; a concurrent registration of the
+		// same number shows up as an active entry.
+		if _, findErr := s.numberRepo.FindActive(ctx, req.Number); findErr == nil {
+			return nil, ErrUPINumberTaken
+		}
+		return nil, ErrVPAHasUPINumber
+	}
+	return n, nil
+}
+
+func (s *upiNumberService) GetNumbers(ctx context.Context, userID string) ([]model.UPINumber, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	return s.numberRepo.FindByUserID(ctx, oid)
+}
+
+func (s *upiNumberService) Port(ctx context.Context, userID, number string, req *model.PortUPINumberRequest) (*model.UPINumber, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	vpa, err := s.ownVPA(ctx, oid, req.VPA)
+	if err != nil {
+		return nil, err
+	}
+	n, err := s.numberRepo.Relink(ctx, oid, number, vpa)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrUPINumberNotFound
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrVPAHasUPINumber
+		}
+		return nil, err
+	}
+	return n, nil
+}
+
+func (s *upiNumberService) Deregister(ctx context.Context, userID, number string) (*model.UPINumber, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	n, err := s.numberRepo.Deregister(ctx, oid, number)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrUPINumberNotFound
+		}
+		return nil, err
+	}
+	return n, nil
+}
+
+func (s *upiNumberService) ownVPA(ctx context.Context, userID bson.ObjectID, address string) (string, error) {
+	address = strings.ToLower(strings.TrimSpace(address))
+	vpa, err := s.vpaRepo.FindByAddress(ctx, address)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return "", ErrVPANotFound
+		}
+		return "", err
+	}
+	if vpa.UserID != userID || !vpa.IsActive {
+		return "", ErrVPANotFound
+	}
+	return vpa.Address, nil
+}
+
+func upiNumberKind(number string) string {
+	switch {
+	case mobileNumberPattern.MatchString(number):
+		return "mobile"
+	case numericIDPattern.MatchString(number):
+		return "numeric"
+	}
+	return ""
+}
+
+// resolveUPINumber returns the VPA a mapper number points at. Addresses that
+// are not UPI numbers are returned unchanged.
+func resolveUPINumber(ctx context.Context, repo repository.UPINumberRepo, address string) (string, error) {
+	if upiNumberKind(address) == "" {
+		return address, nil
+	}
+	n, err := repo.FindActive(ctx, address)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return "", ErrUPINumberNotFound
+		}
+		return "", err
+	}
+	return n.VPA, nil
+}
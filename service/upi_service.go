@@ -2,25 +2,41 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"log/slog"
 	"strings"
 	"time"
 
+	"github.com/banking-superapp/upi-service/logging"
+	"github.com/banking-superapp/upi-service/metrics"
 	"github.com/banking-superapp/upi-service/model"
 	"github.com/banking-superapp/upi-service/repository"
+	"github.com/banking-superapp/upi-service/secure"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 )
 
 var (
-	ErrVPANotFound   = errors.New("VPA not found or inactive")
-	ErrVPAExists     = errors.New("VPA already exists")
-	ErrUnauthorized  = errors.New("unauthorized")
-	ErrInvalidAmount = errors.New("amount must be greater than zero")
+	ErrVPANotFound      = errors.New("VPA not found or inactive")
+	ErrVPAExists        = errors.New("VPA already exists")
+	ErrUnauthorized     = errors.New("unauthorized")
+	ErrInvalidAmount    = errors.New("amount must be greater than zero")
+	ErrCollectNotFound  = errors.New("collect request not found")
+	ErrCollectNotActive = errors.New("collect request is no longer pending")
+	ErrInvalidFilter    = errors.New("invalid transaction filter")
+	ErrInvalidCursor    = errors.New("invalid cursor")
 )
 
-const bankSuffix = "@digitalbank"
+const (
+	bankSuffix   = "@digitalbank"
+	verifiedName = "Verified User" // In production: fetch from profile service
+
+	ledgerBackfillBatch = 500
+	staleCollectClaim   = 15 * time.Minute
+)
 
 type UPIService interface {
 	CreateVPA(ctx context.Context, userID string, req *model.CreateVPARequest) (*model.VPA, error)
@@ -28,20 +44,38 @@ type UPIService interface {
 	ValidateVPA(ctx context.Context, address string) (*model.VPAValidateResponse, error)
 	Pay(ctx context.Context, userID string, req *model.UPIPayRequest) (*model.UPITransaction, error)
 	Collect(ctx context.Context, userID string, req *model.CollectRequestInput) (*model.CollectRequest, error)
-	GetTransactions(ctx context.Context, userID string, page, limit int64) ([]model.UPITransaction, int64, error)
+	GetPendingCollects(ctx context.Context, userID string) ([]model.CollectRequest, error)
+	RespondCollect(ctx context.Context, userID, collectID string, approve bool) (*model.CollectRequest, error)
+	// RecoverCollects finishes approvals interrupted between paying and
+	// recording the payment, using the transaction ID recorded with the
+	// claim: paid collects are approved, unpaid ones become pending again.
+	RecoverCollects(ctx context.Context) error
+	GetTransactions(ctx context.Context, userID string, filter *model.TxnFilter, q *model.TxnPageQuery) (*model.TxnPage, error)
 	CreateMandate(ctx context.Context, userID string, req *model.CreateMandateRequest) (*model.Mandate, error)
 	GetMandates(ctx context.Context, userID string) ([]model.Mandate, error)
 }
 
 type upiService struct {
-	vpaRepo     repository.VPARepo
-	txnRepo     repository.UPITransactionRepo
-	mandateRepo repository.MandateRepo
-	collectRepo repository.CollectRepo
+	vpaRepo         repository.VPARepo
+	txnRepo         repository.UPITransactionRepo
+	mandateRepo     repository.MandateRepo
+	collectRepo     repository.CollectRepo
+	orderRepo       repository.MerchantOrderRepo
+	merchantRepo    repository.MerchantRepo
+	splitRepo       repository.SplitRepo
+	ledgerRepo      repository.LedgerRepo
+	beneficiaryRepo repository.BeneficiaryRepo
+	upiNumberRepo   repository.UPINumberRepo
+	lite            LiteService
+	funding         FundingService
+	categorizer     *Categorizer
+	budgets         BudgetService
+	webhooks        WebhookSender
+	audit           AuditService
 }
 
-func NewUPIService(vr repository.VPARepo, tr repository.UPITransactionRepo, mr repository.MandateRepo, cr repository.CollectRepo) UPIService {
-	return &upiService{vpaRepo: vr, txnRepo: tr, mandateRepo: mr, collectRepo: cr}
+func NewUPIService(vr repository.VPARepo, tr repository.UPITransactionRepo, mr repository.MandateRepo, cr repository.CollectRepo, or repository.MerchantOrderRepo, mer repository.MerchantRepo, sr repository.SplitRepo, lr repository.LedgerRepo, br repository.BeneficiaryRepo, nr repository.UPINumberRepo, ls LiteService, fs FundingService, cat *Categorizer, bs BudgetService, wh WebhookSender, as AuditService) UPIService {
+	return &upiService{vpaRepo: vr, txnRepo: tr, mandateRepo: mr, collectRepo: cr, orderRepo: or, merchantRepo: mer, splitRepo: sr, ledgerRepo: lr, beneficiaryRepo: br, upiNumberRepo: nr, lite: ls, funding: fs, categorizer: cat, budgets: bs, webhooks: wh, audit: as}
 }
 
 func (s *upiService) CreateVPA(ctx context.Context, userID string, req *model.CreateVPARequest) (*model.VPA, error) {
@@ -54,7 +88,7 @@ func (s *upiService) CreateVPA(ctx context.Context, userID string, req *model.Cr
 	vpa := &model.VPA{
 		UserID:    oid,
 		Address:   address,
-		AccountID: req.AccountID,
+		AccountID: secure.Indexed(req.AccountID),
 		IsDefault: true,
 		IsActive:  true,
 	}
@@ -65,6 +99,7 @@ func (s *upiService) CreateVPA(ctx context.Context, userID string, req *model.Cr
 		}
 		return nil, err
 	}
+	s.audit.Record(ctx, "vpa.create", "vpa", vpa.Address, nil, vpa)
 	return vpa, nil
 }
 
@@ -76,30 +111,79 @@ func (s *upiService) GetVPAs(ctx context.Context, userID string) ([]model.VPA, e
 	return s.vpaRepo.FindByUserID(ctx, oid)
 }
 
+// ValidateVPA also accepts a UPI number, which is resolved through the mapper.
 func (s *upiService) ValidateVPA(ctx context.Context, address string) (*model.VPAValidateResponse, error) {
-	vpa, err := s.vpaRepo.FindByAddress(ctx, address)
+	resolved, err := resolveUPINumber(ctx, s.upiNumberRepo, address)
+	if err != nil {
+		if errors.Is(err, ErrUPINumberNotFound) {
+			return &model.VPAValidateResponse{UPINumber: address, Valid: false}, nil
+		}
+		return nil, err
+	}
+	number := ""
+	if resolved != address {
+		number = address
+	}
+
+	vpa, err := s.vpaRepo.FindByAddress(ctx, resolved)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return &model.VPAValidateResponse{VPA: address, Valid: false}, nil
+			return &model.VPAValidateResponse{VPA: resolved, UPINumber: number, Valid: false}, nil
 		}
 		return nil, err
 	}
 	return &model.VPAValidateResponse{
-		VPA:   vpa.Address,
-		Name:  "Verified User", // In production: fetch from profile service
-		Valid: true,
+		VPA:       vpa.Address,
+		UPINumber: number,
+		Name:      verifiedName,
+		Valid:     true,
 	}, nil
 }
 
 func (s *upiService) Pay(ctx context.Context, userID string, req *model.UPIPayRequest) (*model.UPITransaction, error) {
+	txn, err := s.pay(ctx, userID, req)
+	observePayment(txn, err)
+	return txn, err
+}
+
+// observePayment counts a payment attempt; errors mean it was rejected
+// before reaching the switch.
+func observePayment(txn *model.UPITransaction, err error) {
+	if err != nil {
+		metrics.Payments.WithLabelValues("rejected").Inc()
+		return
+	}
+	metrics.Payments.WithLabelValues(txn.Status).Inc()
+	if txn.Status == "success" {
+		funding, _, _ := strings.Cut(fundingAccount(txn), ":")
+		if funding == "" {
+			funding = "bank"
+		}
+		metrics.PaymentAmount.WithLabelValues(txn.PaymentType, funding).Observe(txn.Amount)
+	}
+}
+
+func (s *upiService) pay(ctx context.Context, userID string, req *model.UPIPayRequest) (*model.UPITransaction, error) {
 	if req.Amount <= 0 {
 		return nil, ErrInvalidAmount
 	}
 
+	if req.Lite && req.FundingSourceID != "" {
+		return nil, ErrFundingNotEligible
+	}
+
 	oid, err := bson.ObjectIDFromHex(userID)
 	if err != nil {
 		return nil, ErrUnauthorized
 	}
+	if req.BeneficiaryID != "" {
+		if err := resolveBeneficiary(ctx, s.beneficiaryRepo, oid, req); err != nil {
+			return nil, err
+		}
+	}
+	if req.ToVPA, err = resolveUPINumber(ctx, s.upiNumberRepo, req.ToVPA); err != nil {
+		return nil, err
+	}
 
 	// Get user's default VPA
 	vpas, err := s.vpaRepo.FindByUserID(ctx, oid)
@@ -107,32 +191,171 @@ func (s *upiService) Pay(ctx context.Context, userID string, req *model.UPIPayRe
 		return nil, errors.New("no VPA found for user")
 	}
 
-	fromVPA := vpas[0].Address
-	for _, v := range vpas {
-		if v.IsDefault {
-			fromVPA = v.Address
-			break
-		}
-	}
+	fromVPA := defaultVPA(vpas).Address
 
+	txnID, ok := ctx.Value(txnIDKey{}).(string)
+	if !ok {
+		txnID = generateTxnID()
+	}
+	ctx = logging.With(ctx, "txn_id", txnID)
 	txn := &model.UPITransaction{
 		UserID:          oid,
-		TxnID:           generateTxnID(),
+		TxnID:           txnID,
+		RRN:             generateRRN(),
 		Type:            "pay",
 		FromVPA:         fromVPA,
 		ToVPA:           req.ToVPA,
 		Amount:          req.Amount,
-		Note:            req.Note,
+		Note:            secure.Text(req.Note),
+		TxnRef:          req.TxnRef,
 		Status:          "success", // In production: integrate with UPI switch
 		TransactionDate: time.Now(),
 	}
 
+	if _, err := classifyPayment(ctx, s.merchantRepo, txn); err != nil {
+		return nil, err
+	}
+	if txn.Category, err = s.categorizer.Categorize(ctx, txn); err != nil {
+		// An uncategorized payment still goes through.
+		slog.WarnContext(ctx, "categorization failed", "error", err)
+	}
+
+	var order *model.MerchantOrder
+	if req.TxnRef != "" {
+		if order, err = claimOrder(ctx, s.orderRepo, req, txn.TxnID); err != nil {
+			releaseMerchantVolume(ctx, s.merchantRepo, txn)
+			return nil, err
+		}
+	}
+
+	switch {
+	case req.Lite:
+		if err := s.lite.Debit(ctx, oid, txn.Amount); err != nil {
+			if order != nil {
+				_ = s.orderRepo.Reopen(ctx, order.TxnRef, txn.TxnID)
+			}
+			releaseMerchantVolume(ctx, s.merchantRepo, txn)
+			return nil, err
+		}
+		txn.Channel = liteAccount
+	case req.FundingSourceID != "":
+		if err := s.funding.Reserve(ctx, oid, req.FundingSourceID, txn); err != nil {
+			if order != nil {
+				_ = s.orderRepo.Reopen(ctx, order.TxnRef, txn.TxnID)
+			}
+			releaseMerchantVolume(ctx, s.merchantRepo, txn)
+			return nil, err
+		}
+	}
+
 	if err := s.txnRepo.Create(ctx, txn); err != nil {
+		if order != nil {
+			_ = s.orderRepo.Reopen(ctx, order.TxnRef, txn.TxnID)
+		}
+		if req.Lite {
+			if refundErr := s.lite.Refund(ctx, oid, txn.Amount); refundErr != nil {
+				slog.ErrorContext(ctx, "lite refund failed", "amount", txn.Amount, "error", refundErr)
+			}
+		}
+		s.funding.Release(ctx, txn)
+		releaseMerchantVolume(ctx, s.merchantRepo, txn)
 		return nil, err
 	}
+	s.audit.Record(ctx, "txn.create", "transaction", txn.TxnID, nil, txn)
+	s.postLedger(ctx, txn)
+	if req.Lite {
+		go s.autoTopUpLite(context.WithoutCancel(ctx), oid)
+	}
+	if err := s.beneficiaryRepo.TouchLastPaid(ctx, oid, txn.ToVPA, txn.TransactionDate); err != nil {
+		slog.WarnContext(ctx, "updating beneficiary failed", "error", err)
+	}
+	go evaluateBudgets(context.WithoutCancel(ctx), s.budgets, *txn)
+
+	if order != nil && order.CallbackURL != "" {
+		go s.notifyOrderPaid(context.WithoutCancel(ctx), order)
+	}
 	return txn, nil
 }
 
+// postLedger records the payer's debit against the account that funded the
+// payment and, when the payee banks with us, the payee's credit. In
+// production: postings come from core banking.
+func (s *upiService) postLedger(ctx context.Context, txn *model.UPITransaction) {
+	if err := postTxnLedger(ctx, s.vpaRepo, s.ledgerRepo, txn); err != nil {
+		slog.ErrorContext(ctx, "ledger posting failed", "error", err)
+	}
+}
+
+func postTxnLedger(ctx context.Context, vr repository.VPARepo, lr repository.LedgerRepo, txn *model.UPITransaction) error {
+	entries := []model.LedgerEntry{{
+		UserID:       txn.UserID,
+		VPA:          txn.FromVPA,
+		Account:      fundingAccount(txn),
+		TxnID:        txn.TxnID,
+		Direction:    "debit",
+		Amount:       txn.Amount,
+		Counterparty: secure.String(txn.ToVPA),
+		Note:         secure.String(txn.Note),
+		PostedAt:     txn.TransactionDate,
+	}}
+	if payee, err := vr.FindByAddress(ctx, txn.ToVPA); err == nil {
+		entries = append(entries, model.LedgerEntry{
+			UserID:       payee.UserID,
+			VPA:          payee.Address,
+			TxnID:        txn.TxnID,
+			Direction:    "credit",
+			Amount:       txn.Amount,
+			Counterparty: secure.String(txn.FromVPA),
+			Note:         secure.String(txn.Note),
+			PostedAt:     txn.TransactionDate,
+		})
+	}
+	return lr.Post(ctx, entries)
+}
+
+// BackfillLedger posts the entries of transactions made before the ledger
+// existed and returns how many transactions it posted, or with dryRun how
+// many it would post.
+func BackfillLedger(ctx context.Context, vr repository.VPARepo, tr repository.UPITransactionRepo, lr repository.LedgerRepo, dryRun bool) (int64, error) {
+	if dryRun {
+		return tr.CountUnposted(ctx)
+	}
+	var posted int64
+	var after bson.ObjectID
+	for {
+		txns, err := tr.FindUnposted(ctx, after, ledgerBackfillBatch)
+		if err != nil || len(txns) == 0 {
+			return posted, err
+		}
+		for i := range txns {
+			if err := postTxnLedger(ctx, vr, lr, &txns[i]); err != nil {
+				return posted, fmt.Errorf("posting %s: %w", txns[i].TxnID, err)
+			}
+			posted++
+		}
+		after = txns[len(txns)-1].ID
+	}
+}
+
+func (s *upiService) autoTopUpLite(ctx context.Context, userID bson.ObjectID) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	if err := s.lite.AutoTopUp(ctx, userID); err != nil {
+		slog.ErrorContext(ctx, "lite auto top-up failed", "error", err)
+	}
+}
+
+// notifyOrderPaid runs after Pay returns; ctx keeps the request's trace but
+// not its cancellation.
+func (s *upiService) notifyOrderPaid(ctx context.Context, order *model.MerchantOrder) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	payload := map[string]interface{}{"event": "order.paid", "order": order}
+	if err := s.webhooks.Send(ctx, order.CallbackURL, order.UserID.Hex(), payload); err != nil {
+		slog.WarnContext(ctx, "order callback failed", "order_id", order.OrderID, "error", err)
+	}
+}
+
 func (s *upiService) Collect(ctx context.Context, userID string, req *model.CollectRequestInput) (*model.CollectRequest, error) {
 	if req.Amount <= 0 {
 		return nil, ErrInvalidAmount
@@ -148,20 +371,14 @@ func (s *upiService) Collect(ctx context.Context, userID string, req *model.Coll
 		return nil, errors.New("no VPA found for user")
 	}
 
-	toVPA := vpas[0].Address
-	for _, v := range vpas {
-		if v.IsDefault {
-			toVPA = v.Address
-			break
-		}
-	}
+	toVPA := defaultVPA(vpas).Address
 
 	cr := &model.CollectRequest{
 		UserID:    oid,
 		FromVPA:   req.FromVPA,
 		ToVPA:     toVPA,
 		Amount:    req.Amount,
-		Note:      req.Note,
+		Note:      secure.String(req.Note),
 		Status:    "pending",
 		ExpiresAt: time.Now().Add(24 * time.Hour),
 	}
@@ -169,21 +386,259 @@ func (s *upiService) Collect(ctx context.Context, userID string, req *model.Coll
 	if err := s.collectRepo.Create(ctx, cr); err != nil {
 		return nil, err
 	}
+	metrics.Collects.WithLabelValues("created").Inc()
+	s.audit.Record(ctx, "collect.create", "collect", cr.ID.Hex(), nil, cr)
+	return cr, nil
+}
+
+// GetPendingCollects lists unexpired collect requests awaiting the user's approval.
+func (s *upiService) GetPendingCollects(ctx context.Context, userID string) ([]model.CollectRequest, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	vpas, err := s.vpaRepo.FindByUserID(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+	addresses := make([]string, 0, len(vpas))
+	for _, v := range vpas {
+		addresses = append(addresses, v.Address)
+	}
+	return s.collectRepo.FindPendingByPayer(ctx, addresses)
+}
+
+// RespondCollect approves a collect request addressed to one of the user's
+// VPAs by paying it, or declines it.
+func (s *upiService) RespondCollect(ctx context.Context, userID, collectID string, approve bool) (*model.CollectRequest, error) {
+	oid, err := bson.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	cid, err := bson.ObjectIDFromHex(collectID)
+	if err != nil {
+		return nil, ErrCollectNotFound
+	}
+
+	cr, err := s.collectRepo.FindByID(ctx, cid)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrCollectNotFound
+		}
+		return nil, err
+	}
+	payer, err := s.vpaRepo.FindByAddress(ctx, cr.FromVPA)
+	if err != nil || payer.UserID != oid {
+		return nil, ErrCollectNotFound
+	}
+	before := *cr
+	if cr.Status == "pending" && time.Now().After(cr.ExpiresAt) {
+		if err := s.collectRepo.Transition(ctx, cid, "pending", "expired", ""); err == nil {
+			metrics.Collects.WithLabelValues("expired").Inc()
+			cr.Status = "expired"
+			s.audit.Record(ctx, "collect.expire", "collect", collectID, &before, cr)
+		}
+		return nil, ErrCollectNotActive
+	}
+	if cr.Status != "pending" {
+		return nil, ErrCollectNotActive
+	}
+
+	if !approve {
+		if err := s.collectRepo.Transition(ctx, cid, "pending", "declined", ""); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return nil, ErrCollectNotActive
+			}
+			return nil, err
+		}
+		cr.Status = "declined"
+		metrics.Collects.WithLabelValues("declined").Inc()
+		s.audit.Record(ctx, "collect.decline", "collect", collectID, &before, cr)
+		if cr.SplitID != nil {
+			if err := settleSplitParticipant(ctx, s.splitRepo, cr, "declined"); err != nil {
+				slog.ErrorContext(ctx, "recording split collect failed", "split_id", cr.SplitID.Hex(), "collect_id", collectID, "error", err)
+			}
+		}
+		return cr, nil
+	}
+
+	txnID := generateTxnID()
+	if err := s.collectRepo.Claim(ctx, cid, txnID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrCollectNotActive
+		}
+		return nil, err
+	}
+	cr.Status, cr.TxnID = "processing", txnID
+	txn, err := s.Pay(withTxnID(ctx, txnID), userID, &model.UPIPayRequest{ToVPA: cr.ToVPA, Amount: cr.Amount, Note: string(cr.Note)})
+	if err != nil {
+		// A payment stored before the error is left for RecoverCollects.
+		if _, findErr := s.txnRepo.FindByTxnID(ctx, txnID); errors.Is(findErr, mongo.ErrNoDocuments) {
+			_ = s.collectRepo.Release(ctx, cid)
+		}
+		return nil, err
+	}
+	if err := s.approveCollect(ctx, cr, &before, txn.TxnID); err != nil {
+		return nil, err
+	}
 	return cr, nil
 }
 
-func (s *upiService) GetTransactions(ctx context.Context, userID string, page, limit int64) ([]model.UPITransaction, int64, error) {
+// approveCollect records that the claimed collect cr was paid by txnID.
+func (s *upiService) approveCollect(ctx context.Context, cr, before *model.CollectRequest, txnID string) error {
+	if err := s.collectRepo.Transition(ctx, cr.ID, "processing", "approved", txnID); err != nil {
+		return err
+	}
+	cr.Status, cr.TxnID = "approved", txnID
+	metrics.Collects.WithLabelValues("approved").Inc()
+	s.audit.Record(ctx, "collect.approve", "collect", cr.ID.Hex(), before, cr)
+	if cr.SplitID != nil {
+		if err := settleSplitParticipant(ctx, s.splitRepo, cr, "paid"); err != nil {
+			slog.ErrorContext(ctx, "recording split collect failed", "split_id", cr.SplitID.Hex(), "collect_id", cr.ID.Hex(), "error", err)
+		}
+	}
+	return nil
+}
+
+func (s *upiService) RecoverCollects(ctx context.Context) error {
+	stale, err := s.collectRepo.FindStaleClaims(ctx, time.Now().Add(-staleCollectClaim))
+	if err != nil {
+		return err
+	}
+	for i := range stale {
+		cr := &stale[i]
+		txn, err := s.txnRepo.FindByTxnID(ctx, cr.TxnID)
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			// Never paid; the payer can approve it again.
+			err = s.collectRepo.Release(ctx, cr.ID)
+		case err != nil:
+		case txn.Status == "success":
+			before := *cr
+			err = s.approveCollect(ctx, cr, &before, txn.TxnID)
+		case txn.Status != "pending":
+			err = s.collectRepo.Release(ctx, cr.ID)
+		}
+		if err != nil {
+			return fmt.Errorf("recovering collect %s: %w", cr.ID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+// GetTransactions returns one page of history. Pages are addressed by the
+// opaque next_cursor of the previous page, or by page number for older
+// clients; page numbers can shift while new payments arrive, cursors do not.
+func (s *upiService) GetTransactions(ctx context.Context, userID string, filter *model.TxnFilter, q *model.TxnPageQuery) (*model.TxnPage, error) {
 	oid, err := bson.ObjectIDFromHex(userID)
 	if err != nil {
-		return nil, 0, ErrUnauthorized
+		return nil, ErrUnauthorized
 	}
-	if page < 1 {
-		page = 1
+	if q.Page < 1 {
+		q.Page = 1
 	}
-	if limit < 1 || limit > 100 {
-		limit = 20
+	if q.Limit < 1 || q.Limit > 100 {
+		q.Limit = 20
 	}
-	return s.txnRepo.FindByUserID(ctx, oid, page, limit)
+	if err := validateTxnFilter(filter); err != nil {
+		return nil, err
+	}
+	var after *model.TxnCursor
+	if q.Cursor != "" {
+		if after, err = decodeTxnCursor(q.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	vpas, err := s.vpaRepo.FindByUserID(ctx, oid)
+	if err != nil {
+		return nil, err
+	}
+	addresses := make([]string, 0, len(vpas))
+	for _, v := range vpas {
+		addresses = append(addresses, v.Address)
+	}
+
+	// One extra row tells us whether another page exists.
+	txns, err := s.txnRepo.FindByUserID(ctx, oid, addresses, filter, after, (q.Page-1)*q.Limit, q.Limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &model.TxnPage{Transactions: txns}
+	if int64(len(txns)) > q.Limit {
+		page.Transactions = txns[:q.Limit]
+		last := page.Transactions[q.Limit-1]
+		page.NextCursor = encodeTxnCursor(last.TransactionDate, last.ID)
+	}
+	if after == nil {
+		page.Page = q.Page
+	}
+	if q.WithTotal {
+		total, err := s.txnRepo.CountByUserID(ctx, oid, addresses, filter)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = &total
+	}
+
+	for i := range page.Transactions {
+		t := &page.Transactions[i]
+		if t.UserID == oid {
+			t.Direction = "sent"
+			continue
+		}
+		// The payer's category, funding source, channel and fee details are
+		// not the payee's to see.
+		t.Direction = "received"
+		t.Category, t.Channel, t.FundingSourceID, t.FundingType, t.MDRFee = "", "", nil, "", 0
+	}
+	return page, nil
+}
+
+// Cursors are the transaction date in Unix milliseconds (BSON date
+// precision) followed by the ObjectID, base64url encoded.
+func encodeTxnCursor(date time.Time, id bson.ObjectID) string {
+	buf := make([]byte, 8, 8+len(id))
+	binary.BigEndian.PutUint64(buf, uint64(date.UnixMilli()))
+	buf = append(buf, id[:]...)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+func decodeTxnCursor(s string) (*model.TxnCursor, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(buf) != 8+len(bson.ObjectID{}) {
+		return nil, ErrInvalidCursor
+	}
+	c := &model.TxnCursor{Date: time.UnixMilli(int64(binary.BigEndian.Uint64(buf[:8])))}
+	copy(c.ID[:], buf[8:])
+	return c, nil
+}
+
+func validateTxnFilter(f *model.TxnFilter) error {
+	switch f.Direction {
+	case "", "sent", "received":
+	default:
+		return ErrInvalidFilter
+	}
+	switch f.Type {
+	case "", "pay", "collect", "refund":
+	default:
+		return ErrInvalidFilter
+	}
+	switch f.Status {
+	case "", "pending", "success", "failed", "declined":
+	default:
+		return ErrInvalidFilter
+	}
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return ErrInvalidFilter
+	}
+	if f.MinAmount != nil && f.MaxAmount != nil && *f.MinAmount > *f.MaxAmount {
+		return ErrInvalidFilter
+	}
+	f.Counterparty = strings.ToLower(strings.TrimSpace(f.Counterparty))
+	f.Query = strings.TrimSpace(f.Query)
+	return nil
 }
 
 func (s *upiService) CreateMandate(ctx context.Context, userID string, req *model.CreateMandateRequest) (*model.Mandate, error) {
@@ -207,13 +662,14 @@ func (s *upiService) CreateMandate(ctx context.Context, userID string, req *mode
 		Frequency: req.Frequency,
 		StartDate: req.StartDate,
 		EndDate:   req.EndDate,
-		Purpose:   req.Purpose,
+		Purpose:   secure.String(req.Purpose),
 		Status:    "active",
 	}
 
 	if err := s.mandateRepo.Create(ctx, mandate); err != nil {
 		return nil, err
 	}
+	s.audit.Record(ctx, "mandate.create", "mandate", mandate.MandateID, nil, mandate)
 	return mandate, nil
 }
 
@@ -225,10 +681,36 @@ func (s *upiService) GetMandates(ctx context.Context, userID string) ([]model.Ma
 	return s.mandateRepo.FindByUserID(ctx, oid)
 }
 
+// defaultVPA returns the VPA flagged as default, falling back to the first one.
+func defaultVPA(vpas []model.VPA) model.VPA {
+	for _, v := range vpas {
+		if v.IsDefault {
+			return v
+		}
+	}
+	return vpas[0]
+}
+
 func generateTxnID() string {
 	return fmt.Sprintf("UPI%d", time.Now().UnixNano())
 }
 
+type txnIDKey struct{}
+
+// withTxnID makes Pay with ctx use txnID, so callers can record the ID before
+// paying and look the payment up after a crash. txn_id is unique, so paying
+// again under the same ID cannot pay twice.
+func withTxnID(ctx context.Context, txnID string) context.Context {
+	return context.WithValue(ctx, txnIDKey{}, txnID)
+}
+
+// generateRRN builds a retrieval reference number in the usual YDDDHH plus
+// six digit sequence layout. In production: the switch assigns the RRN.
+func generateRRN() string {
+	now := time.Now().In(ist)
+	return fmt.Sprintf("%d%03d%02d%06d", now.Year()%10, now.YearDay(), now.Hour(), now.Nanosecond()/1000%1000000)
+}
+
 func generateMandateID() string {
 	return fmt.Sprintf("MND%d", time.Now().UnixNano())
 }
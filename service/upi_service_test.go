@@ -0,0 +1,57 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestTxnCursorRoundTrip(t *testing.T) {
+	id, _ := bson.ObjectIDFromHex("65f1a2b3c4d5e6f708192a3b")
+	tests := []struct {
+		name string
+		date time.Time
+		id   bson.ObjectID
+		want time.Time
+	}{
+		{name: "millisecond date", date: time.UnixMilli(1767225600123), id: id, want: time.UnixMilli(1767225600123)},
+		{name: "finer precision is dropped", date: time.Unix(1767225600, 123456789), id: id, want: time.UnixMilli(1767225600123)},
+		{name: "zone does not matter", date: time.Date(2026, 1, 1, 5, 30, 0, 0, ist), id: id, want: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "zero ID", date: time.UnixMilli(0), want: time.UnixMilli(0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := decodeTxnCursor(encodeTxnCursor(tt.date, tt.id))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !c.Date.Equal(tt.want) || c.ID != tt.id {
+				t.Errorf("cursor = %v %s, want %v %s", c.Date, c.ID.Hex(), tt.want, tt.id.Hex())
+			}
+		})
+	}
+}
+
+func TestDecodeTxnCursorRejects(t *testing.T) {
+	valid := encodeTxnCursor(time.UnixMilli(1767225600123), bson.NewObjectID())
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{name: "not base64", cursor: "!!!"},
+		{name: "padded", cursor: base64.URLEncoding.EncodeToString(make([]byte, 20))},
+		{name: "short", cursor: base64.RawURLEncoding.EncodeToString(make([]byte, 19))},
+		{name: "long", cursor: base64.RawURLEncoding.EncodeToString(make([]byte, 21))},
+		{name: "truncated", cursor: valid[:len(valid)-2]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeTxnCursor(tt.cursor); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("decodeTxnCursor(%q) = %v, want %v", tt.cursor, err, ErrInvalidCursor)
+			}
+		})
+	}
+}
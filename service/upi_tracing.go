@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+
+	"github.com/banking-superapp/upi-service/model"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/banking-superapp/upi-service/service")
+
+// tracedUPIService wraps every UPIService method in a span. Attributes
+// describe the operation, never the payer, payee or amount.
+type tracedUPIService struct {
+	next UPIService
+}
+
+func TraceUPIService(next UPIService) UPIService {
+	return &tracedUPIService{next: next}
+}
+
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (t *tracedUPIService) CreateVPA(ctx context.Context, userID string, req *model.CreateVPARequest) (vpa *model.VPA, err error) {
+	ctx, span := startSpan(ctx, "UPIService.CreateVPA")
+	defer func() { endSpan(span, err) }()
+	return t.next.CreateVPA(ctx, userID, req)
+}
+
+func (t *tracedUPIService) GetVPAs(ctx context.Context, userID string) (vpas []model.VPA, err error) {
+	ctx, span := startSpan(ctx, "UPIService.GetVPAs")
+	defer func() { endSpan(span, err) }()
+	return t.next.GetVPAs(ctx, userID)
+}
+
+func (t *tracedUPIService) ValidateVPA(ctx context.Context, address string) (resp *model.VPAValidateResponse, err error) {
+	ctx, span := startSpan(ctx, "UPIService.ValidateVPA")
+	defer func() { endSpan(span, err) }()
+	return t.next.ValidateVPA(ctx, address)
+}
+
+func (t *tracedUPIService) Pay(ctx context.Context, userID string, req *model.UPIPayRequest) (txn *model.UPITransaction, err error) {
+	ctx, span := startSpan(ctx, "UPIService.Pay",
+		attribute.Bool("upi.lite", req.Lite),
+		attribute.Bool("upi.merchant_order", req.TxnRef != ""))
+	defer func() {
+		if txn != nil {
+			span.SetAttributes(
+				attribute.String("upi.txn_id", txn.TxnID),
+				attribute.String("upi.payment_type", txn.PaymentType),
+				attribute.String("upi.status", txn.Status))
+		}
+		endSpan(span, err)
+	}()
+	return t.next.Pay(ctx, userID, req)
+}
+
+func (t *tracedUPIService) Collect(ctx context.Context, userID string, req *model.CollectRequestInput) (cr *model.CollectRequest, err error) {
+	ctx, span := startSpan(ctx, "UPIService.Collect")
+	defer func() { endSpan(span, err) }()
+	return t.next.Collect(ctx, userID, req)
+}
+
+func (t *tracedUPIService) GetPendingCollects(ctx context.Context, userID string) (crs []model.CollectRequest, err error) {
+	ctx, span := startSpan(ctx, "UPIService.GetPendingCollects")
+	defer func() { endSpan(span, err) }()
+	return t.next.GetPendingCollects(ctx, userID)
+}
+
+func (t *tracedUPIService) RespondCollect(ctx context.Context, userID, collectID string, approve bool) (cr *model.CollectRequest, err error) {
+	ctx, span := startSpan(ctx, "UPIService.RespondCollect", attribute.Bool("upi.approve", approve))
+	defer func() { endSpan(span, err) }()
+	return t.next.RespondCollect(ctx, userID, collectID, approve)
+}
+
+func (t *tracedUPIService) RecoverCollects(ctx context.Context) (err error) {
+	ctx, span := startSpan(ctx, "UPIService.RecoverCollects")
+	defer func() { endSpan(span, err) }()
+	return t.next.RecoverCollects(ctx)
+}
+
+func (t *tracedUPIService) GetTransactions(ctx context.Context, userID string, filter *model.TxnFilter, q *model.TxnPageQuery) (page *model.TxnPage, err error) {
+	ctx, span := startSpan(ctx, "UPIService.GetTransactions")
+	defer func() { endSpan(span, err) }()
+	return t.next.GetTransactions(ctx, userID, filter, q)
+}
+
+func (t *tracedUPIService) CreateMandate(ctx context.Context, userID string, req *model.CreateMandateRequest) (m *model.Mandate, err error) {
+	ctx, span := startSpan(ctx, "UPIService.CreateMandate")
+	defer func() { endSpan(span, err) }()
+	return t.next.CreateMandate(ctx, userID, req)
+}
+
+func (t *tracedUPIService) GetMandates(ctx context.Context, userID string) (ms []model.Mandate, err error) {
+	ctx, span := startSpan(ctx, "UPIService.GetMandates")
+	defer func() { endSpan(span, err) }()
+	return t.next.GetMandates(ctx, userID)
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var ErrCallbackAddress = errors.New("callback URL resolves to a private or reserved address")
+
+// WebhookSender delivers event payloads to merchant supplied callback URLs.
+type WebhookSender interface {
+	// Send POSTs payload to url, signed with the merchant's key.
+	Send(ctx context.Context, url, merchantID string, payload interface{}) error
+	// SigningKey returns the key a merchant verifies its callbacks with.
+	SigningKey(merchantID string) string
+}
+
+type httpWebhookSender struct {
+	client *http.Client
+	secret []byte
+}
+
+// NewWebhookSender signs callbacks with keys derived from secret. Callbacks
+// only reach public addresses, checked when connecting so DNS cannot swap in
+// an internal one, and redirects are not followed.
+func NewWebhookSender(secret string) (WebhookSender, error) {
+	if secret == "" {
+		return nil, errors.New("WEBHOOK_SECRET is not set")
+	}
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: publicAddressOnly}
+	client := &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: 5 * time.Second},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return &httpWebhookSender{client: client, secret: []byte(secret)}, nil
+}
+
+func (s *httpWebhookSender) Send(ctx context.Context, url, merchantID string, payload interface{}) (err error) {
+	ctx, span := tracer.Start(ctx, "webhook POST", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() { endSpan(span, err) }()
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	// Receivers recompute the HMAC over "<timestamp>.<body>" and reject
+	// stale timestamps, so a captured callback cannot be replayed.
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Callback-Timestamp", ts)
+	req.Header.Set("X-Callback-Signature", "sha256="+hex.EncodeToString(hmacSHA256(s.key(merchantID), []byte(ts+"."), body)))
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s returned %d", url, resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *httpWebhookSender) SigningKey(merchantID string) string {
+	return hex.EncodeToString(s.key(merchantID))
+}
+
+func (s *httpWebhookSender) key(merchantID string) []byte {
+	return hmacSHA256(s.secret, []byte("callback:"+merchantID))
+}
+
+func hmacSHA256(key []byte, parts ...[]byte) []byte {
+	h := hmac.New(sha256.New, key)
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return h.Sum(nil)
+}
+
+// publicAddressOnly refuses connections to addresses inside our network or
+// the cloud provider's, such as the metadata service.
+func publicAddressOnly(_, address string, _ syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil || !publicAddr(ap.Addr()) {
+		return ErrCallbackAddress
+	}
+	return nil
+}
+
+// reservedPrefixes are not private by net/netip's definition but are no more
+// reachable from the internet.
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64, which can reach internal IPv4
+}
+
+func publicAddr(a netip.Addr) bool {
+	a = a.Unmap()
+	if !a.IsGlobalUnicast() || a.IsPrivate() {
+		return false
+	}
+	for _, p := range reservedPrefixes {
+		if p.Contains(a) {
+			return false
+		}
+	}
+	return true
+}
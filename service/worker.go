@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// RunPeriodically calls fn every interval until ctx is cancelled, recording a
+// heartbeat in hb on start and after every run. Errors are logged and do not
+// stop the loop.
+func RunPeriodically(ctx context.Context, hb *Heartbeats, name string, interval time.Duration, fn func(context.Context) error) {
+	hb.Beat(name, interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := fn(ctx); err != nil {
+				slog.ErrorContext(ctx, "worker run failed", "worker", name, "error", err)
+			}
+			hb.Beat(name, interval)
+		}
+	}
+}